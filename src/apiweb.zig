@@ -26,6 +26,8 @@ const Events = @import("events/event.zig");
 const XHR = @import("xhr/xhr.zig");
 const Storage = @import("storage/storage.zig");
 const URL = @import("url/url.zig");
+const CSSOM = @import("cssom/cssom.zig");
+const Crypto = @import("crypto/crypto.zig");
 
 pub const HTMLDocument = @import("html/document.zig").HTMLDocument;
 
@@ -38,6 +40,8 @@ pub const Interfaces = generate.Tuple(.{
     XHR.Interfaces,
     Storage.Interfaces,
     URL.Interfaces,
+    CSSOM.Interfaces,
+    Crypto.Interfaces,
 });
 
 pub const UserContext = @import("user_context.zig").UserContext;
@@ -252,7 +252,8 @@ pub const Connection = struct {
 
             switch (err) {
                 error.TlsConnectionTruncated, error.TlsRecordOverflow, error.TlsDecodeError, error.TlsBadRecordMac, error.TlsBadLength, error.TlsIllegalParameter, error.TlsUnexpectedMessage => return error.TlsFailure,
-                error.ConnectionTimedOut => return error.ConnectionTimedOut,
+                // the read timeout is reached.
+                error.ConnectionTimedOut, error.WouldBlock => return error.ConnectionTimedOut,
                 error.ConnectionResetByPeer, error.BrokenPipe => return error.ConnectionResetByPeer,
                 else => return error.UnexpectedReadFailure,
             }
@@ -267,12 +268,28 @@ pub const Connection = struct {
         }
 
         return conn.stream.readv(buffers) catch |err| switch (err) {
-            error.ConnectionTimedOut => return error.ConnectionTimedOut,
+            // the read timeout is reached.
+            error.ConnectionTimedOut, error.WouldBlock => return error.ConnectionTimedOut,
             error.ConnectionResetByPeer, error.BrokenPipe => return error.ConnectionResetByPeer,
             else => return error.UnexpectedReadFailure,
         };
     }
 
+    /// Sets the timeout of the blocking reads, they fail with
+    /// `error.ConnectionTimedOut` once it is reached. 0 means no timeout.
+    pub fn setReadTimeout(conn: *Connection, ms: u64) !void {
+        const timeout = std.posix.timeval{
+            .tv_sec = @intCast(ms / std.time.ms_per_s),
+            .tv_usec = @intCast((ms % std.time.ms_per_s) * std.time.us_per_ms),
+        };
+        try std.posix.setsockopt(
+            conn.stream.handle,
+            std.posix.SOL.SOCKET,
+            std.posix.SO.RCVTIMEO,
+            std.mem.asBytes(&timeout),
+        );
+    }
+
     /// Refills the read buffer with data from the connection.
     pub fn fill(conn: *Connection) ReadError!void {
         if (conn.read_end != conn.read_start) return;
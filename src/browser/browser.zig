@@ -33,9 +33,11 @@ const Env = jsruntime.Env;
 const apiweb = @import("../apiweb.zig");
 
 const Window = @import("../html/window.zig").Window;
+const Navigator = @import("../html/navigator.zig").Navigator;
 const Walker = @import("../dom/walker.zig").WalkerDepthFirst;
 
 const storage = @import("../storage/storage.zig");
+const polyfill = @import("../polyfill/polyfill.zig");
 
 const FetchResult = std.http.Client.FetchResult;
 
@@ -51,13 +53,19 @@ const log = std.log.scoped(.browser);
 pub const Browser = struct {
     session: *Session,
 
-    pub fn init(alloc: std.mem.Allocator, vm: jsruntime.VM) !Browser {
+    // Options are given by the embedder to customize the browser.
+    pub const Options = struct {
+        // navigator contains the values exposed by window.navigator.
+        navigator: Navigator = .{},
+    };
+
+    pub fn init(alloc: std.mem.Allocator, vm: jsruntime.VM, opts: Options) !Browser {
         // We want to ensure the caller initialised a VM, but the browser
         // doesn't use it directly...
         _ = vm;
 
         return Browser{
-            .session = try Session.init(alloc, "about:blank"),
+            .session = try Session.init(alloc, "about:blank", opts),
         };
     }
 
@@ -99,7 +107,7 @@ pub const Session = struct {
 
     jstypes: [Types.len]usize = undefined,
 
-    fn init(alloc: std.mem.Allocator, uri: []const u8) !*Session {
+    fn init(alloc: std.mem.Allocator, uri: []const u8, opts: Browser.Options) !*Session {
         var self = try alloc.create(Session);
         self.* = Session{
             .uri = uri,
@@ -112,6 +120,8 @@ pub const Session = struct {
             .httpClient = undefined,
         };
 
+        self.window.navigator = opts.navigator;
+
         self.env = try Env.init(self.arena.allocator(), &self.loop, null);
         self.httpClient = .{ .allocator = alloc, .loop = &self.loop };
         try self.env.load(&self.jstypes);
@@ -151,6 +161,9 @@ pub const Page = struct {
     rawuri: ?[]const u8 = null,
     uri: std.Uri = undefined,
     origin: ?[]const u8 = null,
+    // opaque_shelf is the storage shelf of the opaque origin, released when
+    // the document is replaced.
+    opaque_shelf: ?*storage.Shelf = null,
 
     raw_data: ?[]const u8 = null,
 
@@ -169,7 +182,10 @@ pub const Page = struct {
 
     // reset js env and mem arena.
     pub fn end(self: *Page) void {
+        self.session.window.clearTimers(&self.session.loop);
+        self.session.window.releaseDocument();
         self.session.env.stop();
+        self.releaseOpaqueShelf();
         // TODO unload document: https://html.spec.whatwg.org/#unloading-documents
 
         // clear netsurf memory arena.
@@ -183,6 +199,15 @@ pub const Page = struct {
         self.session.page = null;
     }
 
+    fn releaseOpaqueShelf(self: *Page) void {
+        const shelf = self.opaque_shelf orelse return;
+        // the storage is not supported until the next document sets its
+        // shelf.
+        if (self.session.window.storageShelf == shelf) self.session.window.setStorageShelf(null);
+        self.session.storageShed.releaseOpaque(shelf);
+        self.opaque_shelf = null;
+    }
+
     // dump writes the page content into the given file.
     pub fn dump(self: *Page, out: std.fs.File) !void {
 
@@ -221,19 +246,16 @@ pub const Page = struct {
 
         log.debug("starting GET {s}", .{uri});
 
+        self.session.window.performance.reset();
+
         // own the url
         if (self.rawuri) |prev| alloc.free(prev);
         self.rawuri = try alloc.dupe(u8, uri);
         self.uri = std.Uri.parse(self.rawuri.?) catch try std.Uri.parseAfterScheme("", self.rawuri.?);
 
         // prepare origin value.
-        var buf = std.ArrayList(u8).init(alloc);
-        defer buf.deinit();
-        try self.uri.writeToStream(.{
-            .scheme = true,
-            .authority = true,
-        }, buf.writer());
-        self.origin = try buf.toOwnedSlice();
+        if (self.origin) |prev| alloc.free(prev);
+        self.origin = try serializeOrigin(alloc, self.uri);
 
         // TODO handle fragment in url.
 
@@ -243,6 +265,8 @@ pub const Page = struct {
 
         const req = resp.req;
 
+        self.session.window.performance.timing.mark("responseStart");
+
         log.info("GET {any} {d}", .{ self.uri, req.response.status });
 
         // TODO handle redirection
@@ -313,9 +337,15 @@ pub const Page = struct {
         // TODO set the referrer to the document.
 
         self.session.window.replaceDocument(html_doc);
-        self.session.window.setStorageShelf(
-            try self.session.storageShed.getOrPut(self.origin orelse "null"),
-        );
+        // storage is partitioned by origin, an opaque origin gets its own
+        // isolated shelf, which is released with the document.
+        self.releaseOpaqueShelf();
+        if (self.origin) |origin| {
+            self.session.window.setStorageShelf(try self.session.storageShed.getOrPut(origin));
+        } else {
+            self.opaque_shelf = try self.session.storageShed.createOpaque();
+            self.session.window.setStorageShelf(self.opaque_shelf.?);
+        }
 
         // https://html.spec.whatwg.org/#read-html
 
@@ -334,6 +364,9 @@ pub const Page = struct {
         log.debug("setup global env", .{});
         try self.session.env.bindGlobal(&self.session.window);
 
+        // load polyfills
+        try polyfill.load(alloc, &self.session.env);
+
         // browse the DOM tree to retrieve scripts
         // TODO execute the synchronous scripts during the HTL parsing.
         // TODO fetch the script resources concurrently but execute them in the
@@ -421,7 +454,10 @@ pub const Page = struct {
         defer parser.eventDestroy(evt);
 
         try parser.eventInit(evt, "DOMContentLoaded", .{ .bubbles = true, .cancelable = true });
+        parser.eventSetTrusted(evt, true);
+        self.session.window.performance.timing.mark("domContentLoadedEventStart");
         _ = try parser.eventTargetDispatchEvent(parser.toEventTarget(parser.DocumentHTML, html_doc), evt);
+        self.session.window.performance.timing.mark("domContentLoadedEventEnd");
 
         // eval async scripts.
         for (sasync.items) |e| {
@@ -437,10 +473,13 @@ pub const Page = struct {
         defer parser.eventDestroy(loadevt);
 
         try parser.eventInit(loadevt, "load", .{});
+        parser.eventSetTrusted(loadevt, true);
+        self.session.window.performance.timing.mark("loadEventStart");
         _ = try parser.eventTargetDispatchEvent(
             parser.toEventTarget(Window, &self.session.window),
             loadevt,
         );
+        self.session.window.performance.timing.mark("loadEventEnd");
     }
 
     // evalScript evaluates the src in priority.
@@ -557,4 +596,50 @@ pub const Page = struct {
 
         return false;
     }
+
+    const tuple_schemes = [_]struct {
+        name: []const u8,
+        port: u16,
+    }{
+        .{ .name = "ftp", .port = 21 },
+        .{ .name = "http", .port = 80 },
+        .{ .name = "https", .port = 443 },
+        .{ .name = "ws", .port = 80 },
+        .{ .name = "wss", .port = 443 },
+    };
+
+    // serializeOrigin returns the serialized origin of the uri: scheme, host
+    // and port. The default port of the scheme is omitted.
+    // It returns null for an opaque origin (eg. data:, about:blank or file:).
+    // https://url.spec.whatwg.org/#concept-url-origin
+    fn serializeOrigin(alloc: std.mem.Allocator, uri: std.Uri) !?[]const u8 {
+        for (tuple_schemes) |scheme| {
+            if (!std.ascii.eqlIgnoreCase(scheme.name, uri.scheme)) continue;
+            if (uri.host == null) return null;
+
+            // the scheme and the host are case insensitive.
+            const host = try std.ascii.allocLowerString(alloc, switch (uri.host.?) {
+                .raw => |v| v,
+                .percent_encoded => |v| v,
+            });
+            defer alloc.free(host);
+
+            var u = uri;
+            u.scheme = scheme.name;
+            u.host = switch (uri.host.?) {
+                .raw => .{ .raw = host },
+                .percent_encoded => .{ .percent_encoded = host },
+            };
+            if (u.port != null and u.port.? == scheme.port) u.port = null;
+
+            var buf = std.ArrayList(u8).init(alloc);
+            defer buf.deinit();
+            try u.writeToStream(.{
+                .scheme = true,
+                .authority = true,
+            }, buf.writer());
+            return try buf.toOwnedSlice();
+        }
+        return null;
+    }
 };
@@ -61,9 +61,16 @@ pub fn writeNode(root: *parser.Node, writer: anytype) !void {
                 // void elements can't have any content.
                 if (try isVoid(parser.nodeToElement(next.?))) continue;
 
-                // write the children
+                // write the children, a template's children are its
+                // contents.
                 // TODO avoid recursion
-                try writeNode(next.?, writer);
+                const tag_type = try parser.elementHTMLGetTagType(@as(*parser.ElementHTML, @ptrCast(next.?)));
+                if (tag_type == .template) {
+                    const content = try parser.templateContent(@as(*parser.Template, @ptrCast(next.?)));
+                    try writeNode(parser.documentFragmentToNode(content), writer);
+                } else {
+                    try writeNode(next.?, writer);
+                }
 
                 // close the tag
                 try writer.writeAll("</");
@@ -18,7 +18,7 @@
 
 const std = @import("std");
 
-const user_agent = "Lightpanda.io/1.0";
+pub const user_agent = "Lightpanda.io/1.0";
 
 pub const Loader = struct {
     client: std.http.Client,
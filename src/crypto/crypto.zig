@@ -0,0 +1,129 @@
+// Copyright (C) 2023-2024  Lightpanda (Selecy SAS)
+//
+// Francis Bouvier <francis@lightpanda.io>
+// Pierre Tachoire <pierre@lightpanda.io>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+const std = @import("std");
+
+const parser = @import("netsurf");
+
+const jsruntime = @import("jsruntime");
+const Case = jsruntime.test_utils.Case;
+const checkCases = jsruntime.test_utils.checkCases;
+
+const generate = @import("../generate.zig");
+
+const DOMException = @import("../dom/exceptions.zig").DOMException;
+
+pub const Interfaces = generate.Tuple(.{
+    Crypto,
+});
+
+// https://w3c.github.io/webcrypto/#crypto-interface
+// getRandomValues is implemented by the crypto polyfill b/c typed arrays are
+// not supported by the native bindings.
+// TODO implement subtle.
+pub const Crypto = struct {
+    pub const mem_guarantied = true;
+    pub const Exception = DOMException;
+
+    // uuid contains the last generated UUID.
+    uuid: [36]u8 = undefined,
+
+    // randomUUID returns a version 4 UUID generated with a cryptographically
+    // secure random source.
+    // https://w3c.github.io/webcrypto/#Crypto-method-randomUUID
+    pub fn _randomUUID(self: *Crypto) []const u8 {
+        var bytes: [16]u8 = undefined;
+        std.crypto.random.bytes(&bytes);
+
+        // Set the 4 most significant bits of bytes[6], which represent the
+        // UUID version, to 0100.
+        bytes[6] = (bytes[6] & 0x0f) | 0x40;
+        // Set the 2 most significant bits of bytes[8], which represent the
+        // UUID variant, to 10.
+        bytes[8] = (bytes[8] & 0x3f) | 0x80;
+
+        const hex = std.fmt.bytesToHex(bytes, .lower);
+        _ = std.fmt.bufPrint(&self.uuid, "{s}-{s}-{s}-{s}-{s}", .{
+            hex[0..8],
+            hex[8..12],
+            hex[12..16],
+            hex[16..20],
+            hex[20..32],
+        }) catch unreachable;
+
+        return &self.uuid;
+    }
+
+    // randomHex returns n random bytes encoded in hexadecimal, used by the
+    // getRandomValues polyfill to fill the typed arrays.
+    // integer is false if the array is not an integer typed array.
+    pub fn _randomHex(_: *Crypto, alloc: std.mem.Allocator, n: u32, integer: bool) ![]const u8 {
+        if (!integer) return parser.DOMError.TypeMismatch;
+        // getRandomValues is limited to 65536 bytes.
+        if (n > 65536) return parser.DOMError.QuotaExceeded;
+
+        const bytes = try alloc.alloc(u8, n);
+        defer alloc.free(bytes);
+        std.crypto.random.bytes(bytes);
+
+        const hex = try alloc.alloc(u8, 2 * n);
+        const charset = "0123456789abcdef";
+        for (bytes, 0..) |b, i| {
+            hex[2 * i] = charset[b >> 4];
+            hex[2 * i + 1] = charset[b & 0x0f];
+        }
+        return hex;
+    }
+};
+
+// Tests
+// -----
+
+pub fn testExecFn(
+    _: std.mem.Allocator,
+    js_env: *jsruntime.Env,
+) anyerror!void {
+    var uuid = [_]Case{
+        .{ .src = "let uuid = crypto.randomUUID()", .ex = "undefined" },
+        .{ .src = "/^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$/.test(uuid)", .ex = "true" },
+        .{ .src = "uuid === crypto.randomUUID()", .ex = "false" },
+        .{ .src = "window.crypto === crypto", .ex = "true" },
+    };
+    try checkCases(js_env, &uuid);
+
+    var random = [_]Case{
+        .{ .src = "let rnd = new Uint8Array(32)", .ex = "undefined" },
+        .{ .src = "crypto.getRandomValues(rnd) === rnd", .ex = "true" },
+        .{ .src = "rnd.some((v) => v !== 0)", .ex = "true" },
+        .{ .src = "crypto.getRandomValues(new Uint32Array(10)).length", .ex = "10" },
+        .{ .src = "crypto.getRandomValues(new BigInt64Array(2)).length", .ex = "2" },
+        .{ .src = "crypto.getRandomValues(new Uint8Array(65536)).length", .ex = "65536" },
+        .{ .src = "var err; try { crypto.getRandomValues(new Uint8Array(65537)) } catch (e) { err = e } err.name", .ex = "QuotaExceededError" },
+        .{ .src = "try { crypto.getRandomValues(new Float32Array(2)) } catch (e) { err = e } err.name", .ex = "TypeMismatchError" },
+        .{ .src = "try { crypto.getRandomValues([1, 2]) } catch (e) { err = e } err.name", .ex = "TypeMismatchError" },
+        .{ .src = "err instanceof DOMException", .ex = "true" },
+        .{ .src = "err.code", .ex = "17" },
+        .{ .src = "try { crypto.getRandomValues(new Uint32Array(16385)) } catch (e) { err = e } err.name", .ex = "QuotaExceededError" },
+        .{ .src = "err instanceof DOMException", .ex = "true" },
+        .{ .src = "err.code", .ex = "22" },
+        // the native random source is hidden.
+        .{ .src = "crypto.randomHex", .ex = "undefined" },
+        .{ .src = "let rndview = new Uint8Array(new ArrayBuffer(8), 2, 4); crypto.getRandomValues(rndview).length", .ex = "4" },
+    };
+    try checkCases(js_env, &random);
+}
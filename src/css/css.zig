@@ -20,7 +20,7 @@
 // This package is a rewrite in Zig of Cascadia CSS Selector parser.
 // see https://github.com/andybalholm/cascadia
 const std = @import("std");
-const Selector = @import("selector.zig").Selector;
+pub const Selector = @import("selector.zig").Selector;
 const parser = @import("parser.zig");
 
 // parse parse a selector string and returns the parsed result or an error.
@@ -174,3 +174,34 @@ test "parse" {
         defer s.deinit(alloc);
     }
 }
+
+test "specificity" {
+    const alloc = std.testing.allocator;
+
+    const testcases = [_]struct {
+        q: []const u8,
+        exp: Selector.Specificity,
+    }{
+        .{ .q = "*", .exp = .{ 0, 0, 0 } },
+        .{ .q = "li", .exp = .{ 0, 0, 1 } },
+        .{ .q = "ul li", .exp = .{ 0, 0, 2 } },
+        .{ .q = "ul ol+li", .exp = .{ 0, 0, 3 } },
+        .{ .q = "h1 + *[rel=up]", .exp = .{ 0, 1, 1 } },
+        .{ .q = "ul ol li.red", .exp = .{ 0, 1, 3 } },
+        .{ .q = "li.red.level", .exp = .{ 0, 2, 1 } },
+        .{ .q = "#x34y", .exp = .{ 1, 0, 0 } },
+        .{ .q = "#s12:not(FOO)", .exp = .{ 1, 0, 1 } },
+        .{ .q = "div.card[data-id]", .exp = .{ 0, 2, 1 } },
+        .{ .q = "p, #foo", .exp = .{ 1, 0, 0 } },
+    };
+
+    for (testcases) |tc| {
+        const s = try parse(alloc, tc.q, .{});
+        defer s.deinit(alloc);
+
+        std.testing.expectEqual(tc.exp, s.specificity()) catch |e| {
+            std.debug.print("query: {s}\n", .{tc.q});
+            return e;
+        };
+    }
+}
@@ -732,6 +732,52 @@ pub const Selector = union(enum) {
         return false;
     }
 
+    // Specificity is the number of id selectors, the number of class,
+    // attribute and pseudo-class selectors and the number of type and
+    // pseudo-element selectors.
+    pub const Specificity = [3]u32;
+
+    fn addSpecificity(a: Specificity, b: Specificity) Specificity {
+        return .{ a[0] + b[0], a[1] + b[1], a[2] + b[2] };
+    }
+
+    // specificity returns the selector's specificity.
+    // https://www.w3.org/TR/selectors-4/#specificity-rules
+    pub fn specificity(s: Selector) Specificity {
+        return switch (s) {
+            .id => .{ 1, 0, 0 },
+            .class,
+            .attribute,
+            .pseudo_class,
+            .pseudo_class_only_child,
+            .pseudo_class_lang,
+            .pseudo_class_contains,
+            .pseudo_class_regexp,
+            .pseudo_class_nth,
+            .never_match,
+            => .{ 0, 1, 0 },
+            .tag, .pseudo_element => .{ 0, 0, 1 },
+            .compound => |v| {
+                var res: Specificity = .{ 0, 0, 0 };
+                for (v.selectors) |sel| res = addSpecificity(res, sel.specificity());
+                if (v.pseudo_elt != null) res = addSpecificity(res, .{ 0, 0, 1 });
+                return res;
+            },
+            .combined => |v| addSpecificity(v.first.specificity(), v.second.specificity()),
+            // :not() and :has() take the specificity of their argument.
+            .pseudo_class_relative => |v| v.match.specificity(),
+            // a group takes the greatest specificity of its selectors.
+            .group => |v| {
+                var res: Specificity = .{ 0, 0, 0 };
+                for (v) |sel| {
+                    const ss = sel.specificity();
+                    if (std.mem.order(u32, &res, &ss) == .lt) res = ss;
+                }
+                return res;
+            },
+        };
+    }
+
     pub fn deinit(sel: Selector, alloc: std.mem.Allocator) void {
         switch (sel) {
             .group => |v| {
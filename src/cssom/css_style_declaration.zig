@@ -0,0 +1,963 @@
+// Copyright (C) 2023-2024  Lightpanda (Selecy SAS)
+//
+// Francis Bouvier <francis@lightpanda.io>
+// Pierre Tachoire <pierre@lightpanda.io>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+const std = @import("std");
+
+const parser = @import("netsurf");
+
+const jsruntime = @import("jsruntime");
+const Case = jsruntime.test_utils.Case;
+const checkCases = jsruntime.test_utils.checkCases;
+
+const css = @import("../css/css.zig");
+const Node = @import("../css/libdom.zig").Node;
+
+const collection = @import("../dom/html_collection.zig");
+const DOMException = @import("../dom/exceptions.zig").DOMException;
+
+// Property lists the supported CSS properties.
+// TODO support more properties.
+const Property = enum(u8) {
+    display,
+    visibility,
+    color,
+    font_size,
+    width,
+    height,
+
+    fn fromName(name: []const u8) ?Property {
+        for (properties, 0..) |p, i| {
+            if (std.ascii.eqlIgnoreCase(p.name, name)) return @enumFromInt(i);
+        }
+        return null;
+    }
+
+    fn def(p: Property) PropertyDef {
+        return properties[@intFromEnum(p)];
+    }
+};
+
+const PropertyDef = struct {
+    name: []const u8,
+    // initial is the computed initial value.
+    initial: []const u8,
+    inherited: bool,
+};
+
+// properties' definitions, in the Property enum order.
+// https://www.w3.org/TR/CSS22/propidx.html
+const properties = [_]PropertyDef{
+    .{ .name = "display", .initial = "inline", .inherited = false },
+    .{ .name = "visibility", .initial = "visible", .inherited = true },
+    .{ .name = "color", .initial = "rgb(0, 0, 0)", .inherited = true },
+    // medium
+    .{ .name = "font-size", .initial = "16px", .inherited = true },
+    .{ .name = "width", .initial = "auto", .inherited = false },
+    .{ .name = "height", .initial = "auto", .inherited = false },
+};
+
+// WEB IDL https://drafts.csswg.org/cssom/#the-cssstyledeclaration-interface
+// For now, CSSStyleDeclaration is only returned by getComputedStyle and is
+// read-only.
+pub const CSSStyleDeclaration = struct {
+    pub const mem_guarantied = true;
+    pub const Exception = DOMException;
+
+    // values contains the owned computed values, in the Property enum order.
+    values: [properties.len][]const u8,
+
+    // computed returns the computed style of the element.
+    // The values take into account the cascade of the document's style
+    // elements, the inline style and the inheritance.
+    // The rules of the style elements are cached into sheets.
+    // https://drafts.csswg.org/cssom/#dom-window-getcomputedstyle
+    pub fn computed(alloc: std.mem.Allocator, sheets: *StyleSheets, e: *parser.Element) !CSSStyleDeclaration {
+        // all the cascade intermediate allocations are done into an arena.
+        var arena = std.heap.ArenaAllocator.init(alloc);
+        defer arena.deinit();
+
+        var cascade = Cascade{
+            .alloc = arena.allocator(),
+            .rules = try sheets.get(alloc, arena.allocator(), e),
+        };
+
+        var self = CSSStyleDeclaration{ .values = undefined };
+        var i: usize = 0;
+        errdefer for (self.values[0..i]) |v| alloc.free(v);
+
+        while (i < properties.len) : (i += 1) {
+            const p: Property = @enumFromInt(i);
+            var v = try cascade.computed(e, p);
+            if (p == .color) v = try serializeColor(arena.allocator(), v);
+            self.values[i] = try alloc.dupe(u8, v);
+        }
+
+        return self;
+    }
+
+    pub fn get_length(_: *CSSStyleDeclaration) u32 {
+        return properties.len;
+    }
+
+    pub fn _item(_: *CSSStyleDeclaration, index: u32) []const u8 {
+        if (index >= properties.len) return "";
+        return properties[index].name;
+    }
+
+    pub fn _getPropertyValue(self: *CSSStyleDeclaration, name: []const u8) []const u8 {
+        const p = Property.fromName(name) orelse return "";
+        return self.values[@intFromEnum(p)];
+    }
+
+    // https://drafts.csswg.org/cssom/#dom-cssstyledeclaration-setproperty
+    pub fn _setProperty(_: *CSSStyleDeclaration, _: []const u8, _: []const u8) !void {
+        // If the computed flag is set, then throw a NoModificationAllowedError
+        // exception.
+        return parser.DOMError.NoModificationAllowed;
+    }
+
+    pub fn get_display(self: *CSSStyleDeclaration) []const u8 {
+        return self.values[@intFromEnum(Property.display)];
+    }
+
+    pub fn get_visibility(self: *CSSStyleDeclaration) []const u8 {
+        return self.values[@intFromEnum(Property.visibility)];
+    }
+
+    pub fn get_color(self: *CSSStyleDeclaration) []const u8 {
+        return self.values[@intFromEnum(Property.color)];
+    }
+
+    pub fn get_fontSize(self: *CSSStyleDeclaration) []const u8 {
+        return self.values[@intFromEnum(Property.font_size)];
+    }
+
+    pub fn get_width(self: *CSSStyleDeclaration) []const u8 {
+        return self.values[@intFromEnum(Property.width)];
+    }
+
+    pub fn get_height(self: *CSSStyleDeclaration) []const u8 {
+        return self.values[@intFromEnum(Property.height)];
+    }
+
+    pub fn deinit(self: *CSSStyleDeclaration, alloc: std.mem.Allocator) void {
+        for (self.values) |v| alloc.free(v);
+    }
+};
+
+const Declaration = struct {
+    property: Property,
+    value: []const u8,
+    important: bool,
+};
+
+const Rule = struct {
+    selector: css.Selector,
+    declarations: []const Declaration,
+};
+
+// Rank is used to sort declarations in the cascade.
+// https://www.w3.org/TR/css-cascade-4/#cascade-sort
+const Rank = struct {
+    important: bool = false,
+    @"inline": bool = false,
+    specificity: css.Selector.Specificity = .{ 0, 0, 0 },
+    order: usize = 0,
+
+    // lessThan returns true if the rank a loses against the rank b.
+    fn lessThan(a: Rank, b: Rank) bool {
+        if (a.important != b.important) return b.important;
+        if (a.@"inline" != b.@"inline") return b.@"inline";
+        switch (std.mem.order(u32, &a.specificity, &b.specificity)) {
+            .lt => return true,
+            .gt => return false,
+            .eq => return a.order < b.order,
+        }
+    }
+};
+
+// StyleSheets caches the rules of the document's style elements.
+// The style elements' sources are read again only after a DOM change, and the
+// rules are parsed again only when these sources change.
+// TODO support linked stylesheets and media queries.
+pub const StyleSheets = struct {
+    arena: ?std.heap.ArenaAllocator = null,
+    doc: ?*parser.Document = null,
+    // tree version at which the sources were read.
+    version: u64 = 0,
+    // hash of the style elements' sources used to parse the rules.
+    hash: u64 = 0,
+    rules: []const Rule = &.{},
+
+    pub fn reset(self: *StyleSheets) void {
+        if (self.arena) |*a| a.deinit();
+        self.* = .{};
+    }
+
+    // get returns the rules of the element's document.
+    // The rules are owned by the cache, tmp is used for the intermediate
+    // allocations.
+    fn get(self: *StyleSheets, alloc: std.mem.Allocator, tmp: std.mem.Allocator, e: *parser.Element) ![]const Rule {
+        const doc = try parser.nodeOwnerDocument(parser.elementToNode(e)) orelse return &.{};
+
+        const version = parser.treeVersion();
+        if (self.arena != null and self.doc == doc and self.version == version) return self.rules;
+
+        var sources: std.ArrayListUnmanaged([]const u8) = .{};
+        var hasher = std.hash.Wyhash.init(0);
+
+        var styles = try collection.HTMLCollectionByTagName(tmp, parser.documentToNode(doc), "style", false);
+        const ln = try styles.get_length();
+        var i: u32 = 0;
+        while (i < ln) : (i += 1) {
+            const style = try styles.item(i) orelse continue;
+            const src = try parser.nodeTextContent(style) orelse continue;
+            try sources.append(tmp, src);
+            hasher.update(std.mem.asBytes(&src.len));
+            hasher.update(src);
+        }
+        const hash = hasher.final();
+
+        if (self.arena != null and self.doc == doc and self.hash == hash) {
+            self.version = version;
+            return self.rules;
+        }
+
+        self.reset();
+        self.arena = std.heap.ArenaAllocator.init(alloc);
+        const arena = self.arena.?.allocator();
+
+        var rules: std.ArrayListUnmanaged(Rule) = .{};
+        for (sources.items) |src| try parseStyleSheet(arena, &rules, src);
+
+        self.doc = doc;
+        self.version = version;
+        self.hash = hash;
+        self.rules = rules.items;
+        return self.rules;
+    }
+};
+
+// Cascade computes the values from the document's rules.
+const Cascade = struct {
+    alloc: std.mem.Allocator,
+    rules: []const Rule,
+
+    // declared returns the cascaded value of the property for the element or
+    // null if no declaration applies.
+    fn declared(self: *Cascade, e: *parser.Element, p: Property) !?[]const u8 {
+        var value: ?[]const u8 = null;
+        var rank = Rank{};
+
+        const n = Node{ .node = parser.elementToNode(e) };
+        for (self.rules, 0..) |rule, order| {
+            const spec = try matchSpecificity(rule.selector, n) orelse continue;
+            for (rule.declarations) |d| {
+                if (d.property != p) continue;
+
+                const r = Rank{ .important = d.important, .specificity = spec, .order = order };
+                if (value == null or !r.lessThan(rank)) {
+                    value = d.value;
+                    rank = r;
+                }
+            }
+        }
+
+        // inline style
+        const style = try parser.elementGetAttribute(e, "style") orelse return value;
+        for (try parseDeclarations(self.alloc, style)) |d| {
+            if (d.property != p) continue;
+
+            const r = Rank{ .important = d.important, .@"inline" = true, .order = self.rules.len };
+            if (value == null or !r.lessThan(rank)) {
+                value = d.value;
+                rank = r;
+            }
+        }
+
+        return value;
+    }
+
+    // computed returns the specified value with the lengths resolved in px.
+    // The percentages of width and height are kept: they depend on the
+    // layout, which is not supported.
+    // https://www.w3.org/TR/css-cascade-4/#computed
+    fn computed(self: *Cascade, e: *parser.Element, p: Property) anyerror![]const u8 {
+        const v = try self.specified(e, p);
+        return switch (p) {
+            .font_size => try self.resolveFontSize(e, v),
+            .width, .height => try self.resolveLength(e, v),
+            else => v,
+        };
+    }
+
+    // https://www.w3.org/TR/css-cascade-4/#specified
+    fn specified(self: *Cascade, e: *parser.Element, p: Property) anyerror![]const u8 {
+        const def = p.def();
+
+        if (try self.declared(e, p)) |v| {
+            if (std.ascii.eqlIgnoreCase(v, "inherit")) return self.inherited(e, p);
+            if (std.ascii.eqlIgnoreCase(v, "initial")) return def.initial;
+            if (std.ascii.eqlIgnoreCase(v, "unset")) {
+                if (def.inherited) return self.inherited(e, p);
+                return def.initial;
+            }
+            // currentcolor of the color property is the inherited color.
+            if (p == .color and std.ascii.eqlIgnoreCase(v, "currentcolor")) return self.inherited(e, p);
+            return v;
+        }
+
+        if (def.inherited) return self.inherited(e, p);
+        if (p == .display) return try defaultDisplay(e);
+        return def.initial;
+    }
+
+    fn inherited(self: *Cascade, e: *parser.Element, p: Property) ![]const u8 {
+        const parent = try parser.nodeParentElement(parser.elementToNode(e)) orelse return p.def().initial;
+        return self.computed(parent, p);
+    }
+
+    // resolveFontSize resolves the keywords, the relative sizes and the
+    // lengths in px. The unsupported values are returned unchanged.
+    // https://drafts.csswg.org/css-fonts/#font-size-prop
+    fn resolveFontSize(self: *Cascade, e: *parser.Element, v: []const u8) ![]const u8 {
+        for (font_sizes) |fs| {
+            if (std.ascii.eqlIgnoreCase(fs.name, v)) return try serializePx(self.alloc, fs.px);
+        }
+
+        const parent = try parser.nodeParentElement(parser.elementToNode(e));
+        const base = if (parent) |pe| try self.fontSize(pe) else initial_font_size;
+        if (std.ascii.eqlIgnoreCase(v, "smaller")) return try serializePx(self.alloc, base / font_size_ratio);
+        if (std.ascii.eqlIgnoreCase(v, "larger")) return try serializePx(self.alloc, base * font_size_ratio);
+
+        const l = parseLength(v) orelse return v;
+        const px = switch (l.unit) {
+            .px => l.value,
+            .em => l.value * base,
+            .percent => l.value * base / 100,
+            .rem => l.value * try self.rootFontSize(e),
+        };
+        return try serializePx(self.alloc, px);
+    }
+
+    // resolveLength resolves the lengths in px, the relative ones against
+    // the element's font size. The other values are returned unchanged.
+    // https://drafts.csswg.org/css-values/#lengths
+    fn resolveLength(self: *Cascade, e: *parser.Element, v: []const u8) ![]const u8 {
+        const l = parseLength(v) orelse return v;
+        const px = switch (l.unit) {
+            .px => l.value,
+            .em => l.value * try self.fontSize(e),
+            .rem => l.value * try self.rootFontSize(e),
+            .percent => return v,
+        };
+        return try serializePx(self.alloc, px);
+    }
+
+    // fontSize returns the computed font size of the element in px.
+    fn fontSize(self: *Cascade, e: *parser.Element) !f64 {
+        const l = parseLength(try self.computed(e, .font_size)) orelse return initial_font_size;
+        if (l.unit != .px) return initial_font_size;
+        return l.value;
+    }
+
+    // rootFontSize returns the font size of the document element in px. The
+    // rem of the document element itself are relative to the initial size.
+    fn rootFontSize(self: *Cascade, e: *parser.Element) !f64 {
+        const doc = try parser.nodeOwnerDocument(parser.elementToNode(e)) orelse return initial_font_size;
+        const root = try parser.documentGetDocumentElement(doc) orelse return initial_font_size;
+        if (root == e) return initial_font_size;
+        return try self.fontSize(root);
+    }
+};
+
+// initial_font_size is the medium font size in px.
+const initial_font_size: f64 = 16;
+
+// font_size_ratio is the scaling factor of the smaller and larger keywords.
+const font_size_ratio: f64 = 1.2;
+
+// font_sizes are the absolute size keywords.
+// https://drafts.csswg.org/css-fonts/#absolute-size-mapping
+const font_sizes = [_]struct {
+    name: []const u8,
+    px: f64,
+}{
+    .{ .name = "xx-small", .px = 9 },
+    .{ .name = "x-small", .px = 10 },
+    .{ .name = "small", .px = 13 },
+    .{ .name = "medium", .px = 16 },
+    .{ .name = "large", .px = 18 },
+    .{ .name = "x-large", .px = 24 },
+    .{ .name = "xx-large", .px = 32 },
+    .{ .name = "xxx-large", .px = 48 },
+};
+
+const Length = struct {
+    value: f64,
+    unit: Unit,
+
+    // The absolute units are converted in px.
+    const Unit = enum { px, em, rem, percent };
+};
+
+// parseLength parses a length or a percentage, or returns null.
+// https://drafts.csswg.org/css-values/#absolute-lengths
+fn parseLength(v: []const u8) ?Length {
+    var end: usize = 0;
+    while (end < v.len) : (end += 1) {
+        switch (v[end]) {
+            '0'...'9', '.', '+', '-' => {},
+            else => break,
+        }
+    }
+    const value = std.fmt.parseFloat(f64, v[0..end]) catch return null;
+    const unit = v[end..];
+
+    // the unit is optional for zero.
+    if (unit.len == 0) {
+        if (value != 0) return null;
+        return .{ .value = 0, .unit = .px };
+    }
+    if (std.mem.eql(u8, unit, "%")) return .{ .value = value, .unit = .percent };
+    if (std.ascii.eqlIgnoreCase(unit, "em")) return .{ .value = value, .unit = .em };
+    if (std.ascii.eqlIgnoreCase(unit, "rem")) return .{ .value = value, .unit = .rem };
+
+    for (absolute_units) |u| {
+        if (std.ascii.eqlIgnoreCase(u.name, unit)) return .{ .value = value * u.px, .unit = .px };
+    }
+    return null;
+}
+
+const absolute_units = [_]struct {
+    name: []const u8,
+    px: f64,
+}{
+    .{ .name = "px", .px = 1 },
+    .{ .name = "in", .px = 96 },
+    .{ .name = "cm", .px = 96.0 / 2.54 },
+    .{ .name = "mm", .px = 96.0 / 25.4 },
+    .{ .name = "q", .px = 96.0 / 101.6 },
+    .{ .name = "pt", .px = 96.0 / 72.0 },
+    .{ .name = "pc", .px = 16 },
+};
+
+// serializePx serializes a length in px, rounded like the colors' alpha.
+fn serializePx(alloc: std.mem.Allocator, px: f64) ![]const u8 {
+    return try std.fmt.allocPrint(alloc, "{d}px", .{@round(px * 1000) / 1000});
+}
+
+// matchSpecificity returns the specificity of the selector if it matches the
+// node, or null otherwise.
+// For a group, the specificity is the greatest one of the matching selectors.
+fn matchSpecificity(sel: css.Selector, n: Node) !?css.Selector.Specificity {
+    switch (sel) {
+        .group => |v| {
+            var res: ?css.Selector.Specificity = null;
+            for (v) |s| {
+                if (!try s.match(n)) continue;
+                const ss = s.specificity();
+                if (res == null or std.mem.order(u32, &res.?, &ss) == .lt) res = ss;
+            }
+            return res;
+        },
+        else => {
+            if (!try sel.match(n)) return null;
+            return sel.specificity();
+        },
+    }
+}
+
+const whitespaces = " \t\r\n";
+
+// parseDeclarations parses a declarations block.
+// The unsupported properties are ignored.
+fn parseDeclarations(alloc: std.mem.Allocator, block: []const u8) ![]const Declaration {
+    var list: std.ArrayListUnmanaged(Declaration) = .{};
+
+    var it = std.mem.splitScalar(u8, block, ';');
+    while (it.next()) |decl| {
+        const sep = std.mem.indexOfScalar(u8, decl, ':') orelse continue;
+        const name = std.mem.trim(u8, decl[0..sep], whitespaces);
+        const property = Property.fromName(name) orelse continue;
+
+        var value = std.mem.trim(u8, decl[sep + 1 ..], whitespaces);
+        var important = false;
+        if (std.mem.lastIndexOfScalar(u8, value, '!')) |i| {
+            if (std.ascii.eqlIgnoreCase(std.mem.trim(u8, value[i + 1 ..], whitespaces), "important")) {
+                important = true;
+                value = std.mem.trimRight(u8, value[0..i], whitespaces);
+            }
+        }
+        if (value.len == 0) continue;
+
+        try list.append(alloc, .{
+            .property = property,
+            .value = value,
+            .important = important,
+        });
+    }
+
+    return list.items;
+}
+
+// parseStyleSheet appends the style rules of the source to the list.
+// Comments, at-rules and rules with an invalid selector are ignored.
+fn parseStyleSheet(alloc: std.mem.Allocator, rules: *std.ArrayListUnmanaged(Rule), source: []const u8) !void {
+    // remove the comments
+    var buf: std.ArrayListUnmanaged(u8) = .{};
+    var rest = source;
+    while (std.mem.indexOf(u8, rest, "/*")) |start| {
+        try buf.appendSlice(alloc, rest[0..start]);
+        const end = std.mem.indexOfPos(u8, rest, start + 2, "*/") orelse {
+            rest = "";
+            break;
+        };
+        rest = rest[end + 2 ..];
+    }
+    try buf.appendSlice(alloc, rest);
+
+    var s: []const u8 = buf.items;
+    while (true) {
+        s = std.mem.trimLeft(u8, s, whitespaces);
+        if (s.len == 0) return;
+
+        const open = std.mem.indexOfScalar(u8, s, '{');
+
+        if (s[0] == '@') {
+            // at-rules are ignored, skip the statement or the whole block.
+            const semi = std.mem.indexOfScalar(u8, s, ';');
+            if (open == null or (semi != null and semi.? < open.?)) {
+                s = if (semi) |i| s[i + 1 ..] else "";
+                continue;
+            }
+            s = s[blockEnd(s, open.?)..];
+            continue;
+        }
+
+        const o = open orelse return;
+        const close = std.mem.indexOfScalarPos(u8, s, o, '}') orelse s.len;
+        const selector = std.mem.trim(u8, s[0..o], whitespaces);
+        const block = s[o + 1 .. close];
+        s = if (close < s.len) s[close + 1 ..] else "";
+
+        const sel = css.parse(alloc, selector, .{}) catch continue;
+        try rules.append(alloc, .{
+            .selector = sel,
+            .declarations = try parseDeclarations(alloc, block),
+        });
+    }
+}
+
+// blockEnd returns the position following the block closing brace matching
+// the opening brace at the given position.
+fn blockEnd(s: []const u8, open: usize) usize {
+    var depth: usize = 0;
+    for (s[open..], open..) |c, i| {
+        switch (c) {
+            '{' => depth += 1,
+            '}' => {
+                depth -= 1;
+                if (depth == 0) return i + 1;
+            },
+            else => {},
+        }
+    }
+    return s.len;
+}
+
+const Color = struct {
+    r: u8,
+    g: u8,
+    b: u8,
+    a: f64 = 1,
+};
+
+// serializeColor returns the color serialized with rgb() or rgba(), like the
+// initial color. The unsupported values are returned unchanged.
+// https://drafts.csswg.org/css-color/#serializing-sRGB-values
+fn serializeColor(alloc: std.mem.Allocator, v: []const u8) ![]const u8 {
+    const c = parseColor(v) orelse return v;
+    if (c.a >= 1) return try std.fmt.allocPrint(alloc, "rgb({d}, {d}, {d})", .{ c.r, c.g, c.b });
+
+    const a = @round(c.a * 1000) / 1000;
+    return try std.fmt.allocPrint(alloc, "rgba({d}, {d}, {d}, {d})", .{ c.r, c.g, c.b, a });
+}
+
+// parseColor parses the hex, rgb(), rgba() and named colors.
+// https://drafts.csswg.org/css-color/#color-syntax
+fn parseColor(v: []const u8) ?Color {
+    if (v.len > 0 and v[0] == '#') return parseHexColor(v[1..]);
+    if (std.mem.indexOfScalar(u8, v, '(')) |open| return parseRGBColor(v, open);
+    if (std.ascii.eqlIgnoreCase(v, "transparent")) return .{ .r = 0, .g = 0, .b = 0, .a = 0 };
+
+    for (named_colors) |nc| {
+        if (!std.ascii.eqlIgnoreCase(nc.name, v)) continue;
+        return .{ .r = @truncate(nc.rgb >> 16), .g = @truncate(nc.rgb >> 8), .b = @truncate(nc.rgb) };
+    }
+    return null;
+}
+
+fn parseHexColor(h: []const u8) ?Color {
+    var d: [4]u8 = .{ 255, 255, 255, 255 };
+    switch (h.len) {
+        3, 4 => for (h, 0..) |c, i| {
+            d[i] = 17 * (std.fmt.charToDigit(c, 16) catch return null);
+        },
+        6, 8 => for (0..h.len / 2) |i| {
+            d[i] = std.fmt.parseInt(u8, h[2 * i .. 2 * i + 2], 16) catch return null;
+        },
+        else => return null,
+    }
+    return .{ .r = d[0], .g = d[1], .b = d[2], .a = @as(f64, @floatFromInt(d[3])) / 255 };
+}
+
+// parseRGBColor parses the legacy comma separated and the modern space
+// separated syntaxes of rgb() and rgba().
+fn parseRGBColor(v: []const u8, open: usize) ?Color {
+    const name = std.mem.trim(u8, v[0..open], whitespaces);
+    if (!std.ascii.eqlIgnoreCase(name, "rgb") and !std.ascii.eqlIgnoreCase(name, "rgba")) return null;
+    if (v[v.len - 1] != ')') return null;
+
+    var values: [4]f64 = undefined;
+    var n: usize = 0;
+    var it = std.mem.tokenizeAny(u8, v[open + 1 .. v.len - 1], ", /" ++ whitespaces);
+    while (it.next()) |tok| : (n += 1) {
+        if (n == values.len) return null;
+        values[n] = parseColorComponent(tok, if (n < 3) 255 else 1) orelse return null;
+    }
+    if (n < 3) return null;
+
+    return .{
+        .r = channel(values[0]),
+        .g = channel(values[1]),
+        .b = channel(values[2]),
+        .a = if (n == 4) std.math.clamp(values[3], 0, 1) else 1,
+    };
+}
+
+// parseColorComponent parses a number or a percentage of max.
+fn parseColorComponent(tok: []const u8, max: f64) ?f64 {
+    if (std.mem.endsWith(u8, tok, "%")) {
+        const p = std.fmt.parseFloat(f64, tok[0 .. tok.len - 1]) catch return null;
+        return p * max / 100;
+    }
+    return std.fmt.parseFloat(f64, tok) catch null;
+}
+
+fn channel(v: f64) u8 {
+    return @intFromFloat(@round(std.math.clamp(v, 0, 255)));
+}
+
+// https://drafts.csswg.org/css-color/#named-colors
+const named_colors = [_]struct {
+    name: []const u8,
+    rgb: u24,
+}{
+    .{ .name = "aliceblue", .rgb = 0xf0f8ff },
+    .{ .name = "antiquewhite", .rgb = 0xfaebd7 },
+    .{ .name = "aqua", .rgb = 0x00ffff },
+    .{ .name = "aquamarine", .rgb = 0x7fffd4 },
+    .{ .name = "azure", .rgb = 0xf0ffff },
+    .{ .name = "beige", .rgb = 0xf5f5dc },
+    .{ .name = "bisque", .rgb = 0xffe4c4 },
+    .{ .name = "black", .rgb = 0x000000 },
+    .{ .name = "blanchedalmond", .rgb = 0xffebcd },
+    .{ .name = "blue", .rgb = 0x0000ff },
+    .{ .name = "blueviolet", .rgb = 0x8a2be2 },
+    .{ .name = "brown", .rgb = 0xa52a2a },
+    .{ .name = "burlywood", .rgb = 0xdeb887 },
+    .{ .name = "cadetblue", .rgb = 0x5f9ea0 },
+    .{ .name = "chartreuse", .rgb = 0x7fff00 },
+    .{ .name = "chocolate", .rgb = 0xd2691e },
+    .{ .name = "coral", .rgb = 0xff7f50 },
+    .{ .name = "cornflowerblue", .rgb = 0x6495ed },
+    .{ .name = "cornsilk", .rgb = 0xfff8dc },
+    .{ .name = "crimson", .rgb = 0xdc143c },
+    .{ .name = "cyan", .rgb = 0x00ffff },
+    .{ .name = "darkblue", .rgb = 0x00008b },
+    .{ .name = "darkcyan", .rgb = 0x008b8b },
+    .{ .name = "darkgoldenrod", .rgb = 0xb8860b },
+    .{ .name = "darkgray", .rgb = 0xa9a9a9 },
+    .{ .name = "darkgreen", .rgb = 0x006400 },
+    .{ .name = "darkgrey", .rgb = 0xa9a9a9 },
+    .{ .name = "darkkhaki", .rgb = 0xbdb76b },
+    .{ .name = "darkmagenta", .rgb = 0x8b008b },
+    .{ .name = "darkolivegreen", .rgb = 0x556b2f },
+    .{ .name = "darkorange", .rgb = 0xff8c00 },
+    .{ .name = "darkorchid", .rgb = 0x9932cc },
+    .{ .name = "darkred", .rgb = 0x8b0000 },
+    .{ .name = "darksalmon", .rgb = 0xe9967a },
+    .{ .name = "darkseagreen", .rgb = 0x8fbc8f },
+    .{ .name = "darkslateblue", .rgb = 0x483d8b },
+    .{ .name = "darkslategray", .rgb = 0x2f4f4f },
+    .{ .name = "darkslategrey", .rgb = 0x2f4f4f },
+    .{ .name = "darkturquoise", .rgb = 0x00ced1 },
+    .{ .name = "darkviolet", .rgb = 0x9400d3 },
+    .{ .name = "deeppink", .rgb = 0xff1493 },
+    .{ .name = "deepskyblue", .rgb = 0x00bfff },
+    .{ .name = "dimgray", .rgb = 0x696969 },
+    .{ .name = "dimgrey", .rgb = 0x696969 },
+    .{ .name = "dodgerblue", .rgb = 0x1e90ff },
+    .{ .name = "firebrick", .rgb = 0xb22222 },
+    .{ .name = "floralwhite", .rgb = 0xfffaf0 },
+    .{ .name = "forestgreen", .rgb = 0x228b22 },
+    .{ .name = "fuchsia", .rgb = 0xff00ff },
+    .{ .name = "gainsboro", .rgb = 0xdcdcdc },
+    .{ .name = "ghostwhite", .rgb = 0xf8f8ff },
+    .{ .name = "gold", .rgb = 0xffd700 },
+    .{ .name = "goldenrod", .rgb = 0xdaa520 },
+    .{ .name = "gray", .rgb = 0x808080 },
+    .{ .name = "green", .rgb = 0x008000 },
+    .{ .name = "greenyellow", .rgb = 0xadff2f },
+    .{ .name = "grey", .rgb = 0x808080 },
+    .{ .name = "honeydew", .rgb = 0xf0fff0 },
+    .{ .name = "hotpink", .rgb = 0xff69b4 },
+    .{ .name = "indianred", .rgb = 0xcd5c5c },
+    .{ .name = "indigo", .rgb = 0x4b0082 },
+    .{ .name = "ivory", .rgb = 0xfffff0 },
+    .{ .name = "khaki", .rgb = 0xf0e68c },
+    .{ .name = "lavender", .rgb = 0xe6e6fa },
+    .{ .name = "lavenderblush", .rgb = 0xfff0f5 },
+    .{ .name = "lawngreen", .rgb = 0x7cfc00 },
+    .{ .name = "lemonchiffon", .rgb = 0xfffacd },
+    .{ .name = "lightblue", .rgb = 0xadd8e6 },
+    .{ .name = "lightcoral", .rgb = 0xf08080 },
+    .{ .name = "lightcyan", .rgb = 0xe0ffff },
+    .{ .name = "lightgoldenrodyellow", .rgb = 0xfafad2 },
+    .{ .name = "lightgray", .rgb = 0xd3d3d3 },
+    .{ .name = "lightgreen", .rgb = 0x90ee90 },
+    .{ .name = "lightgrey", .rgb = 0xd3d3d3 },
+    .{ .name = "lightpink", .rgb = 0xffb6c1 },
+    .{ .name = "lightsalmon", .rgb = 0xffa07a },
+    .{ .name = "lightseagreen", .rgb = 0x20b2aa },
+    .{ .name = "lightskyblue", .rgb = 0x87cefa },
+    .{ .name = "lightslategray", .rgb = 0x778899 },
+    .{ .name = "lightslategrey", .rgb = 0x778899 },
+    .{ .name = "lightsteelblue", .rgb = 0xb0c4de },
+    .{ .name = "lightyellow", .rgb = 0xffffe0 },
+    .{ .name = "lime", .rgb = 0x00ff00 },
+    .{ .name = "limegreen", .rgb = 0x32cd32 },
+    .{ .name = "linen", .rgb = 0xfaf0e6 },
+    .{ .name = "magenta", .rgb = 0xff00ff },
+    .{ .name = "maroon", .rgb = 0x800000 },
+    .{ .name = "mediumaquamarine", .rgb = 0x66cdaa },
+    .{ .name = "mediumblue", .rgb = 0x0000cd },
+    .{ .name = "mediumorchid", .rgb = 0xba55d3 },
+    .{ .name = "mediumpurple", .rgb = 0x9370db },
+    .{ .name = "mediumseagreen", .rgb = 0x3cb371 },
+    .{ .name = "mediumslateblue", .rgb = 0x7b68ee },
+    .{ .name = "mediumspringgreen", .rgb = 0x00fa9a },
+    .{ .name = "mediumturquoise", .rgb = 0x48d1cc },
+    .{ .name = "mediumvioletred", .rgb = 0xc71585 },
+    .{ .name = "midnightblue", .rgb = 0x191970 },
+    .{ .name = "mintcream", .rgb = 0xf5fffa },
+    .{ .name = "mistyrose", .rgb = 0xffe4e1 },
+    .{ .name = "moccasin", .rgb = 0xffe4b5 },
+    .{ .name = "navajowhite", .rgb = 0xffdead },
+    .{ .name = "navy", .rgb = 0x000080 },
+    .{ .name = "oldlace", .rgb = 0xfdf5e6 },
+    .{ .name = "olive", .rgb = 0x808000 },
+    .{ .name = "olivedrab", .rgb = 0x6b8e23 },
+    .{ .name = "orange", .rgb = 0xffa500 },
+    .{ .name = "orangered", .rgb = 0xff4500 },
+    .{ .name = "orchid", .rgb = 0xda70d6 },
+    .{ .name = "palegoldenrod", .rgb = 0xeee8aa },
+    .{ .name = "palegreen", .rgb = 0x98fb98 },
+    .{ .name = "paleturquoise", .rgb = 0xafeeee },
+    .{ .name = "palevioletred", .rgb = 0xdb7093 },
+    .{ .name = "papayawhip", .rgb = 0xffefd5 },
+    .{ .name = "peachpuff", .rgb = 0xffdab9 },
+    .{ .name = "peru", .rgb = 0xcd853f },
+    .{ .name = "pink", .rgb = 0xffc0cb },
+    .{ .name = "plum", .rgb = 0xdda0dd },
+    .{ .name = "powderblue", .rgb = 0xb0e0e6 },
+    .{ .name = "purple", .rgb = 0x800080 },
+    .{ .name = "rebeccapurple", .rgb = 0x663399 },
+    .{ .name = "red", .rgb = 0xff0000 },
+    .{ .name = "rosybrown", .rgb = 0xbc8f8f },
+    .{ .name = "royalblue", .rgb = 0x4169e1 },
+    .{ .name = "saddlebrown", .rgb = 0x8b4513 },
+    .{ .name = "salmon", .rgb = 0xfa8072 },
+    .{ .name = "sandybrown", .rgb = 0xf4a460 },
+    .{ .name = "seagreen", .rgb = 0x2e8b57 },
+    .{ .name = "seashell", .rgb = 0xfff5ee },
+    .{ .name = "sienna", .rgb = 0xa0522d },
+    .{ .name = "silver", .rgb = 0xc0c0c0 },
+    .{ .name = "skyblue", .rgb = 0x87ceeb },
+    .{ .name = "slateblue", .rgb = 0x6a5acd },
+    .{ .name = "slategray", .rgb = 0x708090 },
+    .{ .name = "slategrey", .rgb = 0x708090 },
+    .{ .name = "snow", .rgb = 0xfffafa },
+    .{ .name = "springgreen", .rgb = 0x00ff7f },
+    .{ .name = "steelblue", .rgb = 0x4682b4 },
+    .{ .name = "tan", .rgb = 0xd2b48c },
+    .{ .name = "teal", .rgb = 0x008080 },
+    .{ .name = "thistle", .rgb = 0xd8bfd8 },
+    .{ .name = "tomato", .rgb = 0xff6347 },
+    .{ .name = "turquoise", .rgb = 0x40e0d0 },
+    .{ .name = "violet", .rgb = 0xee82ee },
+    .{ .name = "wheat", .rgb = 0xf5deb3 },
+    .{ .name = "white", .rgb = 0xffffff },
+    .{ .name = "whitesmoke", .rgb = 0xf5f5f5 },
+    .{ .name = "yellow", .rgb = 0xffff00 },
+    .{ .name = "yellowgreen", .rgb = 0x9acd32 },
+};
+
+// defaultDisplay returns the display value of the user agent stylesheet.
+// https://html.spec.whatwg.org/multipage/rendering.html#the-css-user-agent-style-sheet-and-presentational-hints
+fn defaultDisplay(e: *parser.Element) ![]const u8 {
+    if (try parser.elementHasAttribute(e, "hidden")) return "none";
+
+    const tag = try parser.nodeLocalName(parser.elementToNode(e));
+    for (default_displays) |d| {
+        for (d.tags) |t| {
+            if (std.ascii.eqlIgnoreCase(t, tag)) return d.display;
+        }
+    }
+    return Property.display.def().initial;
+}
+
+const default_displays = [_]struct {
+    display: []const u8,
+    tags: []const []const u8,
+}{
+    .{ .display = "none", .tags = &.{
+        "area",  "base",     "datalist", "head",   "link",  "meta",
+        "noscript", "param", "script",   "style",  "template", "title",
+    } },
+    .{ .display = "block", .tags = &.{
+        "address", "article",    "aside",  "blockquote", "body",   "center",
+        "dd",      "details",    "dialog", "div",        "dl",     "dt",
+        "fieldset", "figcaption", "figure", "footer",    "form",   "h1",
+        "h2",      "h3",         "h4",     "h5",         "h6",     "header",
+        "hgroup",  "hr",         "html",   "legend",     "main",   "menu",
+        "nav",     "ol",         "p",      "pre",        "section", "summary",
+        "ul",
+    } },
+    .{ .display = "list-item", .tags = &.{"li"} },
+    .{ .display = "table", .tags = &.{"table"} },
+    .{ .display = "table-caption", .tags = &.{"caption"} },
+    .{ .display = "table-column-group", .tags = &.{"colgroup"} },
+    .{ .display = "table-column", .tags = &.{"col"} },
+    .{ .display = "table-header-group", .tags = &.{"thead"} },
+    .{ .display = "table-row-group", .tags = &.{"tbody"} },
+    .{ .display = "table-footer-group", .tags = &.{"tfoot"} },
+    .{ .display = "table-row", .tags = &.{"tr"} },
+    .{ .display = "table-cell", .tags = &.{ "td", "th" } },
+    .{ .display = "inline-block", .tags = &.{ "button", "input", "select", "textarea" } },
+};
+
+// Tests
+// -----
+
+pub fn testExecFn(
+    _: std.mem.Allocator,
+    js_env: *jsruntime.Env,
+) anyerror!void {
+    var computed = [_]Case{
+        .{ .src = "let para = document.getElementById('para')", .ex = "undefined" },
+        .{ .src = "let cs = getComputedStyle(para)", .ex = "undefined" },
+        .{ .src = "cs.display", .ex = "block" },
+        .{ .src = "cs.visibility", .ex = "visible" },
+        .{ .src = "cs.color", .ex = "rgb(0, 0, 0)" },
+        .{ .src = "cs.fontSize", .ex = "16px" },
+        .{ .src = "cs.width", .ex = "auto" },
+        .{ .src = "cs.getPropertyValue('font-size')", .ex = "16px" },
+        .{ .src = "getComputedStyle(document.getElementById('link')).display", .ex = "inline" },
+        .{ .src = "var err; try { cs.setProperty('color', 'red') } catch (e) { err = e } err.name", .ex = "NoModificationAllowedError" },
+
+        // inline style and inheritance
+        .{ .src = "let content = document.getElementById('content')", .ex = "undefined" },
+        .{ .src = "content.setAttribute('style', 'color: red; font-size: 12px; width: 10px; visibility: hidden')", .ex = "undefined" },
+        .{ .src = "cs = getComputedStyle(para)", .ex = "[object CSSStyleDeclaration]" },
+        .{ .src = "cs.color", .ex = "rgb(255, 0, 0)" },
+        .{ .src = "cs.fontSize", .ex = "12px" },
+        .{ .src = "cs.visibility", .ex = "hidden" },
+        // width is not inherited
+        .{ .src = "cs.width", .ex = "auto" },
+        .{ .src = "getComputedStyle(content).width", .ex = "10px" },
+
+        // style elements
+        .{ .src = "let style = document.createElement('style')", .ex = "undefined" },
+        .{ .src = "style.textContent = '/* comment */ @media print { p { color: green } } #para { color: blue; display: none } p.ok { color: yellow !important } p { display: flex; height: 5px }'", .ex = "/* comment */ @media print { p { color: green } } #para { color: blue; display: none } p.ok { color: yellow !important } p { display: flex; height: 5px }" },
+        .{ .src = "document.body.appendChild(style).localName", .ex = "style" },
+        .{ .src = "cs = getComputedStyle(para)", .ex = "[object CSSStyleDeclaration]" },
+        .{ .src = "cs.color", .ex = "rgb(0, 0, 255)" },
+        // specificity: id wins against type.
+        .{ .src = "cs.display", .ex = "none" },
+        .{ .src = "cs.height", .ex = "5px" },
+        // important wins against specificity.
+        .{ .src = "getComputedStyle(document.getElementById('para-empty')).color", .ex = "rgb(255, 255, 0)" },
+        // inline style wins against specificity.
+        .{ .src = "para.setAttribute('style', 'display: inline; color: white')", .ex = "undefined" },
+        .{ .src = "getComputedStyle(para).display", .ex = "inline" },
+        .{ .src = "para.setAttribute('style', 'color: inherit')", .ex = "undefined" },
+        .{ .src = "getComputedStyle(para).color", .ex = "rgb(255, 0, 0)" },
+
+        // the colors are serialized like the initial color.
+        .{ .src = "para.setAttribute('style', 'color: #0f08')", .ex = "undefined" },
+        .{ .src = "getComputedStyle(para).color", .ex = "rgba(0, 255, 0, 0.533)" },
+        .{ .src = "para.setAttribute('style', 'color: RGB(10%, 20, 30.6)')", .ex = "undefined" },
+        .{ .src = "getComputedStyle(para).color", .ex = "rgb(26, 20, 31)" },
+        .{ .src = "para.setAttribute('style', 'color: rgb(1 2 3 / 50%)')", .ex = "undefined" },
+        .{ .src = "getComputedStyle(para).color", .ex = "rgba(1, 2, 3, 0.5)" },
+        .{ .src = "para.setAttribute('style', 'color: currentColor')", .ex = "undefined" },
+        .{ .src = "getComputedStyle(para).color", .ex = "rgb(255, 0, 0)" },
+
+        // the cached rules follow the style elements' changes.
+        .{ .src = "para.removeAttribute('style')", .ex = "undefined" },
+        .{ .src = "style.textContent = '#para { color: #123456 }'", .ex = "#para { color: #123456 }" },
+        .{ .src = "getComputedStyle(para).color", .ex = "rgb(18, 52, 86)" },
+        .{ .src = "style.firstChild.data = '#para { color: #654321 }'", .ex = "#para { color: #654321 }" },
+        .{ .src = "getComputedStyle(para).color", .ex = "rgb(101, 67, 33)" },
+        .{ .src = "style.remove()", .ex = "undefined" },
+        .{ .src = "getComputedStyle(para).color", .ex = "rgb(255, 0, 0)" },
+
+        // the lengths are resolved in px, the relative ones against the
+        // font sizes.
+        .{ .src = "para.setAttribute('style', 'font-size: 2em; width: 2em; height: 50%')", .ex = "undefined" },
+        .{ .src = "cs = getComputedStyle(para)", .ex = "[object CSSStyleDeclaration]" },
+        .{ .src = "cs.fontSize", .ex = "24px" },
+        .{ .src = "cs.width", .ex = "48px" },
+        .{ .src = "cs.height", .ex = "50%" },
+        // the computed font size is inherited.
+        .{ .src = "let span = para.appendChild(document.createElement('span'))", .ex = "undefined" },
+        .{ .src = "getComputedStyle(span).fontSize", .ex = "24px" },
+        .{ .src = "span.setAttribute('style', 'font-size: 50%')", .ex = "undefined" },
+        .{ .src = "getComputedStyle(span).fontSize", .ex = "12px" },
+        .{ .src = "span.remove()", .ex = "undefined" },
+        .{ .src = "para.setAttribute('style', 'font-size: 150%')", .ex = "undefined" },
+        .{ .src = "getComputedStyle(para).fontSize", .ex = "18px" },
+        .{ .src = "para.setAttribute('style', 'font-size: larger')", .ex = "undefined" },
+        .{ .src = "getComputedStyle(para).fontSize", .ex = "14.4px" },
+        .{ .src = "para.setAttribute('style', 'font-size: X-Large')", .ex = "undefined" },
+        .{ .src = "getComputedStyle(para).fontSize", .ex = "24px" },
+        .{ .src = "para.setAttribute('style', 'font-size: 12pt; width: 1in; height: 0')", .ex = "undefined" },
+        .{ .src = "cs = getComputedStyle(para)", .ex = "[object CSSStyleDeclaration]" },
+        .{ .src = "cs.fontSize", .ex = "16px" },
+        .{ .src = "cs.width", .ex = "96px" },
+        .{ .src = "cs.height", .ex = "0px" },
+        .{ .src = "para.setAttribute('style', 'font-size: 2rem; width: 1.5rem')", .ex = "undefined" },
+        .{ .src = "cs = getComputedStyle(para)", .ex = "[object CSSStyleDeclaration]" },
+        .{ .src = "cs.fontSize", .ex = "32px" },
+        .{ .src = "cs.width", .ex = "24px" },
+        .{ .src = "para.removeAttribute('style')", .ex = "undefined" },
+    };
+    try checkCases(js_env, &computed);
+}
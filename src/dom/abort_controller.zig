@@ -0,0 +1,213 @@
+// Copyright (C) 2023-2024  Lightpanda (Selecy SAS)
+//
+// Francis Bouvier <francis@lightpanda.io>
+// Pierre Tachoire <pierre@lightpanda.io>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+const std = @import("std");
+
+const jsruntime = @import("jsruntime");
+const Callback = jsruntime.Callback;
+const Case = jsruntime.test_utils.Case;
+const checkCases = jsruntime.test_utils.checkCases;
+
+const generate = @import("../generate.zig");
+
+const parser = @import("netsurf");
+const EventHandler = @import("../events/event.zig").EventHandler;
+
+const DOMException = @import("exceptions.zig").DOMException;
+const EventTarget = @import("event_target.zig").EventTarget;
+
+const log = std.log.scoped(.abort);
+
+pub const Interfaces = generate.Tuple(.{
+    AbortController,
+    AbortSignal,
+});
+
+// WEB IDL https://dom.spec.whatwg.org/#interface-abortcontroller
+pub const AbortController = struct {
+    pub const mem_guarantied = true;
+
+    // The signal is allocated separately to keep a stable pointer, usable by
+    // other APIs (eg. fetch, addEventListener) even if the controller moves.
+    signal: *AbortSignal,
+
+    pub fn constructor(alloc: std.mem.Allocator) !AbortController {
+        const signal = try alloc.create(AbortSignal);
+        signal.* = .{};
+        return .{ .signal = signal };
+    }
+
+    pub fn get_signal(self: *AbortController) *AbortSignal {
+        return self.signal;
+    }
+
+    // https://dom.spec.whatwg.org/#dom-abortcontroller-abort
+    pub fn _abort(self: *AbortController, alloc: std.mem.Allocator, reason: ?[]const u8) !void {
+        try self.signal.signalAbort(alloc, reason);
+    }
+
+    pub fn deinit(self: *AbortController, alloc: std.mem.Allocator) void {
+        self.signal.deinit(alloc);
+        alloc.destroy(self.signal);
+    }
+};
+
+// WEB IDL https://dom.spec.whatwg.org/#interface-AbortSignal
+pub const AbortSignal = struct {
+    pub const prototype = *EventTarget;
+    pub const mem_guarantied = true;
+    pub const Exception = DOMException;
+
+    // Extend libdom event target for pure zig struct.
+    base: parser.EventTargetTBase = parser.EventTargetTBase{},
+
+    aborted: bool = false,
+    // TODO the reason can be any JS value, but we only support strings for
+    // now.
+    reason: ?[]const u8 = null,
+
+    onabort_cbk: ?Callback = null,
+
+    // abort algorithms are run when the signal is aborted.
+    // https://dom.spec.whatwg.org/#abortsignal-abort-algorithms
+    algorithms: std.ArrayListUnmanaged(Algorithm) = .{},
+
+    // For now the only abort algorithm is the removal of an event listener
+    // registered with a signal.
+    // https://dom.spec.whatwg.org/#add-an-event-listener
+    pub const Algorithm = struct {
+        et: *parser.EventTarget,
+        typ: []const u8,
+        cbk_id: usize,
+        capture: bool,
+    };
+
+    pub fn addAlgorithm(self: *AbortSignal, alloc: std.mem.Allocator, algo: Algorithm) !void {
+        const typ = try alloc.dupe(u8, algo.typ);
+        errdefer alloc.free(typ);
+
+        try self.algorithms.append(alloc, .{
+            .et = algo.et,
+            .typ = typ,
+            .cbk_id = algo.cbk_id,
+            .capture = algo.capture,
+        });
+    }
+
+    fn runAlgorithms(self: *AbortSignal, alloc: std.mem.Allocator) !void {
+        defer self.freeAlgorithms(alloc);
+
+        for (self.algorithms.items) |algo| {
+            // the listener could have been removed already.
+            const lst = try parser.eventTargetHasListener(algo.et, algo.typ, algo.capture, algo.cbk_id);
+            if (lst) |l| try parser.eventTargetRemoveEventListener(algo.et, alloc, algo.typ, l, algo.capture);
+        }
+    }
+
+    fn freeAlgorithms(self: *AbortSignal, alloc: std.mem.Allocator) void {
+        for (self.algorithms.items) |algo| alloc.free(algo.typ);
+        self.algorithms.deinit(alloc);
+        self.algorithms = .{};
+    }
+
+    pub fn get_aborted(self: *AbortSignal) bool {
+        return self.aborted;
+    }
+
+    pub fn get_reason(self: *AbortSignal) ?[]const u8 {
+        return self.reason;
+    }
+
+    // https://dom.spec.whatwg.org/#dom-abortsignal-throwifaborted
+    pub fn _throwIfAborted(self: *AbortSignal) !void {
+        if (self.aborted) return parser.DOMError.Abort;
+    }
+
+    pub fn get_onabort(self: *AbortSignal) ?Callback {
+        return self.onabort_cbk;
+    }
+
+    pub fn set_onabort(self: *AbortSignal, alloc: std.mem.Allocator, handler: Callback) !void {
+        const et = @as(*parser.EventTarget, @ptrCast(self));
+
+        if (self.onabort_cbk) |cbk| {
+            const lst = try parser.eventTargetHasListener(et, "abort", false, cbk.id());
+            if (lst) |l| try parser.eventTargetRemoveEventListener(et, alloc, "abort", l, false);
+        }
+
+        try parser.eventTargetAddEventListener(et, alloc, "abort", EventHandler, .{ .cbk = handler }, false);
+        self.onabort_cbk = handler;
+    }
+
+    // https://dom.spec.whatwg.org/#abortsignal-signal-abort
+    pub fn signalAbort(self: *AbortSignal, alloc: std.mem.Allocator, reason: ?[]const u8) !void {
+        // If signal is aborted, then return.
+        if (self.aborted) return;
+
+        self.aborted = true;
+        if (reason) |r| self.reason = try alloc.dupe(u8, r);
+
+        try self.runAlgorithms(alloc);
+
+        const evt = try parser.eventCreate();
+        defer parser.eventDestroy(evt);
+
+        try parser.eventInit(evt, "abort", .{});
+        parser.eventSetTrusted(evt, true);
+        _ = try parser.eventTargetDispatchEvent(@as(*parser.EventTarget, @ptrCast(self)), evt);
+    }
+
+    pub fn deinit(self: *AbortSignal, alloc: std.mem.Allocator) void {
+        if (self.reason) |r| alloc.free(r);
+        self.reason = null;
+
+        self.freeAlgorithms(alloc);
+
+        parser.eventTargetRemoveAllEventListeners(@as(*parser.EventTarget, @ptrCast(self)), alloc) catch |e| {
+            log.err("remove all listeners: {any}", .{e});
+        };
+    }
+};
+
+// Tests
+// -----
+
+pub fn testExecFn(
+    _: std.mem.Allocator,
+    js_env: *jsruntime.Env,
+) anyerror!void {
+    var abort = [_]Case{
+        .{ .src = "let ac = new AbortController()", .ex = "undefined" },
+        .{ .src = "ac.signal.aborted", .ex = "false" },
+        .{ .src = "ac.signal.reason", .ex = "null" },
+        .{ .src = "var nb = 0; ac.signal.addEventListener('abort', () => { nb++ })", .ex = "undefined" },
+        .{ .src = "var nbon = 0; ac.signal.onabort = () => { nbon++ }", .ex = "() => { nbon++ }" },
+        .{ .src = "ac.signal.throwIfAborted()", .ex = "undefined" },
+        .{ .src = "ac.abort('stop')", .ex = "undefined" },
+        .{ .src = "ac.signal.aborted", .ex = "true" },
+        .{ .src = "ac.signal.reason", .ex = "stop" },
+        .{ .src = "nb", .ex = "1" },
+        .{ .src = "nbon", .ex = "1" },
+        // abort twice is a no-op.
+        .{ .src = "ac.abort('again')", .ex = "undefined" },
+        .{ .src = "nb", .ex = "1" },
+        .{ .src = "ac.signal.reason", .ex = "stop" },
+        .{ .src = "var e; try { ac.signal.throwIfAborted() } catch (err) { e = err } e.name", .ex = "AbortError" },
+    };
+    try checkCases(js_env, &abort);
+}
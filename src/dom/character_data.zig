@@ -21,6 +21,7 @@ const std = @import("std");
 const jsruntime = @import("jsruntime");
 const Case = jsruntime.test_utils.Case;
 const checkCases = jsruntime.test_utils.checkCases;
+const Variadic = jsruntime.Variadic;
 const generate = @import("../generate.zig");
 
 const parser = @import("netsurf");
@@ -102,6 +103,26 @@ pub const CharacterData = struct {
     pub fn _substringData(self: *parser.CharacterData, offset: u32, count: u32) ![]const u8 {
         return try parser.characterDataSubstringData(self, offset, count);
     }
+
+    // ChildNode
+    // String arguments are converted into text nodes by the polyfill.
+    // blocked by https://github.com/lightpanda-io/jsruntime-lib/issues/114
+
+    pub fn _before(self: *parser.CharacterData, nodes: ?Variadic(*parser.Node)) !void {
+        return Node.before(parser.characterDataToNode(self), nodes);
+    }
+
+    pub fn _after(self: *parser.CharacterData, nodes: ?Variadic(*parser.Node)) !void {
+        return Node.after(parser.characterDataToNode(self), nodes);
+    }
+
+    pub fn _replaceWith(self: *parser.CharacterData, nodes: ?Variadic(*parser.Node)) !void {
+        return Node.replaceWith(parser.characterDataToNode(self), nodes);
+    }
+
+    pub fn _remove(self: *parser.CharacterData) !void {
+        return Node.remove(parser.characterDataToNode(self));
+    }
 };
 
 // Tests
@@ -177,4 +198,17 @@ pub fn testExecFn(
         .{ .src = "cdata.substringData('OK'.length-1, 0) == ''", .ex = "true" },
     };
     try checkCases(js_env, &substring_data);
+
+    var child_node = [_]Case{
+        .{ .src = "let cnt = document.createTextNode('foo')", .ex = "undefined" },
+        .{ .src = "let cnp = document.createElement('p')", .ex = "undefined" },
+        .{ .src = "cnp.appendChild(cnt); true", .ex = "true" },
+        .{ .src = "cnt.before('a'); cnt.after(document.createElement('b'))", .ex = "undefined" },
+        .{ .src = "cnp.innerHTML", .ex = "afoo<b></b>" },
+        .{ .src = "cnt.replaceWith('bar')", .ex = "undefined" },
+        .{ .src = "cnp.innerHTML", .ex = "abar<b></b>" },
+        .{ .src = "cnp.firstChild.remove()", .ex = "undefined" },
+        .{ .src = "cnp.innerHTML", .ex = "bar<b></b>" },
+    };
+    try checkCases(js_env, &child_node);
 }
@@ -32,8 +32,17 @@ const MatchFirst = struct {
     }
 };
 
+// parse the selector, an invalid selector returns a SyntaxError.
+// https://dom.spec.whatwg.org/#scope-match-a-selectors-string
+fn parse(alloc: std.mem.Allocator, selector: []const u8) !css.Selector {
+    return css.parse(alloc, selector, .{ .accept_pseudo_elts = true }) catch |e| switch (e) {
+        error.OutOfMemory => e,
+        else => parser.DOMError.Syntax,
+    };
+}
+
 pub fn querySelector(alloc: std.mem.Allocator, n: *parser.Node, selector: []const u8) !?*parser.Node {
-    const ps = try css.parse(alloc, selector, .{ .accept_pseudo_elts = true });
+    const ps = try parse(alloc, selector);
     defer ps.deinit(alloc);
 
     var m = MatchFirst{};
@@ -68,7 +77,7 @@ const MatchAll = struct {
 };
 
 pub fn querySelectorAll(alloc: std.mem.Allocator, n: *parser.Node, selector: []const u8) !NodeList {
-    const ps = try css.parse(alloc, selector, .{ .accept_pseudo_elts = true });
+    const ps = try parse(alloc, selector);
     defer ps.deinit(alloc);
 
     var m = MatchAll.init(alloc);
@@ -77,3 +86,28 @@ pub fn querySelectorAll(alloc: std.mem.Allocator, n: *parser.Node, selector: []c
     try css.matchAll(ps, Node{ .node = n }, &m);
     return m.toOwnedList();
 }
+
+// matches returns true if the node n matches the selector.
+// https://dom.spec.whatwg.org/#dom-element-matches
+pub fn matches(alloc: std.mem.Allocator, n: *parser.Node, selector: []const u8) !bool {
+    const ps = try parse(alloc, selector);
+    defer ps.deinit(alloc);
+
+    return try ps.match(Node{ .node = n });
+}
+
+// closest returns the first inclusive ancestor element of n matching the
+// selector or null.
+// https://dom.spec.whatwg.org/#dom-element-closest
+pub fn closest(alloc: std.mem.Allocator, n: *parser.Node, selector: []const u8) !?*parser.Node {
+    const ps = try parse(alloc, selector);
+    defer ps.deinit(alloc);
+
+    var c: ?Node = Node{ .node = n };
+    while (c) |cn| : (c = try cn.parent()) {
+        // stop at the document boundary, only elements can match.
+        if (!cn.isElement()) return null;
+        if (try ps.match(cn)) return cn.node;
+    }
+    return null;
+}
@@ -39,6 +39,7 @@ const ElementUnion = @import("element.zig").Union;
 const DocumentType = @import("document_type.zig").DocumentType;
 const DocumentFragment = @import("document_fragment.zig").DocumentFragment;
 const DOMImplementation = @import("implementation.zig").DOMImplementation;
+const Range = @import("range.zig").Range;
 
 const UserContext = @import("../user_context.zig").UserContext;
 
@@ -164,6 +165,11 @@ pub const Document = struct {
         return try parser.documentCreateDocumentFragment(self);
     }
 
+    // https://dom.spec.whatwg.org/#dom-document-createrange
+    pub fn _createRange(self: *parser.Document) Range {
+        return Range.init(parser.documentToNode(self));
+    }
+
     pub fn _createTextNode(self: *parser.Document, data: []const u8) !*parser.Text {
         return try parser.documentCreateTextNode(self, data);
     }
@@ -233,23 +239,16 @@ pub const Document = struct {
         return css.querySelectorAll(alloc, parser.documentToNode(self), selector);
     }
 
-    // TODO according with https://dom.spec.whatwg.org/#parentnode, the
-    // function must accept either node or string.
+    // String arguments are converted into text nodes by the polyfill.
     // blocked by https://github.com/lightpanda-io/jsruntime-lib/issues/114
     pub fn _prepend(self: *parser.Document, nodes: ?Variadic(*parser.Node)) !void {
         return Node.prepend(parser.documentToNode(self), nodes);
     }
 
-    // TODO according with https://dom.spec.whatwg.org/#parentnode, the
-    // function must accept either node or string.
-    // blocked by https://github.com/lightpanda-io/jsruntime-lib/issues/114
     pub fn _append(self: *parser.Document, nodes: ?Variadic(*parser.Node)) !void {
         return Node.append(parser.documentToNode(self), nodes);
     }
 
-    // TODO according with https://dom.spec.whatwg.org/#parentnode, the
-    // function must accept either node or string.
-    // blocked by https://github.com/lightpanda-io/jsruntime-lib/issues/114
     pub fn _replaceChildren(self: *parser.Document, nodes: ?Variadic(*parser.Node)) !void {
         return Node.replaceChildren(parser.documentToNode(self), nodes);
     }
@@ -311,6 +310,28 @@ pub fn testExecFn(
         .{ .src = "empty.length", .ex = "1" },
         .{ .src = "let emptyok = document.getElementsByClassName('empty ok')", .ex = "undefined" },
         .{ .src = "emptyok.length", .ex = "1" },
+        .{ .src = "document.getElementsByClassName(' ok\\tempty  ').length", .ex = "1" },
+        .{ .src = "document.getElementsByClassName('').length", .ex = "0" },
+        .{ .src = "document.getElementsByClassName(' ').length", .ex = "0" },
+        .{ .src = "document.getElementById('content').getElementsByClassName('ok').length", .ex = "2" },
+
+        // check liveness
+        .{ .src = "let okp = document.createElement('p')", .ex = "undefined" },
+        .{ .src = "okp.className = 'ok'", .ex = "ok" },
+        .{ .src = "document.getElementById('content').appendChild(okp) === okp", .ex = "true" },
+        .{ .src = "ok.length", .ex = "3" },
+        .{ .src = "ok.item(2) === okp", .ex = "true" },
+        .{ .src = "document.getElementById('link').className = 'ko'", .ex = "ko" },
+        .{ .src = "ok.length", .ex = "2" },
+        .{ .src = "ok.item(0).id", .ex = "para-empty" },
+        .{ .src = "okp.remove()", .ex = "undefined" },
+        .{ .src = "ok.length", .ex = "1" },
+        .{ .src = "document.getElementById('link').className = 'ok'", .ex = "ok" },
+        .{ .src = "ok.length", .ex = "2" },
+
+        // the classes are compared case-insensitively in quirks mode only.
+        .{ .src = "new DOMParser().parseFromString('<!DOCTYPE html><p class=Foo></p>', 'text/html').getElementsByClassName('foo').length", .ex = "0" },
+        .{ .src = "new DOMParser().parseFromString('<p class=Foo></p>', 'text/html').getElementsByClassName('foo').length", .ex = "1" },
     };
     try checkCases(js_env, &getElementsByClassName);
 
@@ -23,6 +23,7 @@ const parser = @import("netsurf");
 const jsruntime = @import("jsruntime");
 const Case = jsruntime.test_utils.Case;
 const checkCases = jsruntime.test_utils.checkCases;
+const Variadic = jsruntime.Variadic;
 
 const Node = @import("node.zig").Node;
 
@@ -39,6 +40,20 @@ pub const DocumentFragment = struct {
             parser.documentHTMLToDocument(userctx.document),
         );
     }
+
+    // String arguments are converted into text nodes by the polyfill.
+    // blocked by https://github.com/lightpanda-io/jsruntime-lib/issues/114
+    pub fn _prepend(self: *parser.DocumentFragment, nodes: ?Variadic(*parser.Node)) !void {
+        return Node.prepend(parser.documentFragmentToNode(self), nodes);
+    }
+
+    pub fn _append(self: *parser.DocumentFragment, nodes: ?Variadic(*parser.Node)) !void {
+        return Node.append(parser.documentFragmentToNode(self), nodes);
+    }
+
+    pub fn _replaceChildren(self: *parser.DocumentFragment, nodes: ?Variadic(*parser.Node)) !void {
+        return Node.replaceChildren(parser.documentFragmentToNode(self), nodes);
+    }
 };
 
 // Tests
@@ -53,4 +68,18 @@ pub fn testExecFn(
         .{ .src = "dc.constructor.name", .ex = "DocumentFragment" },
     };
     try checkCases(js_env, &constructor);
+
+    var parent_node = [_]Case{
+        .{ .src = "dc.append('foo', document.createElement('b'), 'bar')", .ex = "undefined" },
+        .{ .src = "dc.childNodes.length", .ex = "3" },
+        .{ .src = "dc.firstChild.data", .ex = "foo" },
+        .{ .src = "dc.prepend(document.createElement('i'))", .ex = "undefined" },
+        .{ .src = "dc.firstChild.localName", .ex = "i" },
+        .{ .src = "dc.replaceChildren('baz')", .ex = "undefined" },
+        .{ .src = "dc.childNodes.length", .ex = "1" },
+        .{ .src = "dc.firstChild.data", .ex = "baz" },
+        .{ .src = "dc.replaceChildren()", .ex = "undefined" },
+        .{ .src = "dc.childNodes.length", .ex = "0" },
+    };
+    try checkCases(js_env, &parent_node);
 }
@@ -18,6 +18,9 @@
 
 const std = @import("std");
 
+const jsruntime = @import("jsruntime");
+const Variadic = jsruntime.Variadic;
+
 const parser = @import("netsurf");
 
 const Node = @import("node.zig").Node;
@@ -39,4 +42,24 @@ pub const DocumentType = struct {
     pub fn get_systemId(self: *parser.DocumentType) ![]const u8 {
         return try parser.documentTypeGetSystemId(self);
     }
+
+    // ChildNode
+    // String arguments are converted into text nodes by the polyfill.
+    // blocked by https://github.com/lightpanda-io/jsruntime-lib/issues/114
+
+    pub fn _before(self: *parser.DocumentType, nodes: ?Variadic(*parser.Node)) !void {
+        return Node.before(parser.documentTypeToNode(self), nodes);
+    }
+
+    pub fn _after(self: *parser.DocumentType, nodes: ?Variadic(*parser.Node)) !void {
+        return Node.after(parser.documentTypeToNode(self), nodes);
+    }
+
+    pub fn _replaceWith(self: *parser.DocumentType, nodes: ?Variadic(*parser.Node)) !void {
+        return Node.replaceWith(parser.documentTypeToNode(self), nodes);
+    }
+
+    pub fn _remove(self: *parser.DocumentType) !void {
+        return Node.remove(parser.documentTypeToNode(self));
+    }
 };
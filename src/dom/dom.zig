@@ -21,20 +21,28 @@ const generate = @import("../generate.zig");
 const DOMException = @import("exceptions.zig").DOMException;
 const EventTarget = @import("event_target.zig").EventTarget;
 const DOMImplementation = @import("implementation.zig").DOMImplementation;
+const DOMParser = @import("dom_parser.zig").DOMParser;
 const NamedNodeMap = @import("namednodemap.zig").NamedNodeMap;
 const DOMTokenList = @import("token_list.zig").DOMTokenList;
 const NodeList = @import("nodelist.zig").NodeList;
 const Nod = @import("node.zig");
 const MutationObserver = @import("mutation_observer.zig");
+const AbortController = @import("abort_controller.zig");
+const DOMRect = @import("dom_rect.zig");
+const Range = @import("range.zig").Range;
 
 pub const Interfaces = generate.Tuple(.{
     DOMException,
     EventTarget,
     DOMImplementation,
+    DOMParser,
     NamedNodeMap,
     DOMTokenList,
     NodeList,
     Nod.Node,
     Nod.Interfaces,
     MutationObserver.Interfaces,
+    AbortController.Interfaces,
+    DOMRect.Interfaces,
+    Range,
 });
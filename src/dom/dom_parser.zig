@@ -0,0 +1,580 @@
+// Copyright (C) 2023-2024  Lightpanda (Selecy SAS)
+//
+// Francis Bouvier <francis@lightpanda.io>
+// Pierre Tachoire <pierre@lightpanda.io>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+const std = @import("std");
+
+const parser = @import("netsurf");
+
+const jsruntime = @import("jsruntime");
+const Case = jsruntime.test_utils.Case;
+const checkCases = jsruntime.test_utils.checkCases;
+
+const DOMException = @import("exceptions.zig").DOMException;
+
+// WEB IDL https://html.spec.whatwg.org/multipage/dynamic-markup-insertion.html#domparser
+pub const DOMParser = struct {
+    pub const mem_guarantied = true;
+
+    pub const Exception = DOMException;
+
+    const xml_types = [_][]const u8{
+        "text/xml",
+        "application/xml",
+        "application/xhtml+xml",
+        "image/svg+xml",
+    };
+
+    // Document is either the HTML document or the XML document parsed.
+    const Document = union(enum) {
+        HTMLDocument: *parser.DocumentHTML,
+        Document: *parser.Document,
+    };
+
+    pub fn constructor() !DOMParser {
+        return .{};
+    }
+
+    // The document is parsed with scripting disabled, the scripts are parsed
+    // but never executed.
+    pub fn _parseFromString(
+        _: *DOMParser,
+        alloc: std.mem.Allocator,
+        str: []const u8,
+        typ: []const u8,
+    ) !Document {
+        if (std.mem.eql(u8, typ, "text/html")) {
+            return .{ .HTMLDocument = try parser.documentHTMLParseFromStr(str) };
+        }
+
+        for (xml_types) |t| {
+            if (std.mem.eql(u8, typ, t)) return .{ .Document = try parseXML(alloc, str) };
+        }
+
+        // the type is not a DOMParserSupportedType.
+        return error.TypeError;
+    }
+
+    pub fn deinit(_: *DOMParser, _: std.mem.Allocator) void {}
+};
+
+const parsererror_ns = "http://www.mozilla.org/newlayout/xml/parsererror.xml";
+const xml_ns = "http://www.w3.org/XML/1998/namespace";
+const xmlns_ns = "http://www.w3.org/2000/xmlns/";
+
+// parseXML returns the XML document of the string. A malformed string
+// returns a document with a parsererror root element describing the error.
+// https://html.spec.whatwg.org/multipage/dynamic-markup-insertion.html#dom-domparser-parsefromstring
+fn parseXML(alloc: std.mem.Allocator, str: []const u8) !*parser.Document {
+    const doc = try parser.domImplementationCreateDocument(null, null, null);
+    errdefer parser.nodeUnref(parser.documentToNode(doc));
+
+    var p = XMLParser{ .alloc = alloc, .doc = doc, .src = str };
+    defer p.deinit();
+
+    p.parse() catch |err| switch (err) {
+        error.XMLSyntax => try errorDocument(alloc, doc, p.msg, p.position()),
+        else => return err,
+    };
+
+    return doc;
+}
+
+// errorDocument replaces the nodes already parsed into the document by a
+// parsererror root element.
+fn errorDocument(alloc: std.mem.Allocator, doc: *parser.Document, msg: []const u8, pos: XMLParser.Position) !void {
+    const node = parser.documentToNode(doc);
+    while (try parser.nodeFirstChild(node)) |child| {
+        _ = try parser.nodeRemoveChild(node, child);
+    }
+
+    const root = try parser.documentCreateElementNS(doc, parsererror_ns, "parsererror");
+    _ = try parser.nodeAppendChild(node, parser.elementToNode(root));
+
+    const txt = try std.fmt.allocPrint(alloc, "XML Parsing Error: {s}\nLine Number {d}, Column {d}", .{
+        msg,
+        pos.line,
+        pos.column,
+    });
+    defer alloc.free(txt);
+
+    const text = try parser.documentCreateTextNode(doc, txt);
+    _ = try parser.nodeAppendChild(parser.elementToNode(root), @as(*parser.Node, @ptrCast(text)));
+}
+
+// XMLParser is a non-validating XML parser building the nodes into the
+// document. The DTD is skipped, so only the predefined entities and the
+// character references are supported.
+// https://www.w3.org/TR/xml/
+const XMLParser = struct {
+    alloc: std.mem.Allocator,
+    doc: *parser.Document,
+    src: []const u8,
+    pos: usize = 0,
+    // msg describes the syntax error.
+    msg: []const u8 = "",
+
+    // opened contains the elements not closed yet.
+    opened: std.ArrayListUnmanaged(Opened) = .{},
+    // namespaces contains the namespace declarations in scope, the last
+    // declaration of a prefix wins.
+    namespaces: std.ArrayListUnmanaged(Namespace) = .{},
+
+    const Opened = struct {
+        name: []const u8,
+        node: *parser.Node,
+    };
+
+    const Namespace = struct {
+        prefix: []const u8,
+        uri: []const u8,
+        // depth is the number of opened elements declaring the namespace.
+        depth: usize,
+    };
+
+    const Attribute = struct {
+        name: []const u8,
+        value: []const u8,
+    };
+
+    const Position = struct {
+        line: usize,
+        column: usize,
+    };
+
+    fn deinit(self: *XMLParser) void {
+        self.opened.deinit(self.alloc);
+        for (self.namespaces.items) |ns| self.alloc.free(ns.uri);
+        self.namespaces.deinit(self.alloc);
+    }
+
+    fn fail(self: *XMLParser, msg: []const u8) error{XMLSyntax} {
+        self.msg = msg;
+        return error.XMLSyntax;
+    }
+
+    // position returns the line and the column of the current position.
+    fn position(self: *XMLParser) Position {
+        const before = self.src[0..@min(self.pos, self.src.len)];
+        const line = std.mem.count(u8, before, "\n") + 1;
+        const start = if (std.mem.lastIndexOfScalar(u8, before, '\n')) |i| i + 1 else 0;
+        return .{ .line = line, .column = before.len - start + 1 };
+    }
+
+    fn startsWith(self: *XMLParser, prefix: []const u8) bool {
+        return std.mem.startsWith(u8, self.src[self.pos..], prefix);
+    }
+
+    fn skipSpaces(self: *XMLParser) bool {
+        const start = self.pos;
+        while (self.pos < self.src.len and isSpace(self.src[self.pos])) self.pos += 1;
+        return self.pos > start;
+    }
+
+    // skipPast moves after the next delimiter.
+    fn skipPast(self: *XMLParser, delim: []const u8, msg: []const u8) ![]const u8 {
+        const i = std.mem.indexOfPos(u8, self.src, self.pos, delim) orelse {
+            self.pos = self.src.len;
+            return self.fail(msg);
+        };
+        const content = self.src[self.pos..i];
+        self.pos = i + delim.len;
+        return content;
+    }
+
+    fn parent(self: *XMLParser) *parser.Node {
+        if (self.opened.items.len == 0) return parser.documentToNode(self.doc);
+        return self.opened.items[self.opened.items.len - 1].node;
+    }
+
+    fn append(self: *XMLParser, node: *parser.Node) !void {
+        _ = try parser.nodeAppendChild(self.parent(), node);
+    }
+
+    fn parse(self: *XMLParser) !void {
+        // the XML declaration is allowed only at the start of the document.
+        if (self.startsWith("<?xml") and self.src.len > 5 and isSpace(self.src[5])) {
+            _ = try self.skipPast("?>", "unclosed XML declaration");
+        }
+
+        var root = false;
+        while (self.pos < self.src.len) {
+            if (self.opened.items.len > 0) {
+                try self.content();
+                continue;
+            }
+
+            // outside of the root element, only the spaces, the comments,
+            // the processing instructions and the doctype are allowed.
+            if (self.skipSpaces()) continue;
+            if (self.startsWith("<!--")) {
+                try self.comment();
+            } else if (self.startsWith("<?")) {
+                try self.processingInstruction();
+            } else if (self.startsWith("<!DOCTYPE")) {
+                if (root) return self.fail("doctype after the document element");
+                try self.doctype();
+            } else if (self.startsWith("<") and !self.startsWith("</")) {
+                if (root) return self.fail("junk after document element");
+                root = true;
+                try self.startTag();
+            } else {
+                return self.fail("syntax error");
+            }
+        }
+
+        if (!root) return self.fail("no root element found");
+        if (self.opened.items.len > 0) return self.fail("no element found");
+    }
+
+    // content parses the next node in the current element.
+    fn content(self: *XMLParser) !void {
+        if (self.startsWith("</")) return try self.endTag();
+        if (self.startsWith("<!--")) return try self.comment();
+        if (self.startsWith("<![CDATA[")) {
+            self.pos += "<![CDATA[".len;
+            const data = try self.skipPast("]]>", "unclosed CDATA section");
+            const cdata = try parser.documentCreateCDATASection(self.doc, data);
+            return try self.append(@as(*parser.Node, @ptrCast(cdata)));
+        }
+        if (self.startsWith("<?")) return try self.processingInstruction();
+        if (self.startsWith("<")) return try self.startTag();
+
+        const end = std.mem.indexOfScalarPos(u8, self.src, self.pos, '<') orelse self.src.len;
+        const raw = self.src[self.pos..end];
+        if (std.mem.indexOf(u8, raw, "]]>") != null) return self.fail("not well-formed");
+
+        const data = try self.decode(raw);
+        defer self.alloc.free(data);
+        self.pos = end;
+
+        const text = try parser.documentCreateTextNode(self.doc, data);
+        try self.append(@as(*parser.Node, @ptrCast(text)));
+    }
+
+    fn comment(self: *XMLParser) !void {
+        self.pos += "<!--".len;
+        const data = try self.skipPast("-->", "unclosed comment");
+        if (std.mem.indexOf(u8, data, "--") != null) return self.fail("not well-formed");
+
+        const c = try parser.documentCreateComment(self.doc, data);
+        try self.append(@as(*parser.Node, @ptrCast(c)));
+    }
+
+    fn processingInstruction(self: *XMLParser) !void {
+        self.pos += "<?".len;
+        const target = try self.name();
+        if (std.ascii.eqlIgnoreCase(target, "xml")) return self.fail("XML or text declaration not at start of entity");
+
+        const sep = self.skipSpaces();
+        const data = try self.skipPast("?>", "unclosed processing instruction");
+        if (!sep and data.len > 0) return self.fail("not well-formed");
+
+        const pi = try parser.documentCreateProcessingInstruction(self.doc, target, data);
+        try self.append(parser.processingInstructionToNode(pi));
+    }
+
+    // doctype skips the document type declaration, including its internal
+    // subset.
+    fn doctype(self: *XMLParser) !void {
+        self.pos += "<!DOCTYPE".len;
+        var quote: ?u8 = null;
+        var depth: usize = 0;
+        while (self.pos < self.src.len) : (self.pos += 1) {
+            const c = self.src[self.pos];
+            if (quote) |q| {
+                if (c == q) quote = null;
+                continue;
+            }
+            switch (c) {
+                '"', '\'' => quote = c,
+                '[' => depth += 1,
+                ']' => depth -|= 1,
+                '>' => if (depth == 0) {
+                    self.pos += 1;
+                    return;
+                },
+                else => {},
+            }
+        }
+        return self.fail("unclosed doctype");
+    }
+
+    fn name(self: *XMLParser) ![]const u8 {
+        const start = self.pos;
+        while (self.pos < self.src.len and isNameChar(self.src[self.pos])) self.pos += 1;
+
+        const n = self.src[start..self.pos];
+        if (n.len == 0 or std.ascii.isDigit(n[0]) or n[0] == '-' or n[0] == '.') {
+            return self.fail("not well-formed");
+        }
+        return n;
+    }
+
+    fn startTag(self: *XMLParser) !void {
+        self.pos += 1;
+        const qname = try self.name();
+
+        var attrs: std.ArrayListUnmanaged(Attribute) = .{};
+        defer {
+            for (attrs.items) |a| self.alloc.free(a.value);
+            attrs.deinit(self.alloc);
+        }
+
+        // the attributes are read first, the namespaces they declare apply
+        // to the element itself.
+        var empty = false;
+        while (true) {
+            const sep = self.skipSpaces();
+            if (self.startsWith("/>")) {
+                self.pos += 2;
+                empty = true;
+                break;
+            }
+            if (self.startsWith(">")) {
+                self.pos += 1;
+                break;
+            }
+            if (!sep) return self.fail("not well-formed");
+
+            const attr = try self.attribute();
+            errdefer self.alloc.free(attr.value);
+            for (attrs.items) |a| {
+                if (std.mem.eql(u8, a.name, attr.name)) return self.fail("duplicate attribute");
+            }
+            try attrs.append(self.alloc, attr);
+        }
+
+        const depth = self.opened.items.len + 1;
+        for (attrs.items) |a| {
+            const prefix = if (std.mem.eql(u8, a.name, "xmlns"))
+                ""
+            else if (std.mem.startsWith(u8, a.name, "xmlns:"))
+                a.name["xmlns:".len..]
+            else
+                continue;
+
+            const uri = try self.alloc.dupe(u8, a.value);
+            errdefer self.alloc.free(uri);
+            try self.namespaces.append(self.alloc, .{ .prefix = prefix, .uri = uri, .depth = depth });
+        }
+
+        const ns = try self.lookup(prefixOf(qname), true);
+        const elt = if (ns) |uri|
+            try parser.documentCreateElementNS(self.doc, uri, qname)
+        else
+            try parser.documentCreateElement(self.doc, qname);
+
+        for (attrs.items) |a| {
+            const is_ns = std.mem.eql(u8, a.name, "xmlns") or std.mem.startsWith(u8, a.name, "xmlns:");
+            // the attributes without prefix have no namespace.
+            const attr_ns = if (is_ns) xmlns_ns else try self.lookup(prefixOf(a.name), false);
+            if (attr_ns) |uri| {
+                try parser.elementSetAttributeNS(elt, uri, a.name, a.value);
+            } else {
+                try parser.elementSetAttribute(elt, a.name, a.value);
+            }
+        }
+
+        const node = parser.elementToNode(elt);
+        try self.append(node);
+
+        if (empty) return self.popNamespaces(depth - 1);
+        try self.opened.append(self.alloc, .{ .name = qname, .node = node });
+    }
+
+    fn attribute(self: *XMLParser) !Attribute {
+        const n = try self.name();
+        _ = self.skipSpaces();
+        if (!self.startsWith("=")) return self.fail("not well-formed");
+        self.pos += 1;
+        _ = self.skipSpaces();
+
+        if (!self.startsWith("\"") and !self.startsWith("'")) return self.fail("not well-formed");
+        const quote = self.src[self.pos .. self.pos + 1];
+        self.pos += 1;
+
+        const raw = try self.skipPast(quote, "unclosed token");
+        if (std.mem.indexOfScalar(u8, raw, '<') != null) return self.fail("not well-formed");
+
+        const value = try self.decode(raw);
+        // the attribute value normalization replaces the white spaces by
+        // spaces.
+        for (value) |*c| {
+            if (isSpace(c.*)) c.* = ' ';
+        }
+        return .{ .name = n, .value = value };
+    }
+
+    fn endTag(self: *XMLParser) !void {
+        self.pos += "</".len;
+        const qname = try self.name();
+        _ = self.skipSpaces();
+        if (!self.startsWith(">")) return self.fail("not well-formed");
+        self.pos += 1;
+
+        const open = self.opened.pop();
+        if (!std.mem.eql(u8, open.name, qname)) return self.fail("mismatched tag");
+        self.popNamespaces(self.opened.items.len);
+    }
+
+    // popNamespaces removes the namespaces declared deeper than depth.
+    fn popNamespaces(self: *XMLParser, depth: usize) void {
+        while (self.namespaces.items.len > 0) {
+            const last = self.namespaces.items[self.namespaces.items.len - 1];
+            if (last.depth <= depth) break;
+            self.alloc.free(last.uri);
+            self.namespaces.items.len -= 1;
+        }
+    }
+
+    // lookup returns the namespace of the prefix. Without prefix, the
+    // default namespace applies only to the elements.
+    fn lookup(self: *XMLParser, prefix: []const u8, element: bool) !?[]const u8 {
+        if (prefix.len == 0 and !element) return null;
+        if (std.mem.eql(u8, prefix, "xml")) return xml_ns;
+
+        var i = self.namespaces.items.len;
+        while (i > 0) {
+            i -= 1;
+            const ns = self.namespaces.items[i];
+            if (!std.mem.eql(u8, ns.prefix, prefix)) continue;
+            // an empty default namespace undeclares it.
+            if (ns.uri.len == 0) break;
+            return ns.uri;
+        }
+
+        if (prefix.len > 0) return self.fail("unbound prefix");
+        return null;
+    }
+
+    // decode replaces the entity and the character references.
+    // The caller owns the returned string.
+    fn decode(self: *XMLParser, raw: []const u8) ![]u8 {
+        var buf: std.ArrayListUnmanaged(u8) = .{};
+        errdefer buf.deinit(self.alloc);
+
+        var i: usize = 0;
+        while (std.mem.indexOfScalarPos(u8, raw, i, '&')) |amp| {
+            try buf.appendSlice(self.alloc, raw[i..amp]);
+
+            const semi = std.mem.indexOfScalarPos(u8, raw, amp, ';') orelse return self.fail("not well-formed");
+            const ref = raw[amp + 1 .. semi];
+            i = semi + 1;
+
+            if (ref.len > 1 and ref[0] == '#') {
+                const cp = (if (ref[1] == 'x')
+                    std.fmt.parseInt(u21, ref[2..], 16)
+                else
+                    std.fmt.parseInt(u21, ref[1..], 10)) catch return self.fail("reference to invalid character number");
+
+                var b: [4]u8 = undefined;
+                const n = std.unicode.utf8Encode(cp, &b) catch return self.fail("reference to invalid character number");
+                if (cp == 0) return self.fail("reference to invalid character number");
+                try buf.appendSlice(self.alloc, b[0..n]);
+                continue;
+            }
+
+            const c: u8 = if (std.mem.eql(u8, ref, "lt"))
+                '<'
+            else if (std.mem.eql(u8, ref, "gt"))
+                '>'
+            else if (std.mem.eql(u8, ref, "amp"))
+                '&'
+            else if (std.mem.eql(u8, ref, "quot"))
+                '"'
+            else if (std.mem.eql(u8, ref, "apos"))
+                '\''
+            else
+                return self.fail("undefined entity");
+            try buf.append(self.alloc, c);
+        }
+        try buf.appendSlice(self.alloc, raw[i..]);
+
+        return try buf.toOwnedSlice(self.alloc);
+    }
+
+    fn prefixOf(qname: []const u8) []const u8 {
+        const i = std.mem.indexOfScalar(u8, qname, ':') orelse return "";
+        return qname[0..i];
+    }
+
+    fn isSpace(c: u8) bool {
+        return c == ' ' or c == '\t' or c == '\n' or c == '\r';
+    }
+
+    fn isNameChar(c: u8) bool {
+        return std.ascii.isAlphanumeric(c) or c == '_' or c == ':' or c == '-' or c == '.' or c >= 0x80;
+    }
+};
+
+// Tests
+// -----
+
+pub fn testExecFn(
+    _: std.mem.Allocator,
+    js_env: *jsruntime.Env,
+) anyerror!void {
+    var parse = [_]Case{
+        .{ .src = "let dp = new DOMParser()", .ex = "undefined" },
+        .{ .src = "let pdoc = dp.parseFromString('<title>foo</title><p id=\"a\" class=\"b\">bar<script>window.dpexec = true</script>', 'text/html')", .ex = "undefined" },
+        .{ .src = "pdoc instanceof Document", .ex = "true" },
+        .{ .src = "pdoc === document", .ex = "false" },
+        .{ .src = "pdoc.title", .ex = "foo" },
+        .{ .src = "pdoc.head.localName", .ex = "head" },
+        .{ .src = "pdoc.body.firstChild.id", .ex = "a" },
+        .{ .src = "pdoc.querySelector('p.b').textContent", .ex = "barwindow.dpexec = true" },
+        .{ .src = "pdoc.getElementsByTagName('script').length", .ex = "1" },
+        // scripts are not executed.
+        .{ .src = "window.dpexec", .ex = "undefined" },
+        .{ .src = "document.getElementById('a')", .ex = "null" },
+        .{ .src = "dp.parseFromString('', 'text/html').body.childNodes.length", .ex = "0" },
+        .{ .src = "try { dp.parseFromString('foo', 'text/foo'); false } catch (e) { true }", .ex = "true" },
+    };
+    try checkCases(js_env, &parse);
+
+    var xml = [_]Case{
+        .{ .src = "let xdoc = dp.parseFromString('<?xml version=\"1.0\"?><!-- c --><root xmlns=\"urn:a\" xmlns:b=\"urn:b\" id=\"r\"><b:item b:k=\"v\">a &amp; b&#33;</b:item><empty/><![CDATA[<x>]]></root>', 'text/xml')", .ex = "undefined" },
+        .{ .src = "xdoc instanceof Document", .ex = "true" },
+        .{ .src = "xdoc instanceof HTMLDocument", .ex = "false" },
+        .{ .src = "xdoc.childNodes.length", .ex = "2" },
+        .{ .src = "xdoc.firstChild.nodeType", .ex = "8" },
+        .{ .src = "xdoc.documentElement.localName", .ex = "root" },
+        .{ .src = "xdoc.documentElement.namespaceURI", .ex = "urn:a" },
+        .{ .src = "xdoc.documentElement.getAttribute('id')", .ex = "r" },
+        .{ .src = "xdoc.documentElement.firstChild.namespaceURI", .ex = "urn:b" },
+        .{ .src = "xdoc.documentElement.firstChild.prefix", .ex = "b" },
+        .{ .src = "xdoc.documentElement.firstChild.getAttributeNS('urn:b', 'k')", .ex = "v" },
+        .{ .src = "xdoc.documentElement.firstChild.textContent", .ex = "a & b!" },
+        .{ .src = "xdoc.documentElement.childNodes[1].localName", .ex = "empty" },
+        .{ .src = "xdoc.documentElement.lastChild.nodeType", .ex = "4" },
+        .{ .src = "xdoc.documentElement.lastChild.data", .ex = "<x>" },
+
+        // a malformed document is described by a parsererror element.
+        .{ .src = "let xerr = dp.parseFromString('<a><b></a>', 'application/xml')", .ex = "undefined" },
+        .{ .src = "xerr.documentElement.localName", .ex = "parsererror" },
+        .{ .src = "xerr.documentElement.namespaceURI", .ex = "http://www.mozilla.org/newlayout/xml/parsererror.xml" },
+        .{ .src = "xerr.documentElement.textContent.startsWith('XML Parsing Error: mismatched tag')", .ex = "true" },
+        .{ .src = "dp.parseFromString('<a>', 'text/xml').documentElement.localName", .ex = "parsererror" },
+        .{ .src = "dp.parseFromString('<a/><b/>', 'text/xml').documentElement.localName", .ex = "parsererror" },
+        .{ .src = "dp.parseFromString('<a>&foo;</a>', 'text/xml').documentElement.localName", .ex = "parsererror" },
+        .{ .src = "var xpart = dp.parseFromString('<?pi x?><a><b/></c>', 'text/xml')", .ex = "undefined" },
+        .{ .src = "xpart.childNodes.length", .ex = "1" },
+        .{ .src = "xpart.getElementsByTagName('b').length", .ex = "0" },
+    };
+    try checkCases(js_env, &xml);
+}
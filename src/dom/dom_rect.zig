@@ -0,0 +1,155 @@
+// Copyright (C) 2023-2024  Lightpanda (Selecy SAS)
+//
+// Francis Bouvier <francis@lightpanda.io>
+// Pierre Tachoire <pierre@lightpanda.io>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+const std = @import("std");
+
+const jsruntime = @import("jsruntime");
+const Case = jsruntime.test_utils.Case;
+const checkCases = jsruntime.test_utils.checkCases;
+
+const generate = @import("../generate.zig");
+
+pub const Interfaces = generate.Tuple(.{
+    DOMRectReadOnly,
+    DOMRect,
+});
+
+// WEB IDL https://drafts.fxtf.org/geometry/#domrectreadonly
+pub const DOMRectReadOnly = struct {
+    pub const mem_guarantied = true;
+
+    x: f64 = 0,
+    y: f64 = 0,
+    width: f64 = 0,
+    height: f64 = 0,
+
+    pub const JSON = struct {
+        x: f64,
+        y: f64,
+        width: f64,
+        height: f64,
+        top: f64,
+        right: f64,
+        bottom: f64,
+        left: f64,
+    };
+
+    pub fn constructor(x: ?f64, y: ?f64, width: ?f64, height: ?f64) DOMRectReadOnly {
+        return .{
+            .x = x orelse 0,
+            .y = y orelse 0,
+            .width = width orelse 0,
+            .height = height orelse 0,
+        };
+    }
+
+    pub fn get_x(self: *DOMRectReadOnly) f64 {
+        return self.x;
+    }
+
+    pub fn get_y(self: *DOMRectReadOnly) f64 {
+        return self.y;
+    }
+
+    pub fn get_width(self: *DOMRectReadOnly) f64 {
+        return self.width;
+    }
+
+    pub fn get_height(self: *DOMRectReadOnly) f64 {
+        return self.height;
+    }
+
+    pub fn get_top(self: *DOMRectReadOnly) f64 {
+        return @min(self.y, self.y + self.height);
+    }
+
+    pub fn get_right(self: *DOMRectReadOnly) f64 {
+        return @max(self.x, self.x + self.width);
+    }
+
+    pub fn get_bottom(self: *DOMRectReadOnly) f64 {
+        return @max(self.y, self.y + self.height);
+    }
+
+    pub fn get_left(self: *DOMRectReadOnly) f64 {
+        return @min(self.x, self.x + self.width);
+    }
+
+    pub fn _toJSON(self: *DOMRectReadOnly) JSON {
+        return .{
+            .x = self.x,
+            .y = self.y,
+            .width = self.width,
+            .height = self.height,
+            .top = self.get_top(),
+            .right = self.get_right(),
+            .bottom = self.get_bottom(),
+            .left = self.get_left(),
+        };
+    }
+};
+
+// WEB IDL https://drafts.fxtf.org/geometry/#domrect
+pub const DOMRect = struct {
+    pub const prototype = *DOMRectReadOnly;
+    pub const mem_guarantied = true;
+
+    proto: DOMRectReadOnly = .{},
+
+    pub fn constructor(x: ?f64, y: ?f64, width: ?f64, height: ?f64) DOMRect {
+        return .{ .proto = DOMRectReadOnly.constructor(x, y, width, height) };
+    }
+
+    pub fn set_x(self: *DOMRect, v: f64) void {
+        self.proto.x = v;
+    }
+
+    pub fn set_y(self: *DOMRect, v: f64) void {
+        self.proto.y = v;
+    }
+
+    pub fn set_width(self: *DOMRect, v: f64) void {
+        self.proto.width = v;
+    }
+
+    pub fn set_height(self: *DOMRect, v: f64) void {
+        self.proto.height = v;
+    }
+};
+
+// Tests
+// -----
+
+pub fn testExecFn(
+    _: std.mem.Allocator,
+    js_env: *jsruntime.Env,
+) anyerror!void {
+    var rect = [_]Case{
+        .{ .src = "let rect = new DOMRect(1, 2, 10, 20)", .ex = "undefined" },
+        .{ .src = "rect instanceof DOMRectReadOnly", .ex = "true" },
+        .{ .src = "rect.x + ',' + rect.y + ',' + rect.width + ',' + rect.height", .ex = "1,2,10,20" },
+        .{ .src = "rect.top + ',' + rect.right + ',' + rect.bottom + ',' + rect.left", .ex = "2,11,22,1" },
+        .{ .src = "rect.width = -20", .ex = "-20" },
+        .{ .src = "rect.left + ',' + rect.right", .ex = "-19,1" },
+        .{ .src = "JSON.stringify(rect)", .ex = "{\"x\":1,\"y\":2,\"width\":-20,\"height\":20,\"top\":2,\"right\":1,\"bottom\":22,\"left\":-19}" },
+        .{ .src = "let rrect = new DOMRectReadOnly()", .ex = "undefined" },
+        .{ .src = "rrect.x + ',' + rrect.width", .ex = "0,0" },
+        .{ .src = "rrect.x = 3; rrect.x", .ex = "0" },
+    };
+    try checkCases(js_env, &rect);
+}
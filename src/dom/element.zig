@@ -32,6 +32,7 @@ const css = @import("css.zig");
 const Node = @import("node.zig").Node;
 const Walker = @import("walker.zig").WalkerDepthFirst;
 const NodeList = @import("nodelist.zig").NodeList;
+const DOMRect = @import("dom_rect.zig").DOMRect;
 const HTMLElem = @import("../html/elements.zig");
 pub const Union = @import("../html/elements.zig").Union;
 
@@ -102,32 +103,126 @@ pub const Element = struct {
         var buf = std.ArrayList(u8).init(alloc);
         defer buf.deinit();
 
-        try writeNode(parser.elementToNode(self), buf.writer());
+        try writeNode(try contentNode(self), buf.writer());
         // TODO express the caller owned the slice.
         // https://github.com/lightpanda-io/jsruntime-lib/issues/195
         return buf.toOwnedSlice();
     }
 
     pub fn set_innerHTML(self: *parser.Element, str: []const u8) !void {
-        const node = parser.elementToNode(self);
-        const doc = try parser.nodeOwnerDocument(node) orelse return parser.DOMError.WrongDocument;
-        // parse the fragment
-        const fragment = try parser.documentParseFragmentFromStr(doc, str);
+        const node = try contentNode(self);
 
         // remove existing children
         try Node.removeChildren(node);
 
+        try insertFragment(node, null, str);
+    }
+
+    // contentNode returns the node holding the element's children: the
+    // contents fragment for a template, the element itself otherwise.
+    fn contentNode(self: *parser.Element) !*parser.Node {
+        const node = parser.elementToNode(self);
+        const tag = try parser.elementHTMLGetTagType(@as(*parser.ElementHTML, @ptrCast(self)));
+        if (tag != .template) return node;
+
+        const content = try parser.templateContent(@as(*parser.Template, @ptrCast(self)));
+        return parser.documentFragmentToNode(content);
+    }
+
+    // insertFragment parses the HTML string as a fragment and inserts the
+    // resulting nodes into parent, before the ref node, or at the end if ref
+    // is null.
+    fn insertFragment(parent: *parser.Node, ref: ?*parser.Node, str: []const u8) !void {
+        // use the parent's document: a template's contents is owned by an
+        // inert document.
+        const doc = try parser.nodeOwnerDocument(parent) orelse return parser.DOMError.WrongDocument;
+        // parse the fragment
+        const fragment = try parser.documentParseFragmentFromStr(doc, str);
+
         // get fragment body children
         const children = try parser.documentFragmentBodyChildren(fragment) orelse return;
 
-        // append children to the node
-        const ln = try parser.nodeListLength(children);
-        var i: u32 = 0;
-        while (i < ln) {
-            defer i += 1;
-            const child = try parser.nodeListItem(children, i) orelse continue;
-            _ = try parser.nodeAppendChild(node, child);
+        // insert children into the parent.
+        // The node list is live, each insertion removes the child from it.
+        while (try parser.nodeListItem(children, 0)) |child| {
+            if (ref) |r| {
+                _ = try parser.nodeInsertBefore(parent, child, r);
+            } else {
+                _ = try parser.nodeAppendChild(parent, child);
+            }
+        }
+    }
+
+    // https://w3c.github.io/DOM-Parsing/#dom-element-insertadjacenthtml
+    pub fn _insertAdjacentHTML(self: *parser.Element, position: []const u8, str: []const u8) !void {
+        const node = parser.elementToNode(self);
+
+        if (std.ascii.eqlIgnoreCase(position, "beforebegin")) {
+            const parent = try adjacentParent(node);
+            return insertFragment(parent, node, str);
         }
+        if (std.ascii.eqlIgnoreCase(position, "afterbegin")) {
+            return insertFragment(node, try parser.nodeFirstChild(node), str);
+        }
+        if (std.ascii.eqlIgnoreCase(position, "beforeend")) {
+            return insertFragment(node, null, str);
+        }
+        if (std.ascii.eqlIgnoreCase(position, "afterend")) {
+            const parent = try adjacentParent(node);
+            return insertFragment(parent, try parser.nodeNextSibling(node), str);
+        }
+
+        return parser.DOMError.Syntax;
+    }
+
+    // adjacentParent returns the parent of the node for beforebegin and
+    // afterend insertions.
+    // If the parent is null or a document, a NoModificationAllowedError is
+    // returned.
+    fn adjacentParent(node: *parser.Node) !*parser.Node {
+        const parent = try parser.nodeParentNode(node) orelse return parser.DOMError.NoModificationAllowed;
+        if (try parser.nodeType(parent) == .document) return parser.DOMError.NoModificationAllowed;
+        return parent;
+    }
+
+    // insertAdjacent inserts the node at the position relative to the element
+    // and returns it. It returns null for beforebegin and afterend positions
+    // if the element has no parent.
+    // https://dom.spec.whatwg.org/#insert-adjacent
+    fn insertAdjacent(self: *parser.Element, position: []const u8, node: *parser.Node) !?*parser.Node {
+        const elt = parser.elementToNode(self);
+
+        if (std.ascii.eqlIgnoreCase(position, "beforebegin")) {
+            const parent = try parser.nodeParentNode(elt) orelse return null;
+            return try parser.nodeInsertBefore(parent, node, elt);
+        }
+        if (std.ascii.eqlIgnoreCase(position, "afterbegin")) {
+            const first = try parser.nodeFirstChild(elt) orelse return try parser.nodeAppendChild(elt, node);
+            return try parser.nodeInsertBefore(elt, node, first);
+        }
+        if (std.ascii.eqlIgnoreCase(position, "beforeend")) {
+            return try parser.nodeAppendChild(elt, node);
+        }
+        if (std.ascii.eqlIgnoreCase(position, "afterend")) {
+            const parent = try parser.nodeParentNode(elt) orelse return null;
+            const next = try parser.nodeNextSibling(elt) orelse return try parser.nodeAppendChild(parent, node);
+            return try parser.nodeInsertBefore(parent, node, next);
+        }
+
+        return parser.DOMError.Syntax;
+    }
+
+    // https://dom.spec.whatwg.org/#dom-element-insertadjacentelement
+    pub fn _insertAdjacentElement(self: *parser.Element, position: []const u8, element: *parser.Element) !?Union {
+        const res = try insertAdjacent(self, position, parser.elementToNode(element)) orelse return null;
+        return try toInterface(parser.nodeToElement(res));
+    }
+
+    // https://dom.spec.whatwg.org/#dom-element-insertadjacenttext
+    pub fn _insertAdjacentText(self: *parser.Element, position: []const u8, data: []const u8) !void {
+        const doc = try parser.nodeOwnerDocument(parser.elementToNode(self)) orelse return parser.DOMError.WrongDocument;
+        const txt = try parser.documentCreateTextNode(doc, data);
+        _ = try insertAdjacent(self, position, @as(*parser.Node, @ptrCast(txt)));
     }
 
     pub fn _hasAttributes(self: *parser.Element) !bool {
@@ -308,27 +403,70 @@ pub const Element = struct {
         return css.querySelectorAll(alloc, parser.elementToNode(self), selector);
     }
 
-    // TODO according with https://dom.spec.whatwg.org/#parentnode, the
-    // function must accept either node or string.
+    pub fn _matches(self: *parser.Element, alloc: std.mem.Allocator, selector: []const u8) !bool {
+        return try css.matches(alloc, parser.elementToNode(self), selector);
+    }
+
+    // webkitMatchesSelector is a legacy alias of matches.
+    pub fn _webkitMatchesSelector(self: *parser.Element, alloc: std.mem.Allocator, selector: []const u8) !bool {
+        return try _matches(self, alloc, selector);
+    }
+
+    pub fn _closest(self: *parser.Element, alloc: std.mem.Allocator, selector: []const u8) !?Union {
+        const n = try css.closest(alloc, parser.elementToNode(self), selector);
+
+        if (n == null) return null;
+
+        return try toInterface(parser.nodeToElement(n.?));
+    }
+
+    // https://drafts.csswg.org/cssom-view/#dom-element-getboundingclientrect
+    // There is no layout, so the rect is always empty and rooted at the
+    // origin.
+    pub fn _getBoundingClientRect(_: *parser.Element) DOMRect {
+        return .{};
+    }
+
+    // https://drafts.csswg.org/cssom-view/#dom-element-scrollintoview
+    // There is no layout, so there is nothing to scroll.
+    // The boolean or options argument is validated by the polyfill, which
+    // also dispatches a scroll event to the document.
+    pub fn _scrollIntoView(_: *parser.Element) void {}
+
+    // String arguments are converted into text nodes by the polyfill.
     // blocked by https://github.com/lightpanda-io/jsruntime-lib/issues/114
     pub fn _prepend(self: *parser.Element, nodes: ?Variadic(*parser.Node)) !void {
         return Node.prepend(parser.elementToNode(self), nodes);
     }
 
-    // TODO according with https://dom.spec.whatwg.org/#parentnode, the
-    // function must accept either node or string.
-    // blocked by https://github.com/lightpanda-io/jsruntime-lib/issues/114
     pub fn _append(self: *parser.Element, nodes: ?Variadic(*parser.Node)) !void {
         return Node.append(parser.elementToNode(self), nodes);
     }
 
-    // TODO according with https://dom.spec.whatwg.org/#parentnode, the
-    // function must accept either node or string.
-    // blocked by https://github.com/lightpanda-io/jsruntime-lib/issues/114
     pub fn _replaceChildren(self: *parser.Element, nodes: ?Variadic(*parser.Node)) !void {
         return Node.replaceChildren(parser.elementToNode(self), nodes);
     }
 
+    // ChildNode
+    // String arguments are converted into text nodes by the polyfill.
+    // blocked by https://github.com/lightpanda-io/jsruntime-lib/issues/114
+
+    pub fn _before(self: *parser.Element, nodes: ?Variadic(*parser.Node)) !void {
+        return Node.before(parser.elementToNode(self), nodes);
+    }
+
+    pub fn _after(self: *parser.Element, nodes: ?Variadic(*parser.Node)) !void {
+        return Node.after(parser.elementToNode(self), nodes);
+    }
+
+    pub fn _replaceWith(self: *parser.Element, nodes: ?Variadic(*parser.Node)) !void {
+        return Node.replaceWith(parser.elementToNode(self), nodes);
+    }
+
+    pub fn _remove(self: *parser.Element) !void {
+        return Node.remove(parser.elementToNode(self));
+    }
+
     pub fn deinit(_: *parser.Element, _: std.mem.Allocator) void {}
 };
 
@@ -409,9 +547,51 @@ pub fn testExecFn(
 
         .{ .src = "c.prepend(document.createTextNode('foo'))", .ex = "undefined" },
         .{ .src = "c.append(document.createTextNode('bar'))", .ex = "undefined" },
+
+        .{ .src = "let pn = document.createElement('div')", .ex = "undefined" },
+        .{ .src = "pn.append('foo', document.createElement('b'), 'bar')", .ex = "undefined" },
+        .{ .src = "pn.innerHTML", .ex = "foo<b></b>bar" },
+        .{ .src = "pn.prepend(document.createElement('i'), 1)", .ex = "undefined" },
+        .{ .src = "pn.innerHTML", .ex = "<i></i>1foo<b></b>bar" },
+        .{ .src = "pn.replaceChildren(pn.lastChild, 'baz', pn.firstChild)", .ex = "undefined" },
+        .{ .src = "pn.innerHTML", .ex = "barbaz<i></i>" },
+        .{ .src = "pn.replaceChildren()", .ex = "undefined" },
+        .{ .src = "pn.childNodes.length", .ex = "0" },
+        .{ .src = "var err; try { pn.append(pn) } catch (e) { err = e } err.name", .ex = "HierarchyRequestError" },
     };
     try checkCases(js_env, &parentNode);
 
+    var childNode = [_]Case{
+        .{ .src = "let cn = document.createElement('div')", .ex = "undefined" },
+        .{ .src = "cn.innerHTML = '<i></i><b></b><u></u>'", .ex = "<i></i><b></b><u></u>" },
+        .{ .src = "let cnb = cn.children[1]", .ex = "undefined" },
+        .{ .src = "cnb.before('foo', document.createElement('p'))", .ex = "undefined" },
+        .{ .src = "cn.innerHTML", .ex = "<i></i>foo<p></p><b></b><u></u>" },
+        .{ .src = "cnb.after('bar')", .ex = "undefined" },
+        .{ .src = "cn.innerHTML", .ex = "<i></i>foo<p></p><b></b>bar<u></u>" },
+
+        // the arguments are siblings of the context node.
+        .{ .src = "cnb.before(cnb.previousSibling, cn.firstChild)", .ex = "undefined" },
+        .{ .src = "cn.innerHTML", .ex = "foo<p></p><i></i><b></b>bar<u></u>" },
+        .{ .src = "cnb.after(cn.lastChild, cnb.nextSibling)", .ex = "undefined" },
+        .{ .src = "cn.innerHTML", .ex = "foo<p></p><i></i><b></b><u></u>bar" },
+
+        .{ .src = "cnb.replaceWith('baz', cnb, cnb.nextSibling)", .ex = "undefined" },
+        .{ .src = "cn.innerHTML", .ex = "foo<p></p><i></i>baz<b></b><u></u>bar" },
+        .{ .src = "cnb.replaceWith(document.createElement('s'))", .ex = "undefined" },
+        .{ .src = "cn.innerHTML", .ex = "foo<p></p><i></i>baz<s></s><u></u>bar" },
+        .{ .src = "cnb.parentNode", .ex = "null" },
+
+        // nothing is done without parent.
+        .{ .src = "cnb.before('foo'); cnb.after('bar'); cnb.replaceWith('baz'); cnb.remove()", .ex = "undefined" },
+
+        .{ .src = "cn.firstChild.remove()", .ex = "undefined" },
+        .{ .src = "cn.innerHTML", .ex = "<p></p><i></i>baz<s></s><u></u>bar" },
+        .{ .src = "cn.lastElementChild.replaceWith()", .ex = "undefined" },
+        .{ .src = "cn.innerHTML", .ex = "<p></p><i></i>baz<s></s>bar" },
+    };
+    try checkCases(js_env, &childNode);
+
     var elementSibling = [_]Case{
         .{ .src = "let d = document.getElementById('para')", .ex = "undefined" },
         .{ .src = "d.previousElementSibling.nodeName", .ex = "P" },
@@ -445,6 +625,47 @@ pub fn testExecFn(
     };
     try checkCases(js_env, &querySelector);
 
+    var closest = [_]Case{
+        .{ .src = "let cl = document.getElementById('para-empty-child')", .ex = "undefined" },
+        .{ .src = "cl.closest('span').id", .ex = "para-empty-child" },
+        .{ .src = "cl.closest('p.ok.empty').id", .ex = "para-empty" },
+        .{ .src = "cl.closest('p:not(.foo)').id", .ex = "para-empty" },
+        .{ .src = "cl.closest('div > p').id", .ex = "para-empty" },
+        .{ .src = "cl.closest('[id=content]').id", .ex = "content" },
+        .{ .src = "cl.closest('div#content').id", .ex = "content" },
+        .{ .src = "cl.closest('p#para')", .ex = "null" },
+        .{ .src = "cl.closest('foo')", .ex = "null" },
+        .{ .src = "var err; try { cl.closest('p.') } catch (e) { err = e } err.name", .ex = "SyntaxError" },
+    };
+    try checkCases(js_env, &closest);
+
+    var matches = [_]Case{
+        .{ .src = "let mt = document.getElementById('para-empty')", .ex = "undefined" },
+        .{ .src = "mt.matches('p')", .ex = "true" },
+        .{ .src = "mt.matches('p.ok.empty')", .ex = "true" },
+        .{ .src = "mt.matches('#para-empty')", .ex = "true" },
+        .{ .src = "mt.matches('[id^=para]')", .ex = "true" },
+        .{ .src = "mt.matches('div > p:first-of-type')", .ex = "true" },
+        .{ .src = "mt.matches('a, p')", .ex = "true" },
+        .{ .src = "mt.matches('p:not(.ok)')", .ex = "false" },
+        .{ .src = "mt.matches('span')", .ex = "false" },
+        // descendants are not matched.
+        .{ .src = "mt.matches('p span')", .ex = "false" },
+        .{ .src = "mt.webkitMatchesSelector('p.empty')", .ex = "true" },
+        .{ .src = "mt.webkitMatchesSelector('div')", .ex = "false" },
+        .{ .src = "var err; try { mt.matches('p.') } catch (e) { err = e } err.name", .ex = "SyntaxError" },
+    };
+    try checkCases(js_env, &matches);
+
+    var bounding = [_]Case{
+        .{ .src = "let br = document.getElementById('para').getBoundingClientRect()", .ex = "undefined" },
+        .{ .src = "br instanceof DOMRect", .ex = "true" },
+        .{ .src = "br.x + ',' + br.y + ',' + br.width + ',' + br.height", .ex = "0,0,0,0" },
+        .{ .src = "br.top + ',' + br.right + ',' + br.bottom + ',' + br.left", .ex = "0,0,0,0" },
+        .{ .src = "br.toJSON().width", .ex = "0" },
+    };
+    try checkCases(js_env, &bounding);
+
     var attrNode = [_]Case{
         .{ .src = "let f = document.getElementById('content')", .ex = "undefined" },
         .{ .src = "let ff = document.createAttribute('foo')", .ex = "undefined" },
@@ -470,4 +691,56 @@ pub fn testExecFn(
         .{ .src = "document.getElementById('para-empty').innerHTML.trim()", .ex = "<span id=\"para-empty-child\"></span>" },
     };
     try checkCases(js_env, &innerHTML);
+
+    var insertAdjacentHTML = [_]Case{
+        .{ .src = "let ia = document.createElement('ul')", .ex = "undefined" },
+        .{ .src = "ia.innerHTML = '<li>b</li>'", .ex = "<li>b</li>" },
+        .{ .src = "ia.insertAdjacentHTML('beforeend', '<li>c</li><li>d</li>')", .ex = "undefined" },
+        .{ .src = "ia.insertAdjacentHTML('afterbegin', '<li>a</li>')", .ex = "undefined" },
+        .{ .src = "ia.innerHTML", .ex = "<li>a</li><li>b</li><li>c</li><li>d</li>" },
+        .{ .src = "var err; try { ia.insertAdjacentHTML('afterend', '<p></p>') } catch (e) { err = e } err.name", .ex = "NoModificationAllowedError" },
+        .{ .src = "try { ia.insertAdjacentHTML('foo', '<p></p>') } catch (e) { err = e } err.name", .ex = "SyntaxError" },
+        .{ .src = "let iap = document.createElement('div')", .ex = "undefined" },
+        .{ .src = "iap.appendChild(ia).nodeName", .ex = "UL" },
+        .{ .src = "ia.insertAdjacentHTML('beforebegin', '<p>before</p>')", .ex = "undefined" },
+        .{ .src = "ia.insertAdjacentHTML('afterend', '<p>after</p>')", .ex = "undefined" },
+        .{ .src = "iap.firstChild.textContent", .ex = "before" },
+        .{ .src = "iap.lastChild.textContent", .ex = "after" },
+        .{ .src = "iap.childNodes.length", .ex = "3" },
+    };
+    try checkCases(js_env, &insertAdjacentHTML);
+
+    var insertAdjacentElement = [_]Case{
+        .{ .src = "let iae = document.createElement('ul')", .ex = "undefined" },
+        .{ .src = "iae.innerHTML = '<li>b</li>'", .ex = "<li>b</li>" },
+        .{ .src = "let iaeli = document.createElement('li')", .ex = "undefined" },
+        .{ .src = "iae.insertAdjacentElement('afterbegin', iaeli) === iaeli", .ex = "true" },
+        .{ .src = "iae.firstChild === iaeli", .ex = "true" },
+        .{ .src = "iae.insertAdjacentElement('beforeend', document.createElement('li')).nodeName", .ex = "LI" },
+        .{ .src = "iae.childNodes.length", .ex = "3" },
+
+        // no parent
+        .{ .src = "iae.insertAdjacentElement('beforebegin', document.createElement('p'))", .ex = "null" },
+        .{ .src = "iae.insertAdjacentElement('afterend', document.createElement('p'))", .ex = "null" },
+        .{ .src = "try { iae.insertAdjacentElement('foo', document.createElement('p')) } catch (e) { err = e } err.name", .ex = "SyntaxError" },
+
+        .{ .src = "let iaep = document.createElement('div')", .ex = "undefined" },
+        .{ .src = "iaep.appendChild(iae).nodeName", .ex = "UL" },
+        .{ .src = "iae.insertAdjacentElement('beforebegin', document.createElement('p')).nodeName", .ex = "P" },
+        .{ .src = "iae.insertAdjacentElement('afterend', document.createElement('span')).nodeName", .ex = "SPAN" },
+        .{ .src = "iaep.firstChild.nodeName", .ex = "P" },
+        .{ .src = "iaep.lastChild.nodeName", .ex = "SPAN" },
+    };
+    try checkCases(js_env, &insertAdjacentElement);
+
+    var insertAdjacentText = [_]Case{
+        .{ .src = "let iat = document.createElement('p')", .ex = "undefined" },
+        .{ .src = "iat.innerHTML = '<b>b</b>'", .ex = "<b>b</b>" },
+        .{ .src = "iat.insertAdjacentText('afterbegin', 'a')", .ex = "undefined" },
+        .{ .src = "iat.insertAdjacentText('beforeend', 'c')", .ex = "undefined" },
+        .{ .src = "iat.innerHTML", .ex = "a<b>b</b>c" },
+        .{ .src = "iat.insertAdjacentText('afterend', 'd')", .ex = "undefined" },
+        .{ .src = "try { iat.insertAdjacentText('foo', 'd') } catch (e) { err = e } err.name", .ex = "SyntaxError" },
+    };
+    try checkCases(js_env, &insertAdjacentText);
 }
@@ -28,6 +28,7 @@ const parser = @import("netsurf");
 const EventHandler = @import("../events/event.zig").EventHandler;
 
 const DOMException = @import("exceptions.zig").DOMException;
+const AbortSignal = @import("abort_controller.zig").AbortSignal;
 const Nod = @import("node.zig");
 
 // EventTarget interfaces
@@ -48,21 +49,32 @@ pub const EventTarget = struct {
     // JS funcs
     // --------
 
+    // https://dom.spec.whatwg.org/#dom-eventtarget-addeventlistener
+    // The options argument, either a boolean for the capture flag or an
+    // options dictionary, is flattened by the polyfill.
+    // see https://github.com/lightpanda-io/jsruntime-lib/issues/114
     pub fn _addEventListener(
         self: *parser.EventTarget,
         alloc: std.mem.Allocator,
         eventType: []const u8,
         cbk: Callback,
-        capture: ?bool,
-        // TODO: hanle EventListenerOptions
-        // see #https://github.com/lightpanda-io/jsruntime-lib/issues/114
+        capture_opt: ?bool,
+        once: ?bool,
+        passive: ?bool,
+        signal: ?*AbortSignal,
     ) !void {
+        const capture = capture_opt orelse false;
+
+        // If listener’s signal is not null and is aborted, then return.
+        if (signal) |s| {
+            if (s.aborted) return;
+        }
 
         // check if event target has already this listener
         const lst = try parser.eventTargetHasListener(
             self,
             eventType,
-            capture orelse false,
+            capture,
             cbk.id(),
         );
         if (lst != null) {
@@ -74,26 +86,42 @@ pub const EventTarget = struct {
             alloc,
             eventType,
             EventHandler,
-            .{ .cbk = cbk },
-            capture orelse false,
+            .{
+                .cbk = cbk,
+                .once = once orelse false,
+                .passive = passive orelse false,
+            },
+            capture,
         );
+
+        // If listener’s signal is not null, then add the following abort
+        // steps to it: remove an event listener.
+        if (signal) |s| {
+            try s.addAlgorithm(alloc, .{
+                .et = self,
+                .typ = eventType,
+                .cbk_id = cbk.id(),
+                .capture = capture,
+            });
+        }
     }
 
+    // https://dom.spec.whatwg.org/#dom-eventtarget-removeeventlistener
+    // The options argument is flattened by the polyfill.
     pub fn _removeEventListener(
         self: *parser.EventTarget,
         alloc: std.mem.Allocator,
         eventType: []const u8,
         cbk_id: JSObjectID,
-        capture: ?bool,
-        // TODO: hanle EventListenerOptions
-        // see #https://github.com/lightpanda-io/jsruntime-lib/issues/114
+        capture_opt: ?bool,
     ) !void {
+        const capture = capture_opt orelse false;
 
         // check if event target has already this listener
         const lst = try parser.eventTargetHasListener(
             self,
             eventType,
-            capture orelse false,
+            capture,
             cbk_id.get(),
         );
         if (lst == null) {
@@ -106,7 +134,7 @@ pub const EventTarget = struct {
             alloc,
             eventType,
             lst.?,
-            capture orelse false,
+            capture,
         );
     }
 
@@ -240,4 +268,71 @@ pub fn testExecFn(
         .{ .src = "cur.getAttribute('id')", .ex = "content" },
     };
     try checkCases(js_env, &bubbles_child);
+
+    var once = [_]Case{
+        .{ .src = "nb = 0", .ex = "0" },
+        .{ .src = "content.addEventListener('once', cbk, {once: true})", .ex = "undefined" },
+        .{ .src = "content.dispatchEvent(new Event('once'))", .ex = "true" },
+        .{ .src = "content.dispatchEvent(new Event('once'))", .ex = "true" },
+        .{ .src = "nb", .ex = "1" },
+        // re-entrant dispatch doesn't call the listener twice.
+        .{ .src = "nb = 0", .ex = "0" },
+        .{ .src = "content.addEventListener('reentrant', function () { nb++; content.dispatchEvent(new Event('reentrant')) }, {once: true})", .ex = "undefined" },
+        .{ .src = "content.dispatchEvent(new Event('reentrant'))", .ex = "true" },
+        .{ .src = "nb", .ex = "1" },
+        // the next listeners are still called after a once listener.
+        .{ .src = "nb = 0", .ex = "0" },
+        .{ .src = "content.addEventListener('once2', cbk, {once: true}); content.addEventListener('once2', () => { nb += 10 })", .ex = "undefined" },
+        .{ .src = "content.dispatchEvent(new Event('once2')); content.dispatchEvent(new Event('once2'))", .ex = "true" },
+        .{ .src = "nb", .ex = "21" },
+        // a once listener called can be added again.
+        .{ .src = "content.addEventListener('once2', cbk, {once: true})", .ex = "undefined" },
+        .{ .src = "content.dispatchEvent(new Event('once2'))", .ex = "true" },
+        .{ .src = "nb", .ex = "32" },
+    };
+    try checkCases(js_env, &once);
+
+    var options = [_]Case{
+        .{ .src = "nb = 0; evt = undefined; phase = undefined; cur = undefined", .ex = "undefined" },
+        .{ .src = "content.addEventListener('opts', cbk, {capture: true, passive: true})", .ex = "undefined" },
+        .{ .src = "para.dispatchEvent(new Event('opts'))", .ex = "true" },
+        .{ .src = "nb", .ex = "1" },
+        .{ .src = "phase", .ex = "1" },
+        .{ .src = "content.removeEventListener('opts', cbk, {capture: true})", .ex = "undefined" },
+        .{ .src = "para.dispatchEvent(new Event('opts'))", .ex = "true" },
+        .{ .src = "nb", .ex = "1" },
+        // capture false registers a bubbling listener, a null listener is ignored.
+        .{ .src = "content.addEventListener('opts', cbk, false); content.addEventListener('opts', null)", .ex = "undefined" },
+        .{ .src = "para.dispatchEvent(new Event('opts'))", .ex = "true" },
+        .{ .src = "phase", .ex = "3" },
+        .{ .src = "content.removeEventListener('opts', cbk, {})", .ex = "undefined" },
+        .{ .src = "para.dispatchEvent(new Event('opts'))", .ex = "true" },
+        .{ .src = "nb", .ex = "2" },
+        .{ .src = "try { content.addEventListener('opts', cbk, {signal: 'foo'}) } catch (e) { e instanceof TypeError }", .ex = "true" },
+    };
+    try checkCases(js_env, &options);
+
+    var signal = [_]Case{
+        .{ .src = "nb = 0", .ex = "0" },
+        .{ .src = "let ac = new AbortController()", .ex = "undefined" },
+        .{ .src = "content.addEventListener('signal', cbk, {signal: ac.signal})", .ex = "undefined" },
+        .{ .src = "content.dispatchEvent(new Event('signal'))", .ex = "true" },
+        .{ .src = "nb", .ex = "1" },
+        .{ .src = "ac.abort()", .ex = "undefined" },
+        .{ .src = "content.dispatchEvent(new Event('signal'))", .ex = "true" },
+        .{ .src = "nb", .ex = "1" },
+        // an aborted signal prevents the listener registration.
+        .{ .src = "content.addEventListener('signal', cbk, {signal: ac.signal})", .ex = "undefined" },
+        .{ .src = "content.dispatchEvent(new Event('signal'))", .ex = "true" },
+        .{ .src = "nb", .ex = "1" },
+        // signal with once.
+        .{ .src = "nb = 0", .ex = "0" },
+        .{ .src = "let ac2 = new AbortController()", .ex = "undefined" },
+        .{ .src = "content.addEventListener('signal2', cbk, {signal: ac2.signal, once: true})", .ex = "undefined" },
+        .{ .src = "content.dispatchEvent(new Event('signal2'))", .ex = "true" },
+        .{ .src = "ac2.abort()", .ex = "undefined" },
+        .{ .src = "content.dispatchEvent(new Event('signal2'))", .ex = "true" },
+        .{ .src = "nb", .ex = "1" },
+    };
+    try checkCases(js_env, &signal);
 }
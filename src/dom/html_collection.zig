@@ -119,9 +119,14 @@ pub const MatchByClassName = struct {
         };
     }
 
+    // match returns true if the element has all the class names.
+    // libdom compares the classes ASCII case-insensitively in quirks mode.
+    // https://dom.spec.whatwg.org/#concept-getelementsbyclassname
     pub fn match(self: MatchByClassName, node: *parser.Node) !bool {
-        var it = std.mem.splitAny(u8, self.classNames, " ");
+        var it = std.mem.tokenizeAny(u8, self.classNames, " \t\n\r\x0C");
         const e = parser.nodeToElement(node);
+        // an empty set of classes matches nothing.
+        if (it.peek() == null) return false;
         while (it.next()) |c| {
             if (!try parser.elementHasClass(e, c)) {
                 return false;
@@ -323,13 +328,12 @@ pub const HTMLCollection = struct {
     // itself.
     include_root: bool = false,
 
-    // save a state for the collection to improve the _item speed.
-    cur_idx: ?u32 = undefined,
-    cur_node: ?*parser.Node = undefined,
-
-    // array_like_keys is used to keep reference to array like interface implementation.
-    // the collection generates keys string which must be free on deinit.
-    array_like_keys: std.ArrayListUnmanaged([]u8) = .{},
+    // save a state for the collection to improve the _item speed, valid
+    // until the tree changes.
+    version: ?u64 = null,
+    cur_idx: u32 = 0,
+    cur_node: ?*parser.Node = null,
+    len: ?u32 = null,
 
     // start returns the first node to walk on.
     fn start(self: HTMLCollection) !?*parser.Node {
@@ -348,12 +352,27 @@ pub const HTMLCollection = struct {
         };
     }
 
+    // sync drops the saved state when the tree has changed since.
+    fn sync(self: *HTMLCollection) void {
+        const v = parser.treeVersion();
+        if (self.version == v) return;
+
+        self.version = v;
+        self.cur_idx = 0;
+        self.cur_node = null;
+        self.len = null;
+    }
+
     /// get_length computes the collection's length dynamically according to
     /// the current root structure.
+    /// The collection is live, the length is kept until the tree changes.
     // TODO: nodes retrieved must be de-referenced.
     pub fn get_length(self: *HTMLCollection) !u32 {
         if (self.root == null) return 0;
 
+        self.sync();
+        if (self.len) |l| return l;
+
         var len: u32 = 0;
         var node = try self.start() orelse return 0;
 
@@ -367,18 +386,21 @@ pub const HTMLCollection = struct {
             node = try self.walker.get_next(self.root.?, node) orelse break;
         }
 
+        self.len = len;
         return len;
     }
 
     pub fn item(self: *HTMLCollection, index: u32) !?*parser.Node {
         if (self.root == null) return null;
 
+        self.sync();
+
         var i: u32 = 0;
         var node: *parser.Node = undefined;
 
         // Use the current state to improve speed if possible.
-        if (self.cur_idx != null and index >= self.cur_idx.?) {
-            i = self.cur_idx.?;
+        if (self.cur_node != null and index >= self.cur_idx) {
+            i = self.cur_idx;
             node = self.cur_node.?;
         } else {
             node = try self.start() orelse return null;
@@ -443,38 +465,7 @@ pub const HTMLCollection = struct {
         return null;
     }
 
-    fn item_name(elt: *parser.Element) !?[]const u8 {
-        if (try parser.elementGetAttribute(elt, "id")) |v| {
-            return v;
-        }
-        if (try parser.elementGetAttribute(elt, "name")) |v| {
-            return v;
-        }
-
-        return null;
-    }
-
-    pub fn postAttach(self: *HTMLCollection, alloc: std.mem.Allocator, js_obj: jsruntime.JSObject) !void {
-        const ln = try self.get_length();
-        var i: u32 = 0;
-        while (i < ln) {
-            defer i += 1;
-            const k = try std.fmt.allocPrint(alloc, "{d}", .{i});
-            try self.array_like_keys.append(alloc, k);
-
-            const node = try self.item(i) orelse unreachable;
-            const e = @as(*parser.Element, @ptrCast(node));
-            try js_obj.set(k, e);
-
-            if (try item_name(e)) |name| {
-                try js_obj.set(name, e);
-            }
-        }
-    }
-
     pub fn deinit(self: *HTMLCollection, alloc: std.mem.Allocator) void {
-        for (self.array_like_keys_) |k| alloc.free(k);
-        self.array_like_keys.deinit(alloc);
         self.matcher.deinit(alloc);
     }
 };
@@ -529,6 +520,17 @@ pub fn testExecFn(
         .{ .src = "getElementsByTagName.item(2).textContent", .ex = "OK live" },
         .{ .src = "content.insertBefore(p, pe) != undefined", .ex = "true" },
         .{ .src = "getElementsByTagName.item(0).textContent", .ex = "OK live" },
+
+        // the indexed and named properties are live too.
+        .{ .src = "getElementsByTagName[0].textContent", .ex = "OK live" },
+        .{ .src = "p.setAttribute('id', 'p-live'); getElementsByTagName['p-live'] === p", .ex = "true" },
+        .{ .src = "content.removeChild(p) === p", .ex = "true" },
+        .{ .src = "getElementsByTagName.length", .ex = "2" },
+        .{ .src = "getElementsByTagName[2]", .ex = "undefined" },
+        .{ .src = "getElementsByTagName['p-live']", .ex = "undefined" },
+        .{ .src = "let plive = []; for (let i = 0; i < getElementsByTagName.length; i++) plive.push(getElementsByTagName[i].localName); plive.join(',')", .ex = "p,p" },
+        .{ .src = "typeof getElementsByTagName.item", .ex = "function" },
+        .{ .src = "HTMLCollection.prototype[0]", .ex = "undefined" },
     };
     try checkCases(js_env, &getElementsByTagName);
 }
@@ -36,30 +36,29 @@ pub const Interfaces = generate.Tuple(.{
     MutationRecords,
 });
 
-const Walker = @import("../dom/walker.zig").WalkerChildren;
-
 const log = std.log.scoped(.events);
 
 // WEB IDL https://dom.spec.whatwg.org/#interface-mutationobserver
+//
+// The native MutationObserver queues the records and calls its callback each
+// time the queue becomes non-empty. The records' delivery to the user's
+// callback on a microtask is done by the MutationObserver polyfill.
 pub const MutationObserver = struct {
     cbk: Callback,
     observers: Observers,
+    // records contains the queued records, not yet taken.
+    records: Records,
 
     pub const mem_guarantied = true;
 
     const Observer = struct {
+        mo: *MutationObserver,
         node: *parser.Node,
         options: MutationObserverInit,
     };
 
-    const deinitFunc = struct {
-        fn deinit(ctx: ?*anyopaque, alloc: std.mem.Allocator) void {
-            const o: *Observer = @ptrCast(@alignCast(ctx));
-            alloc.destroy(o);
-        }
-    }.deinit;
-
     const Observers = std.ArrayListUnmanaged(*Observer);
+    const Records = std.ArrayListUnmanaged(MutationRecord);
 
     pub const MutationObserverInit = struct {
         childList: bool = false,
@@ -84,6 +83,7 @@ pub const MutationObserver = struct {
         return MutationObserver{
             .cbk = cbk,
             .observers = .{},
+            .records = .{},
         };
     }
 
@@ -93,8 +93,17 @@ pub const MutationObserver = struct {
     }
 
     pub fn _observe(self: *MutationObserver, alloc: std.mem.Allocator, node: *parser.Node, options: ?MutationObserverInit) !void {
+        // a new observe call on the same node replaces the options.
+        for (self.observers.items, 0..) |o, i| {
+            if (o.node != node) continue;
+            try removeListeners(alloc, o);
+            alloc.destroy(self.observers.swapRemove(i));
+            break;
+        }
+
         const o = try alloc.create(Observer);
         o.* = .{
+            .mo = self,
             .node = node,
             .options = resolveOptions(options),
         };
@@ -104,88 +113,122 @@ pub const MutationObserver = struct {
         try self.observers.append(alloc, o);
 
         // register node's events.
-        if (o.options.childList or o.options.subtree) {
-            try parser.eventTargetAddEventListener(
-                parser.toEventTarget(parser.Node, node),
-                alloc,
-                "DOMNodeInserted",
-                EventHandler,
-                .{ .cbk = self.cbk, .data = o, .deinitFunc = deinitFunc },
-                false,
-            );
-            try parser.eventTargetAddEventListener(
-                parser.toEventTarget(parser.Node, node),
-                alloc,
-                "DOMNodeRemoved",
-                EventHandler,
-                .{ .cbk = self.cbk, .data = o, .deinitFunc = deinitFunc },
-                false,
-            );
+        // Mutation events bubble, so the descendants' mutations are
+        // received too.
+        if (o.options.childList) {
+            try addListener(alloc, o, "DOMNodeInserted");
+            try addListener(alloc, o, "DOMNodeRemoved");
         }
         if (o.options.attr()) {
-            try parser.eventTargetAddEventListener(
-                parser.toEventTarget(parser.Node, node),
-                alloc,
-                "DOMAttrModified",
-                EventHandler,
-                .{ .cbk = self.cbk, .data = o, .deinitFunc = deinitFunc },
-                false,
-            );
+            try addListener(alloc, o, "DOMAttrModified");
         }
         if (o.options.cdata()) {
-            try parser.eventTargetAddEventListener(
-                parser.toEventTarget(parser.Node, node),
-                alloc,
-                "DOMCharacterDataModified",
-                EventHandler,
-                .{ .cbk = self.cbk, .data = o, .deinitFunc = deinitFunc },
-                false,
-            );
+            try addListener(alloc, o, "DOMCharacterDataModified");
+        }
+    }
+
+    fn addListener(alloc: std.mem.Allocator, o: *Observer, typ: []const u8) !void {
+        try parser.eventTargetAddEventListener(
+            parser.toEventTarget(parser.Node, o.node),
+            alloc,
+            typ,
+            EventHandler,
+            .{ .cbk = o.mo.cbk, .data = o },
+            false,
+        );
+    }
+
+    // removeListeners unregisters the observer's events from its node.
+    fn removeListeners(alloc: std.mem.Allocator, o: *Observer) !void {
+        if (o.options.childList) {
+            try removeListener(alloc, o, "DOMNodeInserted");
+            try removeListener(alloc, o, "DOMNodeRemoved");
+        }
+        if (o.options.attr()) {
+            try removeListener(alloc, o, "DOMAttrModified");
         }
-        if (o.options.subtree) {
-            try parser.eventTargetAddEventListener(
-                parser.toEventTarget(parser.Node, node),
-                alloc,
-                "DOMSubtreeModified",
-                EventHandler,
-                .{ .cbk = self.cbk, .data = o, .deinitFunc = deinitFunc },
-                false,
-            );
+        if (o.options.cdata()) {
+            try removeListener(alloc, o, "DOMCharacterDataModified");
         }
     }
 
-    // TODO
-    pub fn _disconnect(_: *MutationObserver) !void {
-        // TODO unregister listeners.
+    fn removeListener(alloc: std.mem.Allocator, o: *Observer, typ: []const u8) !void {
+        const et = parser.toEventTarget(parser.Node, o.node);
+        const lst = try parser.eventTargetHasListener(et, typ, false, o.mo.cbk.id()) orelse return;
+        try parser.eventTargetRemoveEventListener(et, alloc, typ, lst, false);
     }
 
-    pub fn deinit(self: *MutationObserver, alloc: std.mem.Allocator) void {
-        // TODO unregister listeners.
-        for (self.observers.items) |o| alloc.destroy(o);
-        self.observers.deinit(alloc);
+    // clearObservers unregisters and frees all the observers.
+    fn clearObservers(self: *MutationObserver, alloc: std.mem.Allocator) !void {
+        for (self.observers.items) |o| {
+            try removeListeners(alloc, o);
+            alloc.destroy(o);
+        }
+        self.observers.clearRetainingCapacity();
     }
 
-    // TODO
-    pub fn _takeRecords(_: MutationObserver) ?[]const u8 {
-        return &[_]u8{};
+    pub fn _disconnect(self: *MutationObserver, alloc: std.mem.Allocator) !void {
+        try self.clearObservers(alloc);
+        self.clearRecords(alloc);
+    }
+
+    // https://dom.spec.whatwg.org/#dom-mutationobserver-takerecords
+    pub fn _takeRecords(self: *MutationObserver, alloc: std.mem.Allocator) !MutationRecords {
+        return .{ .records = try self.records.toOwnedSlice(alloc) };
+    }
+
+    // queue appends a record and notifies the callback if the queue was
+    // empty.
+    fn queue(self: *MutationObserver, alloc: std.mem.Allocator, mr: MutationRecord) !void {
+        try self.records.append(alloc, mr);
+        if (self.records.items.len > 1) return;
+
+        var res = CallbackResult.init(alloc);
+        defer res.deinit();
+
+        self.cbk.trycall(.{}, &res) catch |e| log.err("mutation observer callback error: {any}", .{e});
+
+        // in case of function error, we log the result and the trace.
+        if (!res.success) {
+            log.info("mutation observer callback error: {s}", .{res.result orelse "unknown"});
+            log.debug("{s}", .{res.stack orelse "no stack trace"});
+        }
+    }
+
+    fn clearRecords(self: *MutationObserver, alloc: std.mem.Allocator) void {
+        for (self.records.items) |*mr| mr.deinit(alloc);
+        self.records.clearRetainingCapacity();
+    }
+
+    pub fn deinit(self: *MutationObserver, alloc: std.mem.Allocator) void {
+        // the listeners are unregistered before freeing the observers they
+        // point to.
+        self.clearObservers(alloc) catch |e| log.err("mutation observer deinit: {any}", .{e});
+        self.observers.deinit(alloc);
+        self.clearRecords(alloc);
+        self.records.deinit(alloc);
     }
 };
 
-// Handle multiple record?
 pub const MutationRecords = struct {
-    first: ?MutationRecord = null,
+    records: []MutationRecord = &.{},
 
     pub const mem_guarantied = true;
 
     pub fn get_length(self: *MutationRecords) u32 {
-        if (self.first == null) return 0;
+        return @intCast(self.records.len);
+    }
 
-        return 1;
+    pub fn _item(self: *MutationRecords, index: u32) ?MutationRecord {
+        if (index >= self.records.len) return null;
+        return self.records[index];
     }
 
     pub fn postAttach(self: *MutationRecords, js_obj: jsruntime.JSObject) !void {
-        if (self.first) |mr| {
-            try js_obj.set("0", mr);
+        var buf: [16]u8 = undefined;
+        for (self.records, 0..) |mr, i| {
+            const k = try std.fmt.bufPrint(&buf, "{d}", .{i});
+            try js_obj.set(k, mr);
         }
     }
 };
@@ -212,7 +255,7 @@ pub const MutationRecord = struct {
     }
 
     pub fn get_removedNodes(self: MutationRecord) NodeList {
-        return self.addedNodes;
+        return self.removedNodes;
     }
 
     pub fn get_target(self: MutationRecord) *parser.Node {
@@ -238,121 +281,106 @@ pub const MutationRecord = struct {
     pub fn get_oldValue(self: MutationRecord) ?[]const u8 {
         return self.oldValue;
     }
+
+    fn deinit(self: *MutationRecord, alloc: std.mem.Allocator) void {
+        self.addedNodes.deinit(alloc);
+        self.removedNodes.deinit(alloc);
+    }
 };
 
 // EventHandler dedicated to mutation events.
 const EventHandler = struct {
+    // apply returns true if the mutation of the target must be recorded.
+    // The listener receives the mutations of the node and, as mutation events
+    // bubble, the mutations of its descendants.
     fn apply(o: *MutationObserver.Observer, target: *parser.Node) bool {
-        // mutation on any target is always ok.
-        if (o.options.subtree) return true;
-        // if target equals node, alway ok.
         if (target == o.node) return true;
-
-        // no subtree, no same target and no childlist, always noky.
-        if (!o.options.childList) return false;
-
-        // target must be a child of o.node
-        const walker = Walker{};
-        var next: ?*parser.Node = null;
-        while (true) {
-            next = walker.get_next(o.node, next) catch break orelse break;
-            if (next.? == target) return true;
-        }
-
-        return false;
+        return o.options.subtree;
     }
 
     fn handle(evt: ?*parser.Event, data: parser.EventHandlerData) void {
         if (evt == null) return;
 
-        var mrs: MutationRecords = .{};
+        // retrieve the observer from the data.
+        const o: *MutationObserver.Observer = @ptrCast(@alignCast(data.data));
 
-        const t = parser.eventType(evt.?) catch |e| {
-            log.err("mutation observer event type: {any}", .{e});
-            return;
-        };
-        const et = parser.eventTarget(evt.?) catch |e| {
-            log.err("mutation observer event target: {any}", .{e});
+        // TODO get the allocator by another way?
+        const alloc = data.cbk.nat_ctx.alloc;
+
+        const mr = record(o, evt.?, alloc) catch |e| {
+            log.err("mutation event handler error: {any}", .{e});
             return;
         } orelse return;
-        const node = parser.eventTargetToNode(et);
-
-        // retrieve the observer from the data.
-        const o: *MutationObserver.Observer = @ptrCast(@alignCast(data.data));
 
-        if (!apply(o, node)) return;
+        o.mo.queue(alloc, mr) catch |e| {
+            log.err("mutation event handler error: {any}", .{e});
+            return;
+        };
+    }
 
-        const muevt = parser.eventToMutationEvent(evt.?);
+    // record returns the mutation record of the event, or null if the
+    // mutation is not observed.
+    fn record(o: *MutationObserver.Observer, evt: *parser.Event, alloc: std.mem.Allocator) !?MutationRecord {
+        const t = try parser.eventType(evt);
+        const et = try parser.eventTarget(evt) orelse return null;
+        const node = parser.eventTargetToNode(et);
 
-        // TODO get the allocator by another way?
-        const alloc = data.cbk.nat_ctx.alloc;
+        const muevt = parser.eventToMutationEvent(evt);
 
         if (std.mem.eql(u8, t, "DOMAttrModified")) {
-            mrs.first = .{
+            if (!apply(o, node)) return null;
+
+            var mr = MutationRecord{
                 .type = "attributes",
-                .target = o.node,
+                .target = node,
                 .attributeName = parser.mutationEventAttributeName(muevt) catch null,
             };
 
             // record old value if required.
             if (o.options.attributeOldValue) {
-                mrs.first.?.oldValue = parser.mutationEventPrevValue(muevt) catch null;
+                mr.oldValue = parser.mutationEventPrevValue(muevt) catch null;
             }
-        } else if (std.mem.eql(u8, t, "DOMCharacterDataModified")) {
-            mrs.first = .{
+            return mr;
+        }
+
+        if (std.mem.eql(u8, t, "DOMCharacterDataModified")) {
+            if (!apply(o, node)) return null;
+
+            var mr = MutationRecord{
                 .type = "characterData",
-                .target = o.node,
+                .target = node,
             };
 
             // record old value if required.
             if (o.options.characterDataOldValue) {
-                mrs.first.?.oldValue = parser.mutationEventPrevValue(muevt) catch null;
-            }
-        } else if (std.mem.eql(u8, t, "DOMNodeInserted")) {
-            mrs.first = .{
-                .type = "childList",
-                .target = o.node,
-                .addedNodes = NodeList.init(),
-                .removedNodes = NodeList.init(),
-            };
-
-            const rn = parser.mutationEventRelatedNode(muevt) catch null;
-            if (rn) |n| {
-                mrs.first.?.addedNodes.append(alloc, n) catch |e| {
-                    log.err("mutation event handler error: {any}", .{e});
-                    return;
-                };
+                mr.oldValue = parser.mutationEventPrevValue(muevt) catch null;
             }
-        } else if (std.mem.eql(u8, t, "DOMNodeRemoved")) {
-            mrs.first = .{
-                .type = "childList",
-                .target = o.node,
-                .addedNodes = NodeList.init(),
-                .removedNodes = NodeList.init(),
-            };
-
-            const rn = parser.mutationEventRelatedNode(muevt) catch null;
-            if (rn) |n| {
-                mrs.first.?.removedNodes.append(alloc, n) catch |e| {
-                    log.err("mutation event handler error: {any}", .{e});
-                    return;
-                };
-            }
-        } else {
-            return;
+            return mr;
         }
 
-        var res = CallbackResult.init(alloc);
-        defer res.deinit();
+        const inserted = std.mem.eql(u8, t, "DOMNodeInserted");
+        if (!inserted and !std.mem.eql(u8, t, "DOMNodeRemoved")) return null;
 
-        // TODO pass MutationRecords and MutationObserver
-        data.cbk.trycall(.{mrs}, &res) catch |e| log.err("mutation event handler error: {any}", .{e});
+        // The event's target is the inserted or removed node and the related
+        // node is its parent, the record's target.
+        const parent = try parser.mutationEventRelatedNode(muevt) orelse return null;
+        if (node == o.node) return null;
+        if (parent != o.node and !o.options.subtree) return null;
 
-        // in case of function error, we log the result and the trace.
-        if (!res.success) {
-            log.info("mutation observer event handler error: {s}", .{res.result orelse "unknown"});
-            log.debug("{s}", .{res.stack orelse "no stack trace"});
+        var mr = MutationRecord{
+            .type = "childList",
+            .target = parent,
+            .previousSibling = try parser.nodePreviousSibling(node),
+            .nextSibling = try parser.nodeNextSibling(node),
+        };
+        errdefer mr.deinit(alloc);
+
+        if (inserted) {
+            try mr.addedNodes.append(alloc, node);
+        } else {
+            try mr.removedNodes.append(alloc, node);
         }
+        return mr;
     }
 }.handle;
 
@@ -377,12 +405,18 @@ pub fn testExecFn(
         \\// ignored b/c it's about another target.
         \\document.firstElementChild.firstChild.setAttribute("foo", "bar");
         \\nb;
-        , .ex = "1" },
+        , .ex = "0" },
+        // the records are delivered on a microtask.
+        .{ .src = "nb", .ex = "1" },
+        .{ .src = "Array.isArray(mrs)", .ex = "true" },
+        .{ .src = "mrs.length", .ex = "1" },
         .{ .src = "mrs[0].type", .ex = "attributes" },
         .{ .src = "mrs[0].target == document.firstElementChild", .ex = "true" },
         .{ .src = "mrs[0].target.getAttribute('foo')", .ex = "bar" },
         .{ .src = "mrs[0].attributeName", .ex = "foo" },
         .{ .src = "mrs[0].oldValue", .ex = "null" },
+        .{ .src = "document.firstElementChild.setAttribute('foo', 'baz')", .ex = "undefined" },
+        .{ .src = "mrs[0].oldValue", .ex = "bar" },
     };
     try checkCases(js_env, &attr);
 
@@ -397,11 +431,76 @@ pub fn testExecFn(
         \\}).observe(node, { characterData: true, characterDataOldValue: true });
         \\node.data = "foo";
         \\nb2;
-        , .ex = "1" },
+        , .ex = "0" },
+        .{ .src = "nb2", .ex = "1" },
         .{ .src = "mrs2[0].type", .ex = "characterData" },
         .{ .src = "mrs2[0].target == node", .ex = "true" },
         .{ .src = "mrs2[0].target.data", .ex = "foo" },
         .{ .src = "mrs2[0].oldValue", .ex = " And" },
     };
     try checkCases(js_env, &cdata);
+
+    var childlist = [_]Case{
+        .{ .src = 
+        \\var content = document.getElementById("content");
+        \\var nb3 = 0;
+        \\var mrs3;
+        \\var mo3 = new MutationObserver((mu, obs) => {
+        \\    mrs3 = mu;
+        \\    nb3++;
+        \\});
+        \\mo3.observe(content, { childList: true });
+        \\var d1 = document.createElement("div");
+        \\var d2 = document.createElement("div");
+        \\content.appendChild(d1);
+        \\content.appendChild(d2);
+        \\content.removeChild(d1);
+        \\// ignored b/c it's not a child of content.
+        \\d2.appendChild(document.createElement("span"));
+        \\nb3;
+        , .ex = "0" },
+        // the mutations are batched into one call.
+        .{ .src = "nb3", .ex = "1" },
+        .{ .src = "mrs3.length", .ex = "3" },
+        .{ .src = "mrs3[0].type", .ex = "childList" },
+        .{ .src = "mrs3[0].target === content", .ex = "true" },
+        .{ .src = "mrs3[0].addedNodes.length", .ex = "1" },
+        .{ .src = "mrs3[0].addedNodes.item(0) === d1", .ex = "true" },
+        .{ .src = "mrs3[0].removedNodes.length", .ex = "0" },
+        .{ .src = "mrs3[1].addedNodes.item(0) === d2", .ex = "true" },
+        .{ .src = "mrs3[1].previousSibling === d1", .ex = "true" },
+        .{ .src = "mrs3[1].nextSibling", .ex = "null" },
+        .{ .src = "mrs3[2].addedNodes.length", .ex = "0" },
+        .{ .src = "mrs3[2].removedNodes.item(0) === d1", .ex = "true" },
+
+        // takeRecords drains the pending records.
+        .{ .src = "content.appendChild(d1); mo3.takeRecords().length", .ex = "1" },
+        .{ .src = "mo3.takeRecords().length", .ex = "0" },
+        .{ .src = "nb3", .ex = "1" },
+
+        // disconnect stops the delivery.
+        .{ .src = "content.removeChild(d1); mo3.disconnect(); mo3.takeRecords().length", .ex = "0" },
+        .{ .src = "content.appendChild(d1); nb3", .ex = "1" },
+        .{ .src = "nb3", .ex = "1" },
+
+        // subtree
+        .{ .src = "mo3.observe(content, { childList: true, subtree: true, attributes: true })", .ex = "undefined" },
+        .{ .src = "d2.appendChild(document.createElement('span')); d1.setAttribute('foo', 'bar')", .ex = "undefined" },
+        .{ .src = "nb3", .ex = "2" },
+        .{ .src = "mrs3.length", .ex = "2" },
+        .{ .src = "mrs3[0].target === d2", .ex = "true" },
+        .{ .src = "mrs3[0].addedNodes.item(0).localName", .ex = "span" },
+        .{ .src = "mrs3[1].type", .ex = "attributes" },
+        .{ .src = "mrs3[1].target === d1", .ex = "true" },
+
+        // a new observe call replaces the node's listeners.
+        .{ .src = "mo3.observe(content, { attributes: true })", .ex = "undefined" },
+        .{ .src = "content.appendChild(document.createElement('p')); d1.setAttribute('foo', 'baz'); content.setAttribute('foo', 'bar')", .ex = "undefined" },
+        .{ .src = "nb3", .ex = "3" },
+        .{ .src = "mrs3.length", .ex = "1" },
+        .{ .src = "mrs3[0].target === content", .ex = "true" },
+        .{ .src = "mo3.disconnect(); content.removeAttribute('foo'); nb3", .ex = "3" },
+        .{ .src = "nb3", .ex = "3" },
+    };
+    try checkCases(js_env, &childlist);
 }
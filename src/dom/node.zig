@@ -40,6 +40,7 @@ const DocumentType = @import("document_type.zig").DocumentType;
 const DocumentFragment = @import("document_fragment.zig").DocumentFragment;
 const HTMLCollection = @import("html_collection.zig").HTMLCollection;
 const HTMLCollectionIterator = @import("html_collection.zig").HTMLCollectionIterator;
+const Walker = @import("walker.zig").WalkerDepthFirst;
 
 // HTML
 const HTML = @import("../html/html.zig");
@@ -174,24 +175,66 @@ pub const Node = struct {
         try parser.nodeSetValue(self, data);
     }
 
-    pub fn get_textContent(self: *parser.Node) !?[]const u8 {
-        return try parser.nodeTextContent(self);
+    // https://dom.spec.whatwg.org/#dom-node-textcontent
+    // the caller must free the returned string.
+    pub fn get_textContent(self: *parser.Node, alloc: std.mem.Allocator) !?[]const u8 {
+        switch (try parser.nodeType(self)) {
+            .element, .document_fragment => {},
+            .document, .document_type, .notation => return null,
+            else => return try alloc.dupe(u8, try parser.nodeValue(self) orelse ""),
+        }
+
+        // The descendant text nodes' data in tree order: comments and
+        // processing instructions are ignored.
+        var buf = std.ArrayList(u8).init(alloc);
+        defer buf.deinit();
+
+        const walker = Walker{};
+        var next: ?*parser.Node = null;
+        while (true) {
+            next = try walker.get_next(self, next) orelse break;
+            switch (try parser.nodeType(next.?)) {
+                .text, .cdata_section => try buf.appendSlice(try parser.nodeValue(next.?) orelse ""),
+                else => {},
+            }
+        }
+        return try buf.toOwnedSlice();
     }
 
-    pub fn set_textContent(self: *parser.Node, data: []u8) !void {
-        return try parser.nodeSetTextContent(self, data);
+    // https://dom.spec.whatwg.org/#string-replace-all
+    pub fn set_textContent(self: *parser.Node, data: ?[]const u8) !void {
+        const v = data orelse "";
+        switch (try parser.nodeType(self)) {
+            .element, .document_fragment => {},
+            .document, .document_type, .notation => return,
+            else => return try parser.nodeSetValue(self, v),
+        }
+
+        while (try parser.nodeFirstChild(self)) |child| {
+            _ = try parser.nodeRemoveChild(self, child);
+        }
+
+        // an empty string doesn't add any text node.
+        if (v.len == 0) return;
+
+        const doc = try parser.nodeOwnerDocument(self) orelse return;
+        const txt = try parser.documentCreateTextNode(doc, v);
+        _ = try parser.nodeAppendChild(self, @as(*parser.Node, @ptrCast(txt)));
     }
 
     // Methods
 
     pub fn _appendChild(self: *parser.Node, child: *parser.Node) !Union {
         // TODO: DocumentFragment special case
-        const res = try parser.nodeAppendChild(self, child);
+        const res = try parser.nodeAppendChild(self, try adopt(self, child));
         return try Node.toInterface(res);
     }
 
     pub fn _cloneNode(self: *parser.Node, deep: ?bool) !Union {
-        const clone = try parser.nodeCloneNode(self, deep orelse false);
+        const is_deep = deep orelse false;
+        const clone = try parser.nodeCloneNode(self, is_deep);
+        // libdom doesn't know about the templates contents.
+        if (is_deep) try parser.templatesCloneContent(self, clone);
         return try Node.toInterface(clone);
     }
 
@@ -228,7 +271,7 @@ pub const Node = struct {
     }
 
     pub fn _insertBefore(self: *parser.Node, new_node: *parser.Node, ref_node: *parser.Node) !*parser.Node {
-        return try parser.nodeInsertBefore(self, new_node, ref_node);
+        return try parser.nodeInsertBefore(self, try adopt(self, new_node), ref_node);
     }
 
     pub fn _isDefaultNamespace(self: *parser.Node, namespace: []const u8) !bool {
@@ -273,10 +316,21 @@ pub const Node = struct {
     }
 
     pub fn _replaceChild(self: *parser.Node, new_child: *parser.Node, old_child: *parser.Node) !Union {
-        const res = try parser.nodeReplaceChild(self, new_child, old_child);
+        const res = try parser.nodeReplaceChild(self, try adopt(self, new_child), old_child);
         return try Node.toInterface(res);
     }
 
+    // adopt moves the node into the self's document when it's owned by
+    // another one, like the nodes from a template's contents.
+    // libdom refuses to insert nodes from another document.
+    // https://dom.spec.whatwg.org/#concept-node-adopt
+    fn adopt(self: *parser.Node, node: *parser.Node) !*parser.Node {
+        const doc = try parser.nodeOwnerDocument(self) orelse @as(*parser.Document, @ptrCast(self));
+        const owner = try parser.nodeOwnerDocument(node) orelse return node;
+        if (owner == doc) return node;
+        return try parser.documentAdoptNode(doc, node);
+    }
+
     // Check if the hierarchy node tree constraints are respected.
     // For now, it checks only if new nodes are not self.
     // TODO implements the others contraints.
@@ -290,61 +344,144 @@ pub const Node = struct {
         return true;
     }
 
-    // TODO according with https://dom.spec.whatwg.org/#parentnode, the
-    // function must accept either node or string.
+    // The ParentNode's functions accept either nodes or strings, but the
+    // native bindings can't convert an argument into one or the other, so the
+    // strings are converted into text nodes by the parent_node polyfill.
     // blocked by https://github.com/lightpanda-io/jsruntime-lib/issues/114
-    pub fn prepend(self: *parser.Node, nodes: ?Variadic(*parser.Node)) !void {
-        if (nodes == null) return;
-        if (nodes.?.slice.len == 0) return;
+    // https://dom.spec.whatwg.org/#parentnode
 
+    // https://dom.spec.whatwg.org/#dom-parentnode-prepend
+    pub fn prepend(self: *parser.Node, nodes: ?Variadic(*parser.Node)) !void {
         // check hierarchy
         if (!try hierarchy(self, nodes)) return parser.DOMError.HierarchyRequest;
 
-        const first = try parser.nodeFirstChild(self);
-        if (first == null) {
-            for (nodes.?.slice) |node| {
-                _ = try parser.nodeAppendChild(self, node);
-            }
+        const node = try convert(self, nodes) orelse return;
+        if (try parser.nodeFirstChild(self)) |first| {
+            _ = try parser.nodeInsertBefore(self, node, first);
             return;
         }
-
-        for (nodes.?.slice) |node| {
-            _ = try parser.nodeInsertBefore(self, node, first.?);
-        }
+        _ = try parser.nodeAppendChild(self, node);
     }
 
-    // TODO according with https://dom.spec.whatwg.org/#parentnode, the
-    // function must accept either node or string.
-    // blocked by https://github.com/lightpanda-io/jsruntime-lib/issues/114
+    // https://dom.spec.whatwg.org/#dom-parentnode-append
     pub fn append(self: *parser.Node, nodes: ?Variadic(*parser.Node)) !void {
-        if (nodes == null) return;
-        if (nodes.?.slice.len == 0) return;
-
         // check hierarchy
         if (!try hierarchy(self, nodes)) return parser.DOMError.HierarchyRequest;
 
-        for (nodes.?.slice) |node| {
-            _ = try parser.nodeAppendChild(self, node);
-        }
+        const node = try convert(self, nodes) orelse return;
+        _ = try parser.nodeAppendChild(self, node);
     }
 
-    // TODO according with https://dom.spec.whatwg.org/#parentnode, the
-    // function must accept either node or string.
-    // blocked by https://github.com/lightpanda-io/jsruntime-lib/issues/114
+    // https://dom.spec.whatwg.org/#dom-parentnode-replacechildren
     pub fn replaceChildren(self: *parser.Node, nodes: ?Variadic(*parser.Node)) !void {
-        if (nodes == null) return;
-        if (nodes.?.slice.len == 0) return;
-
         // check hierarchy
         if (!try hierarchy(self, nodes)) return parser.DOMError.HierarchyRequest;
 
+        const node = try convert(self, nodes);
+
         // remove existing children
         try removeChildren(self);
 
         // add new children
-        for (nodes.?.slice) |node| {
-            _ = try parser.nodeAppendChild(self, node);
+        if (node) |n| _ = try parser.nodeAppendChild(self, n);
+    }
+
+    // convert returns the node to insert into self: the node itself if there
+    // is only one, a document fragment containing all the nodes otherwise.
+    // So the nodes are inserted at once.
+    // The returned node is adopted by the self's document.
+    // https://dom.spec.whatwg.org/#converting-nodes-into-a-node
+    pub fn convert(self: *parser.Node, nodes: ?Variadic(*parser.Node)) !?*parser.Node {
+        if (nodes == null) return null;
+        const slice = nodes.?.slice;
+        if (slice.len == 0) return null;
+
+        if (slice.len == 1) return try adopt(self, slice[0]);
+
+        const doc = try parser.nodeOwnerDocument(self) orelse @as(*parser.Document, @ptrCast(self));
+        const fragment = parser.documentFragmentToNode(try parser.documentCreateDocumentFragment(doc));
+        for (slice) |node| {
+            _ = try parser.nodeAppendChild(fragment, try adopt(fragment, node));
         }
+        return fragment;
+    }
+
+    // The ChildNode's functions accept either nodes or strings too, the
+    // strings are converted by the child_node polyfill.
+    // https://dom.spec.whatwg.org/#childnode
+
+    // https://dom.spec.whatwg.org/#dom-childnode-before
+    pub fn before(self: *parser.Node, nodes: ?Variadic(*parser.Node)) !void {
+        const parent = try parser.nodeParentNode(self) orelse return;
+
+        // the nodes can be siblings of self, so the reference is computed
+        // before converting them.
+        var prev = try parser.nodePreviousSibling(self);
+        while (prev) |p| : (prev = try parser.nodePreviousSibling(p)) {
+            if (!contains(nodes, p)) break;
+        }
+
+        const node = try convert(parent, nodes) orelse return;
+        const ref = if (prev) |p| try parser.nodeNextSibling(p) else try parser.nodeFirstChild(parent);
+        try insertBefore(parent, node, ref);
+    }
+
+    // https://dom.spec.whatwg.org/#dom-childnode-after
+    pub fn after(self: *parser.Node, nodes: ?Variadic(*parser.Node)) !void {
+        const parent = try parser.nodeParentNode(self) orelse return;
+        const next = try viableNextSibling(self, nodes);
+
+        const node = try convert(parent, nodes) orelse return;
+        try insertBefore(parent, node, next);
+    }
+
+    // https://dom.spec.whatwg.org/#dom-childnode-replacewith
+    pub fn replaceWith(self: *parser.Node, nodes: ?Variadic(*parser.Node)) !void {
+        const parent = try parser.nodeParentNode(self) orelse return;
+        const next = try viableNextSibling(self, nodes);
+
+        const node = try convert(parent, nodes) orelse {
+            _ = try parser.nodeRemoveChild(parent, self);
+            return;
+        };
+
+        // self can be one of the nodes and have been moved by the conversion.
+        if (try parser.nodeParentNode(self) == parent) {
+            _ = try parser.nodeReplaceChild(parent, node, self);
+            return;
+        }
+        try insertBefore(parent, node, next);
+    }
+
+    // https://dom.spec.whatwg.org/#dom-childnode-remove
+    pub fn remove(self: *parser.Node) !void {
+        const parent = try parser.nodeParentNode(self) orelse return;
+        _ = try parser.nodeRemoveChild(parent, self);
+    }
+
+    // viableNextSibling returns the first following sibling of self which is
+    // not in nodes.
+    fn viableNextSibling(self: *parser.Node, nodes: ?Variadic(*parser.Node)) !?*parser.Node {
+        var next = try parser.nodeNextSibling(self);
+        while (next) |n| : (next = try parser.nodeNextSibling(n)) {
+            if (!contains(nodes, n)) break;
+        }
+        return next;
+    }
+
+    fn contains(nodes: ?Variadic(*parser.Node), node: *parser.Node) bool {
+        if (nodes == null) return false;
+        for (nodes.?.slice) |n| if (n == node) return true;
+        return false;
+    }
+
+    // insertBefore inserts the node before ref, or at the end if ref is null.
+    fn insertBefore(parent: *parser.Node, node: *parser.Node, ref: ?*parser.Node) !void {
+        if (ref) |r| {
+            _ = try parser.nodeInsertBefore(parent, node, r);
+            return;
+        }
+        _ = try parser.nodeAppendChild(parent, node);
     }
 
     pub fn removeChildren(self: *parser.Node) !void {
@@ -480,6 +617,25 @@ pub fn testExecFn(
         .{ .src = "trimAndReplace(document.getElementById('para-empty').textContent)", .ex = "" },
         .{ .src = "document.getElementById('para-empty').textContent = 'OK'", .ex = "OK" },
         .{ .src = "document.getElementById('para-empty').firstChild.nodeName === '#text'", .ex = "true" },
+        .{ .src = "document.getElementById('para-empty').childNodes.length", .ex = "1" },
+
+        // comments are ignored.
+        .{ .src = "let tc = document.createElement('div')", .ex = "undefined" },
+        .{ .src = "tc.innerHTML = 'a<!--b--><p>c<span>d</span></p>e'", .ex = "a<!--b--><p>c<span>d</span></p>e" },
+        .{ .src = "tc.textContent", .ex = "acde" },
+        .{ .src = "tc.textContent = 'foo'", .ex = "foo" },
+        .{ .src = "tc.childNodes.length", .ex = "1" },
+        .{ .src = "tc.innerHTML", .ex = "foo" },
+        .{ .src = "tc.textContent = ''", .ex = "" },
+        .{ .src = "tc.childNodes.length", .ex = "0" },
+        .{ .src = "tc.textContent", .ex = "" },
+        .{ .src = "tc.textContent = null", .ex = "null" },
+        .{ .src = "tc.childNodes.length", .ex = "0" },
+
+        .{ .src = "let tcc = document.createComment('foo')", .ex = "undefined" },
+        .{ .src = "tcc.textContent = 'bar'", .ex = "bar" },
+        .{ .src = "tcc.data", .ex = "bar" },
+        .{ .src = "document.textContent", .ex = "null" },
     };
     try checkCases(js_env, &node_text_content);
 
@@ -498,6 +654,23 @@ pub fn testExecFn(
         .{ .src = "clone.firstChild === null", .ex = "true" },
         .{ .src = "let clone_deep = link.cloneNode(true)", .ex = "undefined" },
         .{ .src = "clone_deep.firstChild.nodeName === '#text'", .ex = "true" },
+
+        // attributes and subtree fidelity.
+        .{ .src = "let clone_para = document.getElementById('para-empty').cloneNode(true)", .ex = "undefined" },
+        .{ .src = "clone_para.parentNode === null", .ex = "true" },
+        .{ .src = "clone_para.id", .ex = "para-empty" },
+        .{ .src = "clone_para.className", .ex = "ok empty" },
+        .{ .src = "clone_para.namespaceURI", .ex = "http://www.w3.org/1999/xhtml" },
+        .{ .src = "clone_para.querySelector('span').id", .ex = "para-empty-child" },
+        .{ .src = "clone_para.querySelector('span') !== document.getElementById('para-empty-child')", .ex = "true" },
+        .{ .src = "clone_para.setAttribute('id', 'foo'); document.getElementById('para-empty').id", .ex = "para-empty" },
+        .{ .src = "document.getElementById('para').firstChild.cloneNode().data", .ex = " And" },
+        .{ .src = "content.lastChild.previousSibling.cloneNode().data", .ex = "comment" },
+
+        // event listeners are not copied.
+        .{ .src = "var nb_clone = 0; link.addEventListener('clone', () => { nb_clone++ })", .ex = "undefined" },
+        .{ .src = "link.cloneNode(true).dispatchEvent(new Event('clone'))", .ex = "true" },
+        .{ .src = "nb_clone", .ex = "0" },
     };
     try checkCases(js_env, &node_clone);
 
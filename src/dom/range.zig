@@ -0,0 +1,644 @@
+// Copyright (C) 2023-2024  Lightpanda (Selecy SAS)
+//
+// Francis Bouvier <francis@lightpanda.io>
+// Pierre Tachoire <pierre@lightpanda.io>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+const std = @import("std");
+
+const jsruntime = @import("jsruntime");
+const Case = jsruntime.test_utils.Case;
+const checkCases = jsruntime.test_utils.checkCases;
+
+const parser = @import("netsurf");
+
+const Node = @import("node.zig").Node;
+const NodeUnion = @import("node.zig").Union;
+const Walker = @import("walker.zig").WalkerDepthFirst;
+const DOMException = @import("exceptions.zig").DOMException;
+
+const UserContext = @import("../user_context.zig").UserContext;
+
+// WEB IDL https://dom.spec.whatwg.org/#range
+//
+// TODO the boundary points are not updated on DOM mutations.
+// https://dom.spec.whatwg.org/#concept-live-range-pre-remove
+pub const Range = struct {
+    pub const mem_guarantied = true;
+    pub const Exception = DOMException;
+
+    start_container: *parser.Node,
+    start_offset: u32 = 0,
+    end_container: *parser.Node,
+    end_offset: u32 = 0,
+
+    const Mode = enum { clone, extract };
+
+    pub fn init(node: *parser.Node) Range {
+        return .{
+            .start_container = node,
+            .end_container = node,
+        };
+    }
+
+    pub fn constructor(userctx: UserContext) Range {
+        const doc = parser.documentHTMLToDocument(userctx.document);
+        return init(parser.documentToNode(doc));
+    }
+
+    pub fn get_startContainer(self: *Range) !NodeUnion {
+        return try Node.toInterface(self.start_container);
+    }
+
+    pub fn get_startOffset(self: *Range) u32 {
+        return self.start_offset;
+    }
+
+    pub fn get_endContainer(self: *Range) !NodeUnion {
+        return try Node.toInterface(self.end_container);
+    }
+
+    pub fn get_endOffset(self: *Range) u32 {
+        return self.end_offset;
+    }
+
+    pub fn get_collapsed(self: *Range) bool {
+        return self.start_container == self.end_container and
+            self.start_offset == self.end_offset;
+    }
+
+    // https://dom.spec.whatwg.org/#dom-range-commonancestorcontainer
+    pub fn get_commonAncestorContainer(self: *Range) !NodeUnion {
+        return try Node.toInterface(try commonAncestor(self.start_container, self.end_container));
+    }
+
+    // https://dom.spec.whatwg.org/#dom-range-setstart
+    pub fn _setStart(self: *Range, node: *parser.Node, offset: u32) !void {
+        try self.setStartPoint(node, offset);
+    }
+
+    // https://dom.spec.whatwg.org/#dom-range-setend
+    pub fn _setEnd(self: *Range, node: *parser.Node, offset: u32) !void {
+        try self.setEndPoint(node, offset);
+    }
+
+    pub fn _setStartBefore(self: *Range, node: *parser.Node) !void {
+        const parent = try parser.nodeParentNode(node) orelse return parser.DOMError.InvalidNodeType;
+        try self.setStartPoint(parent, try index(node));
+    }
+
+    pub fn _setStartAfter(self: *Range, node: *parser.Node) !void {
+        const parent = try parser.nodeParentNode(node) orelse return parser.DOMError.InvalidNodeType;
+        try self.setStartPoint(parent, try index(node) + 1);
+    }
+
+    pub fn _setEndBefore(self: *Range, node: *parser.Node) !void {
+        const parent = try parser.nodeParentNode(node) orelse return parser.DOMError.InvalidNodeType;
+        try self.setEndPoint(parent, try index(node));
+    }
+
+    pub fn _setEndAfter(self: *Range, node: *parser.Node) !void {
+        const parent = try parser.nodeParentNode(node) orelse return parser.DOMError.InvalidNodeType;
+        try self.setEndPoint(parent, try index(node) + 1);
+    }
+
+    // https://dom.spec.whatwg.org/#dom-range-collapse
+    pub fn _collapse(self: *Range, to_start: ?bool) void {
+        if (to_start orelse false) {
+            self.end_container = self.start_container;
+            self.end_offset = self.start_offset;
+        } else {
+            self.start_container = self.end_container;
+            self.start_offset = self.end_offset;
+        }
+    }
+
+    // https://dom.spec.whatwg.org/#concept-range-select
+    pub fn _selectNode(self: *Range, node: *parser.Node) !void {
+        const parent = try parser.nodeParentNode(node) orelse return parser.DOMError.InvalidNodeType;
+        const i = try index(node);
+
+        self.start_container = parent;
+        self.start_offset = i;
+        self.end_container = parent;
+        self.end_offset = i + 1;
+    }
+
+    // https://dom.spec.whatwg.org/#dom-range-selectnodecontents
+    pub fn _selectNodeContents(self: *Range, node: *parser.Node) !void {
+        if (try parser.nodeType(node) == .document_type) return parser.DOMError.InvalidNodeType;
+
+        self.start_container = node;
+        self.start_offset = 0;
+        self.end_container = node;
+        self.end_offset = try length(node);
+    }
+
+    // https://dom.spec.whatwg.org/#dom-range-clonecontents
+    pub fn _cloneContents(self: *Range, alloc: std.mem.Allocator) !*parser.DocumentFragment {
+        return try self.contents(alloc, .clone);
+    }
+
+    // https://dom.spec.whatwg.org/#dom-range-extractcontents
+    pub fn _extractContents(self: *Range, alloc: std.mem.Allocator) !*parser.DocumentFragment {
+        return try self.contents(alloc, .extract);
+    }
+
+    // https://dom.spec.whatwg.org/#dom-range-deletecontents
+    pub fn _deleteContents(self: *Range, alloc: std.mem.Allocator) !void {
+        if (self.get_collapsed()) return;
+
+        const start = self.start_container;
+        const end = self.end_container;
+
+        if (start == end and try isCharacterData(start)) {
+            try parser.characterDataDeleteData(
+                toCharacterData(start),
+                self.start_offset,
+                self.end_offset - self.start_offset,
+            );
+            return;
+        }
+
+        // Collect the contained nodes whose parent is not contained before
+        // removing them: the removals change the children's indexes.
+        var nodes = std.ArrayList(*parser.Node).init(alloc);
+        defer nodes.deinit();
+
+        const common = try commonAncestor(start, end);
+        const walker = Walker{};
+        var next: ?*parser.Node = null;
+        while (true) {
+            next = try walker.get_next(common, next) orelse break;
+            if (!try self.isContained(next.?)) continue;
+            if (try self.isContained((try parser.nodeParentNode(next.?)).?)) continue;
+            try nodes.append(next.?);
+        }
+
+        const new_node, const new_offset = try newBoundary(start, self.start_offset, end);
+
+        if (try isCharacterData(start)) {
+            const cdata = toCharacterData(start);
+            const len = try parser.characterDataLength(cdata);
+            try parser.characterDataDeleteData(cdata, self.start_offset, len - self.start_offset);
+        }
+
+        for (nodes.items) |n| {
+            const parent = try parser.nodeParentNode(n) orelse continue;
+            _ = try parser.nodeRemoveChild(parent, n);
+        }
+
+        if (try isCharacterData(end)) {
+            try parser.characterDataDeleteData(toCharacterData(end), 0, self.end_offset);
+        }
+
+        self.start_container = new_node;
+        self.start_offset = new_offset;
+        self.end_container = new_node;
+        self.end_offset = new_offset;
+    }
+
+    // https://dom.spec.whatwg.org/#dom-range-stringifier
+    pub fn _toString(self: *Range, alloc: std.mem.Allocator) ![]const u8 {
+        var buf = std.ArrayList(u8).init(alloc);
+        defer buf.deinit();
+
+        const start = self.start_container;
+        const end = self.end_container;
+
+        if (start == end and try parser.nodeType(start) == .text) {
+            const data = try parser.characterDataSubstringData(
+                toCharacterData(start),
+                self.start_offset,
+                self.end_offset - self.start_offset,
+            );
+            try buf.appendSlice(data);
+            return buf.toOwnedSlice();
+        }
+
+        if (try parser.nodeType(start) == .text) {
+            const cdata = toCharacterData(start);
+            const len = try parser.characterDataLength(cdata);
+            try buf.appendSlice(try parser.characterDataSubstringData(cdata, self.start_offset, len - self.start_offset));
+        }
+
+        const common = try commonAncestor(start, end);
+        const walker = Walker{};
+        var next: ?*parser.Node = null;
+        while (true) {
+            next = try walker.get_next(common, next) orelse break;
+            if (try parser.nodeType(next.?) != .text) continue;
+            if (!try self.isContained(next.?)) continue;
+            try buf.appendSlice(try parser.nodeValue(next.?) orelse "");
+        }
+
+        if (try parser.nodeType(end) == .text) {
+            try buf.appendSlice(try parser.characterDataSubstringData(toCharacterData(end), 0, self.end_offset));
+        }
+
+        return buf.toOwnedSlice();
+    }
+
+    // contents implements both the clone and the extract algorithms.
+    // https://dom.spec.whatwg.org/#concept-range-clone
+    // https://dom.spec.whatwg.org/#concept-range-extract
+    fn contents(self: *Range, alloc: std.mem.Allocator, comptime mode: Mode) anyerror!*parser.DocumentFragment {
+        const start = self.start_container;
+        const start_offset = self.start_offset;
+        const end = self.end_container;
+        const end_offset = self.end_offset;
+
+        const fragment = try parser.documentCreateDocumentFragment(try ownerDocument(start));
+        const fragment_node = parser.documentFragmentToNode(fragment);
+
+        if (self.get_collapsed()) return fragment;
+
+        if (start == end and try isCharacterData(start)) {
+            _ = try parser.nodeAppendChild(
+                fragment_node,
+                try cloneData(start, start_offset, end_offset - start_offset),
+            );
+            if (mode == .extract) {
+                try parser.characterDataDeleteData(toCharacterData(start), start_offset, end_offset - start_offset);
+            }
+            return fragment;
+        }
+
+        const common = try commonAncestor(start, end);
+
+        const first_partial = if (try isInclusiveAncestor(start, end)) null else try childOf(common, start);
+        const last_partial = if (try isInclusiveAncestor(end, start)) null else try childOf(common, end);
+
+        // Collect the contained children before moving them.
+        var contained = std.ArrayList(*parser.Node).init(alloc);
+        defer contained.deinit();
+
+        var child = try parser.nodeFirstChild(common);
+        while (child) |c| : (child = try parser.nodeNextSibling(c)) {
+            if (!try self.isContained(c)) continue;
+            if (try parser.nodeType(c) == .document_type) return parser.DOMError.HierarchyRequest;
+            try contained.append(c);
+        }
+
+        const new_node, const new_offset = try newBoundary(start, start_offset, end);
+
+        if (first_partial) |fp| {
+            if (try isCharacterData(fp)) {
+                const len = try length(start);
+                _ = try parser.nodeAppendChild(
+                    fragment_node,
+                    try cloneData(start, start_offset, len - start_offset),
+                );
+                if (mode == .extract) {
+                    try parser.characterDataDeleteData(toCharacterData(start), start_offset, len - start_offset);
+                }
+            } else {
+                const clone = try parser.nodeCloneNode(fp, false);
+                _ = try parser.nodeAppendChild(fragment_node, clone);
+
+                var subrange = Range{
+                    .start_container = start,
+                    .start_offset = start_offset,
+                    .end_container = fp,
+                    .end_offset = try length(fp),
+                };
+                const subfragment = try subrange.contents(alloc, mode);
+                _ = try parser.nodeAppendChild(clone, parser.documentFragmentToNode(subfragment));
+            }
+        }
+
+        for (contained.items) |c| {
+            const n = switch (mode) {
+                .clone => try parser.nodeCloneNode(c, true),
+                .extract => c,
+            };
+            _ = try parser.nodeAppendChild(fragment_node, n);
+        }
+
+        if (last_partial) |lp| {
+            if (try isCharacterData(lp)) {
+                _ = try parser.nodeAppendChild(fragment_node, try cloneData(end, 0, end_offset));
+                if (mode == .extract) {
+                    try parser.characterDataDeleteData(toCharacterData(end), 0, end_offset);
+                }
+            } else {
+                const clone = try parser.nodeCloneNode(lp, false);
+                _ = try parser.nodeAppendChild(fragment_node, clone);
+
+                var subrange = Range{
+                    .start_container = lp,
+                    .start_offset = 0,
+                    .end_container = end,
+                    .end_offset = end_offset,
+                };
+                const subfragment = try subrange.contents(alloc, mode);
+                _ = try parser.nodeAppendChild(clone, parser.documentFragmentToNode(subfragment));
+            }
+        }
+
+        if (mode == .extract) {
+            self.start_container = new_node;
+            self.start_offset = new_offset;
+            self.end_container = new_node;
+            self.end_offset = new_offset;
+        }
+
+        return fragment;
+    }
+
+    // https://dom.spec.whatwg.org/#concept-range-bp-set
+    fn setStartPoint(self: *Range, node: *parser.Node, offset: u32) !void {
+        try checkBoundary(node, offset);
+
+        if (try root(node) != try root(self.end_container) or
+            try compare(node, offset, self.end_container, self.end_offset) == .gt)
+        {
+            self.end_container = node;
+            self.end_offset = offset;
+        }
+
+        self.start_container = node;
+        self.start_offset = offset;
+    }
+
+    fn setEndPoint(self: *Range, node: *parser.Node, offset: u32) !void {
+        try checkBoundary(node, offset);
+
+        if (try root(node) != try root(self.start_container) or
+            try compare(node, offset, self.start_container, self.start_offset) == .lt)
+        {
+            self.start_container = node;
+            self.start_offset = offset;
+        }
+
+        self.end_container = node;
+        self.end_offset = offset;
+    }
+
+    // https://dom.spec.whatwg.org/#contained
+    fn isContained(self: *Range, node: *parser.Node) !bool {
+        if (try root(node) != try root(self.start_container)) return false;
+
+        return try compare(node, 0, self.start_container, self.start_offset) == .gt and
+            try compare(node, try length(node), self.end_container, self.end_offset) == .lt;
+    }
+};
+
+fn checkBoundary(node: *parser.Node, offset: u32) !void {
+    if (try parser.nodeType(node) == .document_type) return parser.DOMError.InvalidNodeType;
+    if (offset > try length(node)) return parser.DOMError.IndexSize;
+}
+
+// newBoundary returns the boundary point of the range once its contents are
+// removed.
+fn newBoundary(start: *parser.Node, start_offset: u32, end: *parser.Node) !struct { *parser.Node, u32 } {
+    if (try isInclusiveAncestor(start, end)) return .{ start, start_offset };
+
+    var ref = start;
+    while (try parser.nodeParentNode(ref)) |parent| {
+        if (try isInclusiveAncestor(parent, end)) return .{ parent, try index(ref) + 1 };
+        ref = parent;
+    }
+    return .{ ref, 0 };
+}
+
+// compare returns the position of the boundary point a relative to the
+// boundary point b. Both nodes must share the same root.
+// https://dom.spec.whatwg.org/#concept-range-bp-position
+pub fn compare(a: *parser.Node, a_offset: u32, b: *parser.Node, b_offset: u32) !std.math.Order {
+    if (a == b) return std.math.order(a_offset, b_offset);
+
+    if (try treeOrder(a, b) == .gt) return (try position(b, b_offset, a, a_offset)).invert();
+    return try position(a, a_offset, b, b_offset);
+}
+
+// position returns the position of the boundary point a relative to b when a
+// precedes b.
+fn position(a: *parser.Node, a_offset: u32, b: *parser.Node, _: u32) !std.math.Order {
+    if (a != b and try isInclusiveAncestor(a, b)) {
+        if (try index(try childOf(a, b)) < a_offset) return .gt;
+    }
+    return .lt;
+}
+
+// treeOrder compares the positions of a and b in the tree order.
+// https://dom.spec.whatwg.org/#concept-tree-order
+fn treeOrder(a: *parser.Node, b: *parser.Node) !std.math.Order {
+    if (a == b) return .eq;
+
+    const da = try depth(a);
+    const db = try depth(b);
+
+    var x = a;
+    var y = b;
+    var i = da;
+    while (i > db) : (i -= 1) x = (try parser.nodeParentNode(x)).?;
+    i = db;
+    while (i > da) : (i -= 1) y = (try parser.nodeParentNode(y)).?;
+
+    // An ancestor precedes its descendants.
+    if (x == y) return if (da < db) .lt else .gt;
+
+    while (true) {
+        const px = try parser.nodeParentNode(x);
+        const py = try parser.nodeParentNode(y);
+        if (px == py) break;
+        x = px.?;
+        y = py.?;
+    }
+
+    // x and y are siblings.
+    var n = try parser.nodeNextSibling(x);
+    while (n) |s| : (n = try parser.nodeNextSibling(s)) {
+        if (s == y) return .lt;
+    }
+    return .gt;
+}
+
+fn depth(node: *parser.Node) !u32 {
+    var d: u32 = 0;
+    var n = node;
+    while (try parser.nodeParentNode(n)) |parent| : (n = parent) d += 1;
+    return d;
+}
+
+pub fn root(node: *parser.Node) !*parser.Node {
+    var n = node;
+    while (try parser.nodeParentNode(n)) |parent| n = parent;
+    return n;
+}
+
+fn isInclusiveAncestor(ancestor: *parser.Node, node: *parser.Node) !bool {
+    var n: ?*parser.Node = node;
+    while (n) |v| : (n = try parser.nodeParentNode(v)) {
+        if (v == ancestor) return true;
+    }
+    return false;
+}
+
+fn commonAncestor(a: *parser.Node, b: *parser.Node) !*parser.Node {
+    var n = a;
+    while (!try isInclusiveAncestor(n, b)) {
+        n = try parser.nodeParentNode(n) orelse break;
+    }
+    return n;
+}
+
+// childOf returns the inclusive ancestor of node which is a child of parent.
+fn childOf(parent: *parser.Node, node: *parser.Node) !*parser.Node {
+    var n = node;
+    while (try parser.nodeParentNode(n)) |p| : (n = p) {
+        if (p == parent) break;
+    }
+    return n;
+}
+
+// https://dom.spec.whatwg.org/#concept-tree-index
+fn index(node: *parser.Node) !u32 {
+    var i: u32 = 0;
+    var n = try parser.nodePreviousSibling(node);
+    while (n) |s| : (n = try parser.nodePreviousSibling(s)) i += 1;
+    return i;
+}
+
+// https://dom.spec.whatwg.org/#concept-node-length
+fn length(node: *parser.Node) !u32 {
+    switch (try parser.nodeType(node)) {
+        .document_type => return 0,
+        .text, .comment, .cdata_section => {
+            return try parser.characterDataLength(toCharacterData(node));
+        },
+        .processing_instruction => {
+            const v = try parser.nodeValue(node) orelse return 0;
+            return @intCast(v.len);
+        },
+        else => return try parser.nodeListLength(try parser.nodeGetChildNodes(node)),
+    }
+}
+
+fn isCharacterData(node: *parser.Node) !bool {
+    return switch (try parser.nodeType(node)) {
+        .text, .comment, .cdata_section => true,
+        else => false,
+    };
+}
+
+inline fn toCharacterData(node: *parser.Node) *parser.CharacterData {
+    return @as(*parser.CharacterData, @ptrCast(node));
+}
+
+// cloneData returns a clone of the character data node containing only
+// count code units from offset.
+fn cloneData(node: *parser.Node, offset: u32, count: u32) !*parser.Node {
+    const data = try parser.characterDataSubstringData(toCharacterData(node), offset, count);
+    const clone = try parser.nodeCloneNode(node, false);
+    try parser.characterDataSetData(toCharacterData(clone), data);
+    return clone;
+}
+
+fn ownerDocument(node: *parser.Node) !*parser.Document {
+    return try parser.nodeOwnerDocument(node) orelse @as(*parser.Document, @ptrCast(node));
+}
+
+// Tests
+// -----
+
+pub fn testExecFn(
+    _: std.mem.Allocator,
+    js_env: *jsruntime.Env,
+) anyerror!void {
+    var range = [_]Case{
+        .{ .src = "let rg = document.createRange()", .ex = "undefined" },
+        .{ .src = "rg.collapsed", .ex = "true" },
+        .{ .src = "rg.startContainer === document", .ex = "true" },
+        .{ .src = "rg.startOffset", .ex = "0" },
+        .{ .src = "new Range().endContainer === document", .ex = "true" },
+
+        .{ .src = "let rgd = document.createElement('div')", .ex = "undefined" },
+        .{ .src = "rgd.innerHTML = '<p>foo<b>bar</b>baz</p><p>qux</p>'", .ex = "<p>foo<b>bar</b>baz</p><p>qux</p>" },
+
+        .{ .src = "rg.selectNodeContents(rgd)", .ex = "undefined" },
+        .{ .src = "rg.startContainer === rgd", .ex = "true" },
+        .{ .src = "rg.endOffset", .ex = "2" },
+        .{ .src = "rg.commonAncestorContainer === rgd", .ex = "true" },
+
+        .{ .src = "rg.selectNode(rgd.lastChild)", .ex = "undefined" },
+        .{ .src = "rg.startOffset", .ex = "1" },
+        .{ .src = "rg.endOffset", .ex = "2" },
+        .{ .src = "rg.collapse(true)", .ex = "undefined" },
+        .{ .src = "rg.collapsed", .ex = "true" },
+        .{ .src = "rg.endOffset", .ex = "1" },
+
+        // setting the start after the end collapses the range.
+        .{ .src = "rg.setStart(rgd, 2)", .ex = "undefined" },
+        .{ .src = "rg.endOffset", .ex = "2" },
+        .{ .src = "rg.setEndBefore(rgd.firstChild)", .ex = "undefined" },
+        .{ .src = "rg.startOffset", .ex = "0" },
+        .{ .src = "rg.setEndAfter(rgd.lastChild)", .ex = "undefined" },
+        .{ .src = "rg.startOffset", .ex = "0" },
+        .{ .src = "rg.endOffset", .ex = "2" },
+
+        .{ .src = "var err; try { rg.setStart(rgd, 3) } catch (e) { err = e } err.name", .ex = "IndexSizeError" },
+        .{ .src = "try { rg.selectNode(document) } catch (e) { err = e } err.name", .ex = "InvalidNodeTypeError" },
+        .{ .src = "try { rg.setStartBefore(document) } catch (e) { err = e } err.name", .ex = "InvalidNodeTypeError" },
+    };
+    try checkCases(js_env, &range);
+
+    var contents = [_]Case{
+        .{ .src = "rg.setStart(rgd.firstChild.firstChild, 1)", .ex = "undefined" },
+        .{ .src = "rg.setEnd(rgd.lastChild.firstChild, 2)", .ex = "undefined" },
+        .{ .src = "rg.commonAncestorContainer === rgd", .ex = "true" },
+
+        .{ .src = "let rgc = document.createElement('div')", .ex = "undefined" },
+        .{ .src = "rgc.appendChild(rg.cloneContents()); rgc.innerHTML", .ex = "<p>oo<b>bar</b>baz</p><p>qu</p>" },
+        .{ .src = "rgd.innerHTML", .ex = "<p>foo<b>bar</b>baz</p><p>qux</p>" },
+
+        .{ .src = "let rge = document.createElement('div')", .ex = "undefined" },
+        .{ .src = "rge.appendChild(rg.extractContents()); rge.innerHTML", .ex = "<p>oo<b>bar</b>baz</p><p>qu</p>" },
+        .{ .src = "rgd.innerHTML", .ex = "<p>f</p><p>x</p>" },
+        .{ .src = "rg.collapsed", .ex = "true" },
+        .{ .src = "rg.startContainer === rgd", .ex = "true" },
+        .{ .src = "rg.startOffset", .ex = "1" },
+
+        .{ .src = "rgd.innerHTML = '<p>foo</p><p>bar</p><p>baz</p>'", .ex = "<p>foo</p><p>bar</p><p>baz</p>" },
+        .{ .src = "rg.setStart(rgd.firstChild.firstChild, 2)", .ex = "undefined" },
+        .{ .src = "rg.setEnd(rgd.lastChild.firstChild, 1)", .ex = "undefined" },
+        .{ .src = "rg.deleteContents()", .ex = "undefined" },
+        .{ .src = "rgd.innerHTML", .ex = "<p>fo</p><p>az</p>" },
+        .{ .src = "rg.startContainer === rgd", .ex = "true" },
+        .{ .src = "rg.startOffset", .ex = "1" },
+
+        // the range is in a single text node.
+        .{ .src = "rgd.innerHTML = '<p>hello</p>'", .ex = "<p>hello</p>" },
+        .{ .src = "let rgt = rgd.firstChild.firstChild", .ex = "undefined" },
+        .{ .src = "rg.setStart(rgt, 1); rg.setEnd(rgt, 4)", .ex = "undefined" },
+        .{ .src = "rg.cloneContents().firstChild.data", .ex = "ell" },
+        .{ .src = "rg.extractContents().firstChild.data", .ex = "ell" },
+        .{ .src = "rgt.data", .ex = "ho" },
+    };
+    try checkCases(js_env, &contents);
+
+    var stringifier = [_]Case{
+        .{ .src = "let rgs = document.createElement('div')", .ex = "undefined" },
+        .{ .src = "rgs.innerHTML = '<p>foo<b>bar</b>baz</p><p>qux</p>'", .ex = "<p>foo<b>bar</b>baz</p><p>qux</p>" },
+        .{ .src = "let rs = new Range()", .ex = "undefined" },
+        .{ .src = "rs.toString()", .ex = "" },
+        .{ .src = "rs.selectNodeContents(rgs); rs.toString()", .ex = "foobarbazqux" },
+        .{ .src = "rs.setStart(rgs.firstChild.firstChild, 1); rs.setEnd(rgs.lastChild.firstChild, 2)", .ex = "undefined" },
+        .{ .src = "rs.toString()", .ex = "oobarbazqu" },
+        .{ .src = "rs.setEnd(rgs.firstChild.firstChild, 2); rs.toString()", .ex = "o" },
+    };
+    try checkCases(js_env, &stringifier);
+}
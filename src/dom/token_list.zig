@@ -150,6 +150,15 @@ pub fn testExecFn(
         .{ .src = "cl3.toggle('ok')", .ex = "false" },
         .{ .src = "cl3.toggle('ok')", .ex = "true" },
         .{ .src = "cl3.length", .ex = "2" },
+        .{ .src = "cl3.toggle('foo', true)", .ex = "true" },
+        .{ .src = "cl3.toggle('foo', true)", .ex = "true" },
+        .{ .src = "gs.className", .ex = "empty ok foo" },
+        .{ .src = "cl3.toggle('foo', false)", .ex = "false" },
+        .{ .src = "cl3.toggle('foo', false)", .ex = "false" },
+        .{ .src = "gs.className", .ex = "empty ok" },
+        .{ .src = "var err; try { cl3.toggle('') } catch (e) { err = e } err.name", .ex = "SyntaxError" },
+        .{ .src = "try { cl3.toggle('foo bar') } catch (e) { err = e } err.name", .ex = "InvalidCharacterError" },
+        .{ .src = "cl3.value", .ex = "empty ok" },
     };
     try checkCases(js_env, &toogle);
 
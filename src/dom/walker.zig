@@ -50,6 +50,10 @@ pub const WalkerDepthFirst = struct {
             return next;
         }
 
+        // The root without children ends the iteration, its siblings are
+        // outside of the walk.
+        if (n == root) return null;
+
         // TODO deinit next
         if (try parser.nodeNextSibling(n)) |next| {
             return next;
@@ -33,6 +33,10 @@ const EventTarget = @import("../dom/event_target.zig").EventTarget;
 const EventTargetUnion = @import("../dom/event_target.zig").Union;
 
 const ProgressEvent = @import("../xhr/progress_event.zig").ProgressEvent;
+const UIEvent = @import("ui_event.zig").UIEvent;
+const MouseEvent = @import("ui_event.zig").MouseEvent;
+const KeyboardEvent = @import("ui_event.zig").KeyboardEvent;
+const InputEvent = @import("ui_event.zig").InputEvent;
 
 const log = std.log.scoped(.events);
 
@@ -40,6 +44,10 @@ const log = std.log.scoped(.events);
 pub const Interfaces = generate.Tuple(.{
     Event,
     ProgressEvent,
+    UIEvent,
+    MouseEvent,
+    KeyboardEvent,
+    InputEvent,
 });
 const Generated = generate.Union.compile(Interfaces);
 pub const Union = Generated._union;
@@ -63,6 +71,10 @@ pub const Event = struct {
         return switch (try parser.eventGetInternalType(evt)) {
             .event => .{ .Event = evt },
             .progress_event => .{ .ProgressEvent = @as(*ProgressEvent, @ptrCast(evt)).* },
+            .ui_event => .{ .UIEvent = @as(*UIEvent, @ptrCast(evt)).* },
+            .mouse_event => .{ .MouseEvent = @as(*MouseEvent, @ptrCast(evt)).* },
+            .keyboard_event => .{ .KeyboardEvent = @as(*KeyboardEvent, @ptrCast(evt)).* },
+            .input_event => .{ .InputEvent = @as(*InputEvent, @ptrCast(evt)).* },
         };
     }
 
@@ -106,10 +118,16 @@ pub const Event = struct {
         return try parser.eventDefaultPrevented(self);
     }
 
+    // The events created by the scripts are untrusted.
     pub fn get_isTrusted(self: *parser.Event) !bool {
         return try parser.eventIsTrusted(self);
     }
 
+    // There is no shadow DOM, so composed has no effect on the dispatch.
+    pub fn get_composed(self: *parser.Event) !bool {
+        return try parser.eventComposed(self);
+    }
+
     pub fn get_timestamp(self: *parser.Event) !u32 {
         return try parser.eventTimestamp(self);
     }
@@ -122,11 +140,14 @@ pub const Event = struct {
         bubbles: ?bool,
         cancelable: ?bool,
     ) !void {
+        // initEvent keeps the composed flag.
         const opts = EventInit{
             .bubbles = bubbles orelse false,
             .cancelable = cancelable orelse false,
+            .composed = try parser.eventComposed(self),
         };
-        return try parser.eventInit(self, eventType, opts);
+        try parser.eventInit(self, eventType, opts);
+        parser.eventSetTrusted(self, false);
     }
 
     pub fn _stopPropagation(self: *parser.Event) !void {
@@ -167,8 +188,13 @@ pub fn testExecFn(
         .{ .src = "evt.bubbles", .ex = "true" },
         .{ .src = "evt.cancelable", .ex = "true" },
         .{ .src = "evt.defaultPrevented", .ex = "true" },
-        .{ .src = "evt.isTrusted", .ex = "true" },
+        .{ .src = "evt.isTrusted", .ex = "false" },
+        .{ .src = "evt.composed", .ex = "false" },
         .{ .src = "evt.timestamp > 1704063600", .ex = "true" }, // 2024/01/01 00:00
+        .{ .src = "let composed = new Event('composed', {composed: true})", .ex = "undefined" },
+        .{ .src = "composed.composed", .ex = "true" },
+        .{ .src = "composed.initEvent('composed2', true)", .ex = "undefined" },
+        .{ .src = "composed.composed", .ex = "true" },
         // event.type, event.currentTarget, event.phase checked in EventTarget
     };
     try checkCases(js_env, &basic);
@@ -212,6 +238,21 @@ pub fn testExecFn(
         , .ex = "undefined" },
         .{ .src = "content.dispatchEvent(new Event('immediate'))", .ex = "true" },
         .{ .src = "nb", .ex = "1" }, // will be 2 if event was not stopped at first content event listener
+
+        // stopImmediatePropagation stops the propagation to the other nodes too.
+        .{ .src = "nb = 0", .ex = "0" },
+        .{ .src = "para.addEventListener('immediate', function(e) { e.stopImmediatePropagation(); nb = nb + 1; })", .ex = "undefined" },
+        .{ .src = "para.addEventListener('immediate', function(e) { nb = nb + 10; })", .ex = "undefined" },
+        .{ .src = "para.dispatchEvent(new Event('immediate', {bubbles: true}))", .ex = "true" },
+        .{ .src = "nb", .ex = "1" },
+
+        // stopPropagation doesn't stop the other listeners of the current target.
+        .{ .src = "nb = 0", .ex = "0" },
+        .{ .src = "para.addEventListener('stop2', function(e) { e.stopPropagation(); nb = nb + 1; })", .ex = "undefined" },
+        .{ .src = "para.addEventListener('stop2', function(e) { nb = nb + 10; })", .ex = "undefined" },
+        .{ .src = "content.addEventListener('stop2', function(e) { nb = nb + 100; })", .ex = "undefined" },
+        .{ .src = "para.dispatchEvent(new Event('stop2', {bubbles: true}))", .ex = "true" },
+        .{ .src = "nb", .ex = "11" },
     };
     try checkCases(js_env, &stop_immediate);
 
@@ -227,6 +268,7 @@ pub fn testExecFn(
         .{ .src = "evtLegacy.initEvent('legacy')", .ex = "undefined" },
         .{ .src = "content.dispatchEvent(evtLegacy)", .ex = "true" },
         .{ .src = "nb", .ex = "1" },
+        .{ .src = "evtLegacy.isTrusted", .ex = "false" },
     };
     try checkCases(js_env, &legacy);
 
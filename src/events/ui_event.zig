@@ -0,0 +1,453 @@
+// Copyright (C) 2023-2024  Lightpanda (Selecy SAS)
+//
+// Francis Bouvier <francis@lightpanda.io>
+// Pierre Tachoire <pierre@lightpanda.io>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+const std = @import("std");
+
+const jsruntime = @import("jsruntime");
+const Case = jsruntime.test_utils.Case;
+const checkCases = jsruntime.test_utils.checkCases;
+
+const parser = @import("netsurf");
+const Event = @import("event.zig").Event;
+
+const DOMException = @import("../dom/exceptions.zig").DOMException;
+
+// init creates a script event of internal type typ.
+fn init(eventType: []const u8, opts: parser.EventInit, typ: parser.EventType) !parser.Event {
+    const event = try parser.eventCreate();
+    defer parser.eventDestroy(event);
+    try parser.eventInit(event, eventType, opts);
+    try parser.eventSetInternalType(event, typ);
+    return event.*;
+}
+
+// https://w3c.github.io/uievents/#dom-eventmodifierinit-getmodifierstate
+fn modifierState(key: []const u8, ctrl: bool, shift: bool, alt: bool, meta: bool) bool {
+    if (std.mem.eql(u8, key, "Control")) return ctrl;
+    if (std.mem.eql(u8, key, "Shift")) return shift;
+    if (std.mem.eql(u8, key, "Alt")) return alt;
+    if (std.mem.eql(u8, key, "Meta")) return meta;
+    return false;
+}
+
+// https://w3c.github.io/uievents/#interface-uievent
+// TODO support the view.
+pub const UIEvent = struct {
+    pub const prototype = *Event;
+    pub const Exception = DOMException;
+    pub const mem_guarantied = true;
+
+    pub const EventInit = struct {
+        bubbles: bool = false,
+        cancelable: bool = false,
+        composed: bool = false,
+        detail: i32 = 0,
+    };
+
+    proto: parser.Event,
+    detail: i32 = 0,
+
+    pub fn constructor(eventType: []const u8, opts: ?EventInit) !UIEvent {
+        const o = opts orelse EventInit{};
+        return .{
+            .proto = try init(eventType, .{
+                .bubbles = o.bubbles,
+                .cancelable = o.cancelable,
+                .composed = o.composed,
+            }, .ui_event),
+            .detail = o.detail,
+        };
+    }
+
+    pub fn get_detail(self: UIEvent) i32 {
+        return self.detail;
+    }
+};
+
+// https://w3c.github.io/uievents/#interface-mouseevent
+// There is no layout, so the page and offset coordinates are the client
+// ones.
+// TODO support the relatedTarget.
+pub const MouseEvent = struct {
+    pub const prototype = *UIEvent;
+    pub const Exception = DOMException;
+    pub const mem_guarantied = true;
+
+    pub const EventInit = struct {
+        bubbles: bool = false,
+        cancelable: bool = false,
+        composed: bool = false,
+        detail: i32 = 0,
+        ctrlKey: bool = false,
+        shiftKey: bool = false,
+        altKey: bool = false,
+        metaKey: bool = false,
+        screenX: i32 = 0,
+        screenY: i32 = 0,
+        clientX: i32 = 0,
+        clientY: i32 = 0,
+        button: i16 = 0,
+        buttons: u16 = 0,
+    };
+
+    proto: UIEvent,
+    ctrlKey: bool = false,
+    shiftKey: bool = false,
+    altKey: bool = false,
+    metaKey: bool = false,
+    screenX: i32 = 0,
+    screenY: i32 = 0,
+    clientX: i32 = 0,
+    clientY: i32 = 0,
+    button: i16 = 0,
+    buttons: u16 = 0,
+
+    pub fn constructor(eventType: []const u8, opts: ?EventInit) !MouseEvent {
+        const o = opts orelse EventInit{};
+        return .{
+            .proto = .{
+                .proto = try init(eventType, .{
+                    .bubbles = o.bubbles,
+                    .cancelable = o.cancelable,
+                    .composed = o.composed,
+                }, .mouse_event),
+                .detail = o.detail,
+            },
+            .ctrlKey = o.ctrlKey,
+            .shiftKey = o.shiftKey,
+            .altKey = o.altKey,
+            .metaKey = o.metaKey,
+            .screenX = o.screenX,
+            .screenY = o.screenY,
+            .clientX = o.clientX,
+            .clientY = o.clientY,
+            .button = o.button,
+            .buttons = o.buttons,
+        };
+    }
+
+    pub fn get_ctrlKey(self: MouseEvent) bool {
+        return self.ctrlKey;
+    }
+
+    pub fn get_shiftKey(self: MouseEvent) bool {
+        return self.shiftKey;
+    }
+
+    pub fn get_altKey(self: MouseEvent) bool {
+        return self.altKey;
+    }
+
+    pub fn get_metaKey(self: MouseEvent) bool {
+        return self.metaKey;
+    }
+
+    pub fn get_screenX(self: MouseEvent) i32 {
+        return self.screenX;
+    }
+
+    pub fn get_screenY(self: MouseEvent) i32 {
+        return self.screenY;
+    }
+
+    pub fn get_clientX(self: MouseEvent) i32 {
+        return self.clientX;
+    }
+
+    pub fn get_clientY(self: MouseEvent) i32 {
+        return self.clientY;
+    }
+
+    pub fn get_x(self: MouseEvent) i32 {
+        return self.clientX;
+    }
+
+    pub fn get_y(self: MouseEvent) i32 {
+        return self.clientY;
+    }
+
+    pub fn get_pageX(self: MouseEvent) i32 {
+        return self.clientX;
+    }
+
+    pub fn get_pageY(self: MouseEvent) i32 {
+        return self.clientY;
+    }
+
+    pub fn get_offsetX(self: MouseEvent) i32 {
+        return self.clientX;
+    }
+
+    pub fn get_offsetY(self: MouseEvent) i32 {
+        return self.clientY;
+    }
+
+    pub fn get_button(self: MouseEvent) i16 {
+        return self.button;
+    }
+
+    pub fn get_buttons(self: MouseEvent) u16 {
+        return self.buttons;
+    }
+
+    pub fn _getModifierState(self: MouseEvent, key: []const u8) bool {
+        return modifierState(key, self.ctrlKey, self.shiftKey, self.altKey, self.metaKey);
+    }
+};
+
+// https://w3c.github.io/uievents/#interface-keyboardevent
+pub const KeyboardEvent = struct {
+    pub const prototype = *UIEvent;
+    pub const Exception = DOMException;
+    pub const mem_guarantied = true;
+
+    pub const _DOM_KEY_LOCATION_STANDARD = 0;
+    pub const _DOM_KEY_LOCATION_LEFT = 1;
+    pub const _DOM_KEY_LOCATION_RIGHT = 2;
+    pub const _DOM_KEY_LOCATION_NUMPAD = 3;
+
+    pub const EventInit = struct {
+        bubbles: bool = false,
+        cancelable: bool = false,
+        composed: bool = false,
+        detail: i32 = 0,
+        ctrlKey: bool = false,
+        shiftKey: bool = false,
+        altKey: bool = false,
+        metaKey: bool = false,
+        key: []const u8 = "",
+        code: []const u8 = "",
+        location: u32 = 0,
+        repeat: bool = false,
+        isComposing: bool = false,
+        charCode: u32 = 0,
+        keyCode: u32 = 0,
+    };
+
+    proto: UIEvent,
+    ctrlKey: bool = false,
+    shiftKey: bool = false,
+    altKey: bool = false,
+    metaKey: bool = false,
+    key: []const u8 = "",
+    code: []const u8 = "",
+    location: u32 = 0,
+    repeat: bool = false,
+    isComposing: bool = false,
+    charCode: u32 = 0,
+    keyCode: u32 = 0,
+
+    pub fn constructor(alloc: std.mem.Allocator, eventType: []const u8, opts: ?EventInit) !KeyboardEvent {
+        const o = opts orelse EventInit{};
+        return .{
+            .proto = .{
+                .proto = try init(eventType, .{
+                    .bubbles = o.bubbles,
+                    .cancelable = o.cancelable,
+                    .composed = o.composed,
+                }, .keyboard_event),
+                .detail = o.detail,
+            },
+            .ctrlKey = o.ctrlKey,
+            .shiftKey = o.shiftKey,
+            .altKey = o.altKey,
+            .metaKey = o.metaKey,
+            // the strings are copied b/c the event outlives the call.
+            .key = try alloc.dupe(u8, o.key),
+            .code = try alloc.dupe(u8, o.code),
+            .location = o.location,
+            .repeat = o.repeat,
+            .isComposing = o.isComposing,
+            .charCode = o.charCode,
+            .keyCode = o.keyCode,
+        };
+    }
+
+    pub fn get_ctrlKey(self: KeyboardEvent) bool {
+        return self.ctrlKey;
+    }
+
+    pub fn get_shiftKey(self: KeyboardEvent) bool {
+        return self.shiftKey;
+    }
+
+    pub fn get_altKey(self: KeyboardEvent) bool {
+        return self.altKey;
+    }
+
+    pub fn get_metaKey(self: KeyboardEvent) bool {
+        return self.metaKey;
+    }
+
+    pub fn get_key(self: KeyboardEvent) []const u8 {
+        return self.key;
+    }
+
+    pub fn get_code(self: KeyboardEvent) []const u8 {
+        return self.code;
+    }
+
+    pub fn get_location(self: KeyboardEvent) u32 {
+        return self.location;
+    }
+
+    pub fn get_repeat(self: KeyboardEvent) bool {
+        return self.repeat;
+    }
+
+    pub fn get_isComposing(self: KeyboardEvent) bool {
+        return self.isComposing;
+    }
+
+    pub fn get_charCode(self: KeyboardEvent) u32 {
+        return self.charCode;
+    }
+
+    pub fn get_keyCode(self: KeyboardEvent) u32 {
+        return self.keyCode;
+    }
+
+    pub fn _getModifierState(self: KeyboardEvent, key: []const u8) bool {
+        return modifierState(key, self.ctrlKey, self.shiftKey, self.altKey, self.metaKey);
+    }
+};
+
+// https://w3c.github.io/uievents/#interface-inputevent
+pub const InputEvent = struct {
+    pub const prototype = *UIEvent;
+    pub const Exception = DOMException;
+    pub const mem_guarantied = true;
+
+    pub const EventInit = struct {
+        bubbles: bool = false,
+        cancelable: bool = false,
+        composed: bool = false,
+        detail: i32 = 0,
+        data: ?[]const u8 = null,
+        isComposing: bool = false,
+        inputType: []const u8 = "",
+    };
+
+    proto: UIEvent,
+    data: ?[]const u8 = null,
+    isComposing: bool = false,
+    inputType: []const u8 = "",
+
+    pub fn constructor(alloc: std.mem.Allocator, eventType: []const u8, opts: ?EventInit) !InputEvent {
+        const o = opts orelse EventInit{};
+        return .{
+            .proto = .{
+                .proto = try init(eventType, .{
+                    .bubbles = o.bubbles,
+                    .cancelable = o.cancelable,
+                    .composed = o.composed,
+                }, .input_event),
+                .detail = o.detail,
+            },
+            // the strings are copied b/c the event outlives the call.
+            .data = if (o.data) |data| try alloc.dupe(u8, data) else null,
+            .isComposing = o.isComposing,
+            .inputType = try alloc.dupe(u8, o.inputType),
+        };
+    }
+
+    pub fn get_data(self: InputEvent) ?[]const u8 {
+        return self.data;
+    }
+
+    pub fn get_isComposing(self: InputEvent) bool {
+        return self.isComposing;
+    }
+
+    pub fn get_inputType(self: InputEvent) []const u8 {
+        return self.inputType;
+    }
+};
+
+// Tests
+// -----
+
+pub fn testExecFn(
+    _: std.mem.Allocator,
+    js_env: *jsruntime.Env,
+) anyerror!void {
+    var ui_event = [_]Case{
+        .{ .src = "let uievt = new UIEvent('foo', { bubbles: true, detail: 2 })", .ex = "undefined" },
+        .{ .src = "uievt instanceof Event", .ex = "true" },
+        .{ .src = "uievt.type", .ex = "foo" },
+        .{ .src = "uievt.bubbles", .ex = "true" },
+        .{ .src = "uievt.cancelable", .ex = "false" },
+        .{ .src = "uievt.detail", .ex = "2" },
+        .{ .src = "uievt.composed", .ex = "false" },
+        .{ .src = "new UIEvent('foo', { composed: true }).composed", .ex = "true" },
+        .{ .src = "uievt.isTrusted", .ex = "false" },
+    };
+    try checkCases(js_env, &ui_event);
+
+    var mouse_event = [_]Case{
+        .{ .src = "let mevt = new MouseEvent('click', { bubbles: true, cancelable: true, clientX: 10, clientY: 20, button: 1, buttons: 4, ctrlKey: true })", .ex = "undefined" },
+        .{ .src = "mevt instanceof UIEvent", .ex = "true" },
+        .{ .src = "mevt.clientX + ',' + mevt.clientY", .ex = "10,20" },
+        .{ .src = "mevt.x + ',' + mevt.pageY", .ex = "10,20" },
+        .{ .src = "mevt.screenX", .ex = "0" },
+        .{ .src = "new MouseEvent('click', { composed: true }).composed", .ex = "true" },
+        .{ .src = "mevt.button + ',' + mevt.buttons", .ex = "1,4" },
+        .{ .src = "mevt.ctrlKey + ',' + mevt.shiftKey", .ex = "true,false" },
+        .{ .src = "mevt.getModifierState('Control')", .ex = "true" },
+        .{ .src = "mevt.getModifierState('Alt')", .ex = "false" },
+
+        .{ .src = "let mnb = 0; let mtarget = null; let mclientX = 0", .ex = "undefined" },
+        .{ .src = "document.addEventListener('click', (e) => { mnb++; mtarget = e.target; mclientX = e.clientX; e.preventDefault(); })", .ex = "undefined" },
+        .{ .src = "document.getElementById('link').dispatchEvent(mevt)", .ex = "false" },
+        .{ .src = "mnb", .ex = "1" },
+        .{ .src = "mtarget.id", .ex = "link" },
+        .{ .src = "mclientX", .ex = "10" },
+        .{ .src = "mevt.defaultPrevented", .ex = "true" },
+        .{ .src = "mevt.isTrusted", .ex = "false" },
+    };
+    try checkCases(js_env, &mouse_event);
+
+    var keyboard_event = [_]Case{
+        .{ .src = "let kevt = new KeyboardEvent('keydown', { key: 'a', code: 'KeyA', shiftKey: true, repeat: true })", .ex = "undefined" },
+        .{ .src = "kevt instanceof UIEvent", .ex = "true" },
+        .{ .src = "kevt.key + ',' + kevt.code", .ex = "a,KeyA" },
+        .{ .src = "kevt.shiftKey + ',' + kevt.ctrlKey", .ex = "true,false" },
+        .{ .src = "kevt.repeat", .ex = "true" },
+        .{ .src = "new KeyboardEvent('keydown', { composed: true }).composed", .ex = "true" },
+        .{ .src = "kevt.location === KeyboardEvent.DOM_KEY_LOCATION_STANDARD", .ex = "true" },
+        .{ .src = "kevt.getModifierState('Shift')", .ex = "true" },
+        .{ .src = "new KeyboardEvent('keyup').key", .ex = "" },
+
+        .{ .src = "let kkey = null", .ex = "undefined" },
+        .{ .src = "document.addEventListener('keydown', (e) => { kkey = e.key })", .ex = "undefined" },
+        .{ .src = "document.dispatchEvent(kevt)", .ex = "true" },
+        .{ .src = "kkey", .ex = "a" },
+    };
+    try checkCases(js_env, &keyboard_event);
+
+    var input_event = [_]Case{
+        .{ .src = "let ievt = new InputEvent('input', { data: 'foo', inputType: 'insertText' })", .ex = "undefined" },
+        .{ .src = "ievt instanceof UIEvent", .ex = "true" },
+        .{ .src = "ievt.data", .ex = "foo" },
+        .{ .src = "ievt.inputType", .ex = "insertText" },
+        .{ .src = "ievt.isComposing", .ex = "false" },
+        .{ .src = "new InputEvent('input', { composed: true }).composed", .ex = "true" },
+        .{ .src = "new InputEvent('input').data", .ex = "null" },
+    };
+    try checkCases(js_env, &input_event);
+}
@@ -27,6 +27,8 @@ const checkCases = jsruntime.test_utils.checkCases;
 const Node = @import("../dom/node.zig").Node;
 const Document = @import("../dom/document.zig").Document;
 const NodeList = @import("../dom/nodelist.zig").NodeList;
+const Element = @import("../dom/element.zig").Element;
+const ElementUnion = @import("../dom/element.zig").Union;
 const HTMLElem = @import("elements.zig");
 
 const collection = @import("../dom/html_collection.zig");
@@ -202,6 +204,22 @@ pub const HTMLDocument = struct {
         return "";
     }
 
+    // https://drafts.csswg.org/cssom-view/#dom-document-elementfrompoint
+    // There is no layout, so every element has an empty box at the origin
+    // and none of them contains the point: the body, or the root element,
+    // is returned as the topmost element.
+    // elementsFromPoint is implemented by the polyfill.
+    // TODO return null for the points outside of the viewport.
+    pub fn _elementFromPoint(self: *parser.DocumentHTML, _: f64, _: f64) !?ElementUnion {
+        if (try parser.documentHTMLBody(self)) |body| {
+            return try Element.toInterface(@as(*parser.Element, @ptrCast(body)));
+        }
+
+        const doc = parser.documentHTMLToDocument(self);
+        const root = try parser.documentGetDocumentElement(doc) orelse return null;
+        return try Element.toInterface(root);
+    }
+
     pub fn deinit(_: *parser.DocumentHTML, _: std.mem.Allocator) void {}
 };
 
@@ -251,4 +269,14 @@ pub fn testExecFn(
         .{ .src = "list.length", .ex = "1" },
     };
     try checkCases(js_env, &getElementsByName);
+
+    var elementFromPoint = [_]Case{
+        .{ .src = "document.elementFromPoint(0, 0) === document.body", .ex = "true" },
+        .{ .src = "document.elementFromPoint(10, 20) === document.body", .ex = "true" },
+        .{ .src = "document.elementFromPoint(-1, 1e6) === document.body", .ex = "true" },
+        .{ .src = "let efp = document.elementsFromPoint(10, 20)", .ex = "undefined" },
+        .{ .src = "Array.isArray(efp)", .ex = "true" },
+        .{ .src = "efp.map((e) => e.localName).join(',')", .ex = "body,html" },
+    };
+    try checkCases(js_env, &elementFromPoint);
 }
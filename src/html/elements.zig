@@ -26,6 +26,7 @@ const checkCases = jsruntime.test_utils.checkCases;
 
 const Element = @import("../dom/element.zig").Element;
 const URL = @import("../url/url.zig").URL;
+const form = @import("form.zig");
 
 // HTMLElement interfaces
 pub const Interfaces = .{
@@ -520,6 +521,14 @@ pub const HTMLFormElement = struct {
     pub const Self = parser.Form;
     pub const prototype = *HTMLElement;
     pub const mem_guarantied = true;
+
+    pub fn _submit(self: *parser.Form, alloc: std.mem.Allocator) !void {
+        try form.submit(alloc, self);
+    }
+
+    pub fn _requestSubmit(self: *parser.Form, alloc: std.mem.Allocator, submitter: ?*parser.Element) !void {
+        try form.requestSubmit(alloc, self, submitter);
+    }
 };
 
 pub const HTMLFrameElement = struct {
@@ -574,6 +583,49 @@ pub const HTMLInputElement = struct {
     pub const Self = parser.Input;
     pub const prototype = *HTMLElement;
     pub const mem_guarantied = true;
+
+    // https://html.spec.whatwg.org/multipage/input.html#dom-input-value
+    const ValueMode = enum { value, default, default_on, filename };
+
+    fn valueMode(self: *parser.Input) !ValueMode {
+        const t = try parser.elementGetAttribute(@as(*parser.Element, @ptrCast(self)), "type") orelse return .value;
+        const modes = [_]struct { []const u8, ValueMode }{
+            .{ "hidden", .default },
+            .{ "submit", .default },
+            .{ "image", .default },
+            .{ "reset", .default },
+            .{ "button", .default },
+            .{ "checkbox", .default_on },
+            .{ "radio", .default_on },
+            .{ "file", .filename },
+        };
+        for (modes) |m| {
+            if (std.ascii.eqlIgnoreCase(t, m[0])) return m[1];
+        }
+        return .value;
+    }
+
+    // The value set by a script is kept apart from the value attribute, it's
+    // read by the validation and the form submission.
+    pub fn get_value(self: *parser.Input) ![]const u8 {
+        const e = @as(*parser.Element, @ptrCast(self));
+        return switch (try valueMode(self)) {
+            .value => try parser.controlDirtyValue(e) orelse try parser.elementGetAttribute(e, "value") orelse "",
+            .default => try parser.elementGetAttribute(e, "value") orelse "",
+            .default_on => try parser.elementGetAttribute(e, "value") orelse "on",
+            // there is no selected file.
+            .filename => "",
+        };
+    }
+
+    pub fn set_value(self: *parser.Input, alloc: std.mem.Allocator, v: []const u8) !void {
+        const e = @as(*parser.Element, @ptrCast(self));
+        switch (try valueMode(self)) {
+            .value => try parser.controlSetDirtyValue(alloc, e, v),
+            .default, .default_on => try parser.elementSetAttribute(e, "value", v),
+            .filename => if (v.len > 0) return parser.DOMError.InvalidState,
+        }
+    }
 };
 
 pub const HTMLLIElement = struct {
@@ -870,12 +922,27 @@ pub const HTMLTemplateElement = struct {
     pub const Self = parser.Template;
     pub const prototype = *HTMLElement;
     pub const mem_guarantied = true;
+
+    pub fn get_content(self: *parser.Template) !*parser.DocumentFragment {
+        return try parser.templateContent(self);
+    }
 };
 
 pub const HTMLTextAreaElement = struct {
     pub const Self = parser.TextArea;
     pub const prototype = *HTMLElement;
     pub const mem_guarantied = true;
+
+    // The default value is the text content.
+    // https://html.spec.whatwg.org/multipage/form-elements.html#dom-textarea-value
+    pub fn get_value(self: *parser.TextArea) ![]const u8 {
+        const e = @as(*parser.Element, @ptrCast(self));
+        return try parser.controlDirtyValue(e) orelse try parser.nodeTextContent(parser.elementToNode(e)) orelse "";
+    }
+
+    pub fn set_value(self: *parser.TextArea, alloc: std.mem.Allocator, v: []const u8) !void {
+        try parser.controlSetDirtyValue(alloc, @as(*parser.Element, @ptrCast(self)), v);
+    }
 };
 
 pub const HTMLTimeElement = struct {
@@ -1059,4 +1126,65 @@ pub fn testExecFn(
         .{ .src = "script.async", .ex = "false" },
     };
     try checkCases(js_env, &script);
+
+    var submit = [_]Case{
+        .{ .src = "let form = document.createElement('form')", .ex = "undefined" },
+        .{ .src = "form.innerHTML = '<input name=\"a\" value=\"1\"><button id=\"fsubmit\" name=\"b\" formaction=\"/foo\">ok</button><button id=\"freset\" type=\"reset\">reset</button>'; true", .ex = "true" },
+        .{ .src = "document.getElementById('content').appendChild(form); true", .ex = "true" },
+        .{ .src = "let nbsubmit = 0", .ex = "undefined" },
+        .{ .src = "form.addEventListener('submit', () => { nbsubmit++ })", .ex = "undefined" },
+
+        .{ .src = "form.requestSubmit()", .ex = "undefined" },
+        .{ .src = "nbsubmit", .ex = "1" },
+        .{ .src = "form.requestSubmit(document.getElementById('fsubmit'))", .ex = "undefined" },
+        .{ .src = "nbsubmit", .ex = "2" },
+
+        // submit() doesn't fire the submit event.
+        .{ .src = "form.submit()", .ex = "undefined" },
+        .{ .src = "nbsubmit", .ex = "2" },
+
+        .{ .src = "let canceled = false", .ex = "undefined" },
+        .{ .src = "form.addEventListener('submit', (e) => { e.preventDefault(); canceled = e.defaultPrevented })", .ex = "undefined" },
+        .{ .src = "form.requestSubmit()", .ex = "undefined" },
+        .{ .src = "nbsubmit", .ex = "3" },
+        .{ .src = "canceled", .ex = "true" },
+
+        .{ .src = "var err; try { form.requestSubmit(document.getElementById('freset')) } catch (e) { err = e } err instanceof TypeError", .ex = "true" },
+        .{ .src = "err = undefined; try { form.requestSubmit(document.getElementById('link')) } catch (e) { err = e } err instanceof TypeError", .ex = "true" },
+        .{ .src = "let other = document.createElement('button')", .ex = "undefined" },
+        .{ .src = "try { form.requestSubmit(other) } catch (e) { err = e } err.name", .ex = "NotFoundError" },
+        .{ .src = "nbsubmit", .ex = "3" },
+    };
+    try checkCases(js_env, &submit);
+
+    var template = [_]Case{
+        .{ .src = "let tpl = document.createElement('template')", .ex = "undefined" },
+        .{ .src = "tpl.innerHTML = '<p id=\"tplp\">foo</p><template><b>bar</b></template>'; true", .ex = "true" },
+        .{ .src = "tpl.childNodes.length", .ex = "0" },
+        .{ .src = "tpl.content instanceof DocumentFragment", .ex = "true" },
+        .{ .src = "tpl.content.childNodes.length", .ex = "2" },
+        .{ .src = "tpl.content.ownerDocument === document", .ex = "false" },
+        .{ .src = "tpl.innerHTML", .ex = "<p id=\"tplp\">foo</p><template><b>bar</b></template>" },
+        .{ .src = "tpl.content.lastChild.childNodes.length", .ex = "0" },
+        .{ .src = "tpl.content.lastChild.content.childNodes.length", .ex = "1" },
+
+        .{ .src = "document.getElementById('content').appendChild(tpl); true", .ex = "true" },
+        .{ .src = "document.getElementById('tplp')", .ex = "null" },
+
+        .{ .src = "document.getElementById('content').appendChild(tpl.content.cloneNode(true)); true", .ex = "true" },
+        .{ .src = "document.getElementById('tplp').textContent", .ex = "foo" },
+        .{ .src = "document.getElementById('tplp').ownerDocument === document", .ex = "true" },
+        .{ .src = "tpl.content.childNodes.length", .ex = "2" },
+
+        .{ .src = "tpl.cloneNode(true).content.childNodes.length", .ex = "2" },
+        .{ .src = "tpl.cloneNode(false).content.childNodes.length", .ex = "0" },
+
+        // the children appended by a script stay in the template.
+        .{ .src = "let tpl2 = document.createElement('template'); tpl2.appendChild(document.createElement('p')); true", .ex = "true" },
+        .{ .src = "tpl2.content.childNodes.length", .ex = "0" },
+        .{ .src = "tpl2.childNodes.length", .ex = "1" },
+        .{ .src = "let tpl2c = tpl2.cloneNode(true); tpl2c.childNodes.length", .ex = "1" },
+        .{ .src = "tpl2c.content.childNodes.length", .ex = "0" },
+    };
+    try checkCases(js_env, &template);
 }
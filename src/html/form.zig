@@ -0,0 +1,364 @@
+// Copyright (C) 2023-2024  Lightpanda (Selecy SAS)
+//
+// Francis Bouvier <francis@lightpanda.io>
+// Pierre Tachoire <pierre@lightpanda.io>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+const std = @import("std");
+
+const parser = @import("netsurf");
+
+const Walker = @import("../dom/walker.zig").WalkerDepthFirst;
+const query = @import("../url/query.zig");
+const resolveURL = @import("../url/url.zig").resolve;
+
+const log = std.log.scoped(.form);
+
+// https://html.spec.whatwg.org/multipage/form-control-infrastructure.html#form-submission-2
+
+pub const Method = enum {
+    get,
+    post,
+    dialog,
+
+    fn parse(v: ?[]const u8) Method {
+        const m = v orelse return .get;
+        if (std.ascii.eqlIgnoreCase(m, "post")) return .post;
+        if (std.ascii.eqlIgnoreCase(m, "dialog")) return .dialog;
+        return .get;
+    }
+};
+
+pub const Enctype = enum {
+    urlencoded,
+    multipart,
+    text,
+
+    fn parse(v: ?[]const u8) Enctype {
+        const e = v orelse return .urlencoded;
+        if (std.ascii.eqlIgnoreCase(e, "multipart/form-data")) return .multipart;
+        if (std.ascii.eqlIgnoreCase(e, "text/plain")) return .text;
+        return .urlencoded;
+    }
+};
+
+pub const Entry = struct {
+    name: []const u8,
+    value: []const u8,
+};
+
+// Submission contains the result of the form submission algorithm.
+// The strings are owned by the allocator given to init.
+pub const Submission = struct {
+    action: []const u8,
+    method: Method,
+    enctype: Enctype,
+    entries: std.ArrayListUnmanaged(Entry) = .{},
+
+    // init runs the form submission algorithm without navigation: it resolves the
+    // action, the method and the enctype using the submitter's overrides, then
+    // constructs the entry list.
+    pub fn init(alloc: std.mem.Allocator, form: *parser.Form, submitter: ?*parser.Element) !Submission {
+        const form_elem: *parser.Element = @ptrCast(form);
+        const sub = submitter orelse form_elem;
+
+        const method = Method.parse(try override(sub, form_elem, "formmethod", "method"));
+        const enctype = Enctype.parse(try override(sub, form_elem, "formenctype", "enctype"));
+
+        const doc = try parser.nodeOwnerDocument(@ptrCast(form)) orelse return parser.DOMError.InvalidState;
+        const base = try parser.documentGetDocumentURI(doc);
+
+        const action = try resolve(alloc, base, try override(sub, form_elem, "formaction", "action") orelse "");
+        var s = Submission{ .action = action, .method = method, .enctype = enctype };
+        errdefer s.deinit(alloc);
+
+        try constructEntryList(alloc, &s, form, doc, submitter);
+        return s;
+    }
+
+    pub fn deinit(self: *Submission, alloc: std.mem.Allocator) void {
+        for (self.entries.items) |e| {
+            alloc.free(e.name);
+            alloc.free(e.value);
+        }
+        self.entries.deinit(alloc);
+        alloc.free(self.action);
+    }
+
+    fn append(self: *Submission, alloc: std.mem.Allocator, name: []const u8, value: []const u8) !void {
+        const n = try alloc.dupe(u8, name);
+        errdefer alloc.free(n);
+        const v = try alloc.dupe(u8, value);
+        errdefer alloc.free(v);
+
+        try self.entries.append(alloc, .{ .name = n, .value = v });
+    }
+
+    // encode writes the entries using the submission's enctype.
+    // https://url.spec.whatwg.org/#concept-urlencoded-serializer
+    // https://html.spec.whatwg.org/multipage/form-control-infrastructure.html#text/plain-encoding-algorithm
+    // TODO support multipart/form-data.
+    pub fn encode(self: *const Submission, writer: anytype) !void {
+        for (self.entries.items, 0..) |e, i| {
+            switch (self.enctype) {
+                .text => try writer.print("{s}={s}\r\n", .{ e.name, e.value }),
+                .urlencoded, .multipart => {
+                    if (i > 0) try writer.writeByte('&');
+                    try query.escape(writer, e.name);
+                    try writer.writeByte('=');
+                    try query.escape(writer, e.value);
+                },
+            }
+        }
+    }
+};
+
+// requestSubmit submits the form like if the submitter was clicked.
+// https://html.spec.whatwg.org/multipage/forms.html#dom-form-requestsubmit
+pub fn requestSubmit(alloc: std.mem.Allocator, form: *parser.Form, submitter: ?*parser.Element) !void {
+    if (submitter) |s| {
+        if (!try isSubmitButton(s)) return error.TypeError;
+        if (try formOwner(s) != form) return parser.DOMError.NotFound;
+    }
+
+    try submitForm(alloc, form, submitter, false);
+}
+
+// submit submits the form without validation nor submit event.
+// https://html.spec.whatwg.org/multipage/forms.html#dom-form-submit
+pub fn submit(alloc: std.mem.Allocator, form: *parser.Form) !void {
+    try submitForm(alloc, form, null, true);
+}
+
+// https://html.spec.whatwg.org/multipage/form-control-infrastructure.html#concept-form-submit
+fn submitForm(
+    alloc: std.mem.Allocator,
+    form: *parser.Form,
+    submitter: ?*parser.Element,
+    submitted_from_method: bool,
+) !void {
+    const form_elem: *parser.Element = @ptrCast(form);
+
+    if (!submitted_from_method) {
+        // the constraints are validated by the polyfill before calling
+        // requestSubmit, unless novalidate is set.
+
+        // TODO dispatch a SubmitEvent with the submitter.
+        const evt = try parser.eventCreate();
+        defer parser.eventDestroy(evt);
+
+        try parser.eventInit(evt, "submit", .{ .bubbles = true, .cancelable = true });
+        parser.eventSetTrusted(evt, true);
+        _ = try parser.eventTargetDispatchEvent(parser.toEventTarget(parser.Element, form_elem), evt);
+
+        if (try parser.eventDefaultPrevented(evt)) return;
+    }
+
+    var s = try Submission.init(alloc, form, submitter);
+    defer s.deinit(alloc);
+
+    var body = std.ArrayList(u8).init(alloc);
+    defer body.deinit();
+    try s.encode(body.writer());
+
+    // TODO navigate to the action.
+    log.debug("submit {s} {s}: {s}", .{ @tagName(s.method), s.action, body.items });
+}
+
+// override returns the submitter's attribute if set or the form's one.
+fn override(submitter: *parser.Element, form: *parser.Element, sattr: []const u8, fattr: []const u8) !?[]const u8 {
+    if (submitter != form) {
+        if (try parser.elementGetAttribute(submitter, sattr)) |v| return v;
+    }
+    return try parser.elementGetAttribute(form, fattr);
+}
+
+// resolve returns the action resolved against the document url.
+// An empty action is the document url.
+fn resolve(alloc: std.mem.Allocator, base: []const u8, action: []const u8) ![]const u8 {
+    if (action.len == 0) return try alloc.dupe(u8, base);
+
+    return resolveURL(alloc, base, action) catch |e| switch (e) {
+        error.OutOfMemory => return e,
+        else => return try alloc.dupe(u8, action),
+    };
+}
+
+// https://html.spec.whatwg.org/multipage/form-control-infrastructure.html#constructing-the-form-data-set
+// TODO support file inputs and disabled fieldsets.
+fn constructEntryList(
+    alloc: std.mem.Allocator,
+    s: *Submission,
+    form: *parser.Form,
+    doc: *parser.Document,
+    submitter: ?*parser.Element,
+) !void {
+    const root = parser.documentToNode(doc);
+    const walker = Walker{};
+    var next: ?*parser.Node = null;
+    while (true) {
+        next = try walker.get_next(root, next) orelse break;
+        if (try parser.nodeType(next.?) != .element) continue;
+
+        const e = parser.nodeToElement(next.?);
+        const tag = try tagOf(e);
+        switch (tag) {
+            .button, .input, .select, .textarea => {},
+            else => continue,
+        }
+
+        if (try formOwner(e) != form) continue;
+        if (try parser.elementHasAttribute(e, "disabled")) continue;
+
+        // a button is submitted only if it's the submitter.
+        if (try isButton(e) and e != submitter) continue;
+
+        const name = try parser.elementGetAttribute(e, "name");
+
+        if (tag == .input) {
+            const t = try parser.elementGetAttribute(e, "type") orelse "text";
+
+            if (std.ascii.eqlIgnoreCase(t, "image")) {
+                const prefix = name orelse "";
+                const x = try std.mem.concat(alloc, u8, &.{ prefix, if (prefix.len > 0) ".x" else "x" });
+                defer alloc.free(x);
+                const y = try std.mem.concat(alloc, u8, &.{ prefix, if (prefix.len > 0) ".y" else "y" });
+                defer alloc.free(y);
+                try s.append(alloc, x, "0");
+                try s.append(alloc, y, "0");
+                continue;
+            }
+
+            const n = name orelse continue;
+            if (n.len == 0) continue;
+
+            if (std.ascii.eqlIgnoreCase(t, "checkbox") or std.ascii.eqlIgnoreCase(t, "radio")) {
+                if (!try parser.elementHasAttribute(e, "checked")) continue;
+                try s.append(alloc, n, try parser.elementGetAttribute(e, "value") orelse "on");
+                continue;
+            }
+            if (std.ascii.eqlIgnoreCase(t, "file")) continue;
+            if (std.ascii.eqlIgnoreCase(t, "hidden") and std.ascii.eqlIgnoreCase(n, "_charset_")) {
+                try s.append(alloc, n, "UTF-8");
+                continue;
+            }
+
+            // the value set by a script is submitted before the attribute.
+            try s.append(alloc, n, try parser.controlDirtyValue(e) orelse try parser.elementGetAttribute(e, "value") orelse "");
+            continue;
+        }
+
+        const n = name orelse continue;
+        if (n.len == 0) continue;
+
+        switch (tag) {
+            .select => try appendOptions(alloc, s, e, n),
+            .textarea => try s.append(alloc, n, try parser.controlDirtyValue(e) orelse try parser.nodeTextContent(next.?) orelse ""),
+            else => try s.append(alloc, n, try parser.elementGetAttribute(e, "value") orelse ""),
+        }
+    }
+}
+
+// appendOptions appends the selected options of the select.
+// Without selected option, the first option of a single select is selected.
+fn appendOptions(alloc: std.mem.Allocator, s: *Submission, select: *parser.Element, name: []const u8) !void {
+    const root: *parser.Node = @ptrCast(select);
+    const multiple = try parser.elementHasAttribute(select, "multiple");
+
+    var first: ?*parser.Element = null;
+    var selected = false;
+
+    const walker = Walker{};
+    var next: ?*parser.Node = null;
+    while (true) {
+        next = try walker.get_next(root, next) orelse break;
+        if (try parser.nodeType(next.?) != .element) continue;
+
+        const e = parser.nodeToElement(next.?);
+        if (try tagOf(e) != .option) continue;
+        if (try parser.elementHasAttribute(e, "disabled")) continue;
+
+        if (first == null) first = e;
+        if (!try parser.elementHasAttribute(e, "selected")) continue;
+
+        try s.append(alloc, name, try optionValue(e));
+        selected = true;
+        if (!multiple) return;
+    }
+
+    if (!selected and !multiple) {
+        if (first) |e| try s.append(alloc, name, try optionValue(e));
+    }
+}
+
+fn optionValue(option: *parser.Element) ![]const u8 {
+    if (try parser.elementGetAttribute(option, "value")) |v| return v;
+    return try parser.nodeTextContent(@ptrCast(option)) orelse "";
+}
+
+fn tagOf(e: *parser.Element) !parser.Tag {
+    return try parser.elementHTMLGetTagType(@as(*parser.ElementHTML, @ptrCast(e)));
+}
+
+// isButton returns true for the elements which are buttons in the form
+// submission sense.
+fn isButton(e: *parser.Element) !bool {
+    switch (try tagOf(e)) {
+        .button => return true,
+        .input => {
+            const t = try parser.elementGetAttribute(e, "type") orelse return false;
+            return std.ascii.eqlIgnoreCase(t, "submit") or
+                std.ascii.eqlIgnoreCase(t, "image") or
+                std.ascii.eqlIgnoreCase(t, "reset") or
+                std.ascii.eqlIgnoreCase(t, "button");
+        },
+        else => return false,
+    }
+}
+
+// https://html.spec.whatwg.org/multipage/forms.html#concept-submit-button
+pub fn isSubmitButton(e: *parser.Element) !bool {
+    switch (try tagOf(e)) {
+        .button => {
+            // the missing and invalid value default is submit.
+            const t = try parser.elementGetAttribute(e, "type") orelse return true;
+            return !std.ascii.eqlIgnoreCase(t, "reset") and !std.ascii.eqlIgnoreCase(t, "button");
+        },
+        .input => {
+            const t = try parser.elementGetAttribute(e, "type") orelse return false;
+            return std.ascii.eqlIgnoreCase(t, "submit") or std.ascii.eqlIgnoreCase(t, "image");
+        },
+        else => return false,
+    }
+}
+
+// formOwner returns the form associated to the element: the form referenced
+// by the form attribute or the nearest form ancestor.
+// https://html.spec.whatwg.org/multipage/form-control-infrastructure.html#form-owner
+pub fn formOwner(e: *parser.Element) !?*parser.Form {
+    if (try parser.elementGetAttribute(e, "form")) |id| {
+        const doc = try parser.nodeOwnerDocument(@ptrCast(e)) orelse return null;
+        const f = try parser.documentGetElementById(doc, id) orelse return null;
+        if (try tagOf(f) != .form) return null;
+        return @as(*parser.Form, @ptrCast(f));
+    }
+
+    var n = try parser.nodeParentNode(@ptrCast(e));
+    while (n) |p| : (n = try parser.nodeParentNode(p)) {
+        if (try parser.nodeType(p) != .element) continue;
+        if (try tagOf(parser.nodeToElement(p)) == .form) return @as(*parser.Form, @ptrCast(p));
+    }
+    return null;
+}
@@ -0,0 +1,289 @@
+// Copyright (C) 2023-2024  Lightpanda (Selecy SAS)
+//
+// Francis Bouvier <francis@lightpanda.io>
+// Pierre Tachoire <pierre@lightpanda.io>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+const std = @import("std");
+
+const jsruntime = @import("jsruntime");
+const Case = jsruntime.test_utils.Case;
+const checkCases = jsruntime.test_utils.checkCases;
+
+const parser = @import("netsurf");
+
+const DOMException = @import("../dom/exceptions.zig").DOMException;
+const resolveURL = @import("../url/url.zig").resolve;
+
+const UserContext = @import("../user_context.zig").UserContext;
+
+// https://html.spec.whatwg.org/multipage/nav-history-apis.html#the-history-interface
+//
+// The native bindings can't hold a JS value, so the state is stored
+// serialized. The polyfill serializes the state and dispatches the popstate
+// events.
+//
+// TODO keep the session history across the page's navigations.
+pub const History = struct {
+    pub const mem_guarantied = true;
+    pub const Exception = DOMException;
+
+    const Entry = struct {
+        url: []const u8,
+        state: ?[]const u8,
+
+        fn deinit(self: Entry, alloc: std.mem.Allocator) void {
+            alloc.free(self.url);
+            if (self.state) |s| alloc.free(s);
+        }
+    };
+
+    // entries is empty until the first pushState or replaceState call, the
+    // current document is then the first entry.
+    entries: std.ArrayListUnmanaged(Entry) = .{},
+    index: usize = 0,
+    // alloc is the allocator of the entries, set with the first entry.
+    alloc: ?std.mem.Allocator = null,
+
+    // reset frees the entries when the document is replaced.
+    pub fn reset(self: *History) void {
+        const alloc = self.alloc orelse return;
+        for (self.entries.items) |e| e.deinit(alloc);
+        self.entries.clearAndFree(alloc);
+        self.index = 0;
+    }
+
+    pub fn get_length(self: *History) usize {
+        if (self.entries.items.len == 0) return 1;
+        return self.entries.items.len;
+    }
+
+    pub fn get_state(self: *History) ?[]const u8 {
+        if (self.entries.items.len == 0) return null;
+        return self.entries.items[self.index].state;
+    }
+
+    // https://html.spec.whatwg.org/multipage/nav-history-apis.html#dom-history-pushstate
+    pub fn _pushState(
+        self: *History,
+        alloc: std.mem.Allocator,
+        userctx: UserContext,
+        state: ?[]const u8,
+        _: []const u8,
+        url: ?[]const u8,
+    ) !void {
+        // the polyfill passes a null state if it couldn't be serialized.
+        if (state == null) return parser.DOMError.DataClone;
+
+        const doc = parser.documentHTMLToDocument(userctx.document);
+        try self.ensureEntries(alloc, doc);
+
+        const entry = try newEntry(alloc, doc, state, url);
+        errdefer entry.deinit(alloc);
+
+        // remove the entries following the current one.
+        for (self.entries.items[self.index + 1 ..]) |e| e.deinit(alloc);
+        self.entries.shrinkRetainingCapacity(self.index + 1);
+
+        try self.entries.append(alloc, entry);
+        self.index = self.entries.items.len - 1;
+
+        try parser.documentSetDocumentURI(doc, entry.url);
+    }
+
+    // https://html.spec.whatwg.org/multipage/nav-history-apis.html#dom-history-replacestate
+    pub fn _replaceState(
+        self: *History,
+        alloc: std.mem.Allocator,
+        userctx: UserContext,
+        state: ?[]const u8,
+        _: []const u8,
+        url: ?[]const u8,
+    ) !void {
+        // the polyfill passes a null state if it couldn't be serialized.
+        if (state == null) return parser.DOMError.DataClone;
+
+        const doc = parser.documentHTMLToDocument(userctx.document);
+        try self.ensureEntries(alloc, doc);
+
+        const entry = try newEntry(alloc, doc, state, url);
+
+        self.entries.items[self.index].deinit(alloc);
+        self.entries.items[self.index] = entry;
+
+        try parser.documentSetDocumentURI(doc, entry.url);
+    }
+
+    // _go moves the current entry by delta and returns true if the current
+    // entry changed. The polyfill fires then the popstate event and
+    // implements back and forward.
+    // https://html.spec.whatwg.org/multipage/nav-history-apis.html#dom-history-go
+    // TODO reload the page on go(0).
+    pub fn _go(self: *History, userctx: UserContext, delta: ?i32) !bool {
+        const d = delta orelse 0;
+        if (d == 0) return false;
+
+        const target = @as(i64, @intCast(self.index)) + d;
+        if (target < 0 or target >= @as(i64, @intCast(self.entries.items.len))) return false;
+
+        self.index = @intCast(target);
+
+        const doc = parser.documentHTMLToDocument(userctx.document);
+        try parser.documentSetDocumentURI(doc, self.entries.items[self.index].url);
+
+        return true;
+    }
+
+    // ensureEntries adds the current document as the first entry.
+    fn ensureEntries(self: *History, alloc: std.mem.Allocator, doc: *parser.Document) !void {
+        if (self.entries.items.len > 0) return;
+        self.alloc = alloc;
+
+        const entry = try newEntry(alloc, doc, null, null);
+        errdefer entry.deinit(alloc);
+
+        try self.entries.append(alloc, entry);
+        self.index = 0;
+    }
+
+    fn newEntry(
+        alloc: std.mem.Allocator,
+        doc: *parser.Document,
+        state: ?[]const u8,
+        url: ?[]const u8,
+    ) !Entry {
+        const current = try parser.documentGetDocumentURI(doc);
+
+        const u = if (url) |v| try resolve(alloc, current, v) else try alloc.dupe(u8, current);
+        errdefer alloc.free(u);
+
+        return .{
+            .url = u,
+            .state = if (state) |s| try alloc.dupe(u8, s) else null,
+        };
+    }
+
+    // resolve returns the url resolved against the document's url.
+    // The document's url can be rewritten only if both urls have the same
+    // scheme, credentials, host and port, otherwise a SecurityError is returned.
+    // The caller owns the returned string.
+    // https://html.spec.whatwg.org/multipage/nav-history-apis.html#can-have-its-url-rewritten
+    fn resolve(alloc: std.mem.Allocator, base: []const u8, url: []const u8) ![]const u8 {
+        const u = resolveURL(alloc, base, url) catch |e| switch (e) {
+            error.OutOfMemory => return e,
+            else => return parser.DOMError.Security,
+        };
+        errdefer alloc.free(u);
+
+        const base_origin = try origin(alloc, base);
+        defer alloc.free(base_origin);
+
+        const new_origin = try origin(alloc, u);
+        defer alloc.free(new_origin);
+
+        if (!std.ascii.eqlIgnoreCase(base_origin, new_origin)) return parser.DOMError.Security;
+
+        return u;
+    }
+
+    // origin returns the scheme, the credentials, the host and the port of
+    // the url.
+    fn origin(alloc: std.mem.Allocator, url: []const u8) ![]const u8 {
+        const uri = std.Uri.parse(url) catch return parser.DOMError.Security;
+        return try serialize(alloc, uri, .{
+            .scheme = true,
+            .authentication = true,
+            .authority = true,
+        });
+    }
+
+    fn serialize(alloc: std.mem.Allocator, uri: std.Uri, opts: std.Uri.WriteToStreamOptions) ![]const u8 {
+        var buf = std.ArrayList(u8).init(alloc);
+        defer buf.deinit();
+
+        try uri.writeToStream(opts, buf.writer());
+        return try buf.toOwnedSlice();
+    }
+};
+
+// Tests
+// -----
+
+pub fn testExecFn(
+    _: std.mem.Allocator,
+    js_env: *jsruntime.Env,
+) anyerror!void {
+    var history = [_]Case{
+        .{ .src = "window.history === history", .ex = "true" },
+        .{ .src = "history.length", .ex = "1" },
+        .{ .src = "history.state", .ex = "null" },
+
+        .{ .src = "history.pushState({foo: 'bar'}, '', '#foo')", .ex = "undefined" },
+        .{ .src = "history.length", .ex = "2" },
+        .{ .src = "document.URL", .ex = "about:blank#foo" },
+        .{ .src = "history.state.foo", .ex = "bar" },
+        .{ .src = "history.state === history.state", .ex = "true" },
+
+        .{ .src = "history.replaceState({bar: 'baz'}, '', '#bar')", .ex = "undefined" },
+        .{ .src = "history.length", .ex = "2" },
+        .{ .src = "document.URL", .ex = "about:blank#bar" },
+        .{ .src = "history.state.bar", .ex = "baz" },
+
+        .{ .src = "history.pushState(null, '')", .ex = "undefined" },
+        .{ .src = "history.length", .ex = "3" },
+        .{ .src = "document.URL", .ex = "about:blank#bar" },
+        .{ .src = "history.state", .ex = "null" },
+
+        .{ .src = "var err; try { history.pushState(null, '', 'http://lightpanda.io/') } catch (e) { err = e } err.name", .ex = "SecurityError" },
+        .{ .src = "history.length", .ex = "3" },
+
+        .{ .src = "try { history.pushState({b: 1n}, '') } catch (e) { err = e } err.name", .ex = "DataCloneError" },
+        .{ .src = "err instanceof DOMException", .ex = "true" },
+        .{ .src = "err.code", .ex = "25" },
+        .{ .src = "err = null; try { history.replaceState(1n, '') } catch (e) { err = e } err.name", .ex = "DataCloneError" },
+        .{ .src = "history.length", .ex = "3" },
+    };
+    try checkCases(js_env, &history);
+
+    var popstate = [_]Case{
+        .{ .src = "let nbpop = 0; let popped;", .ex = "undefined" },
+        .{ .src = "window.addEventListener('popstate', (e) => { nbpop++; popped = e.state; })", .ex = "undefined" },
+
+        .{ .src = "history.back()", .ex = "undefined" },
+        .{ .src = "document.URL", .ex = "about:blank#bar" },
+        .{ .src = "nbpop", .ex = "1" },
+        .{ .src = "popped.bar", .ex = "baz" },
+
+        .{ .src = "history.go(-1)", .ex = "undefined" },
+        .{ .src = "document.URL", .ex = "about:blank" },
+        .{ .src = "nbpop", .ex = "2" },
+        .{ .src = "popped", .ex = "null" },
+        .{ .src = "history.state", .ex = "null" },
+
+        .{ .src = "history.back()", .ex = "undefined" },
+        .{ .src = "history.go(10)", .ex = "undefined" },
+        .{ .src = "nbpop", .ex = "2" },
+
+        .{ .src = "history.forward()", .ex = "undefined" },
+        .{ .src = "document.URL", .ex = "about:blank#bar" },
+        .{ .src = "nbpop", .ex = "3" },
+
+        // pushing a state removes the following entries.
+        .{ .src = "history.pushState(null, '', '#baz')", .ex = "undefined" },
+        .{ .src = "history.length", .ex = "3" },
+        .{ .src = "document.URL", .ex = "about:blank#baz" },
+    };
+    try checkCases(js_env, &popstate);
+}
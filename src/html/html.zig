@@ -21,6 +21,10 @@ const generate = @import("../generate.zig");
 const HTMLDocument = @import("document.zig").HTMLDocument;
 const HTMLElem = @import("elements.zig");
 const Window = @import("window.zig").Window;
+const Navigator = @import("navigator.zig").Navigator;
+const History = @import("history.zig").History;
+const Performance = @import("performance.zig");
+const Selection = @import("selection.zig").Selection;
 
 pub const Interfaces = generate.Tuple(.{
     HTMLDocument,
@@ -28,4 +32,8 @@ pub const Interfaces = generate.Tuple(.{
     HTMLElem.HTMLMediaElement,
     HTMLElem.Interfaces,
     Window,
+    Navigator,
+    History,
+    Performance.Interfaces,
+    Selection,
 });
@@ -0,0 +1,338 @@
+// Copyright (C) 2023-2024  Lightpanda (Selecy SAS)
+//
+// Francis Bouvier <francis@lightpanda.io>
+// Pierre Tachoire <pierre@lightpanda.io>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+const std = @import("std");
+const builtin = @import("builtin");
+
+const jsruntime = @import("jsruntime");
+const Loop = jsruntime.Loop;
+const Case = jsruntime.test_utils.Case;
+const checkCases = jsruntime.test_utils.checkCases;
+
+const parser = @import("netsurf");
+
+const DOMException = @import("../dom/exceptions.zig").DOMException;
+
+const Client = @import("../async/Client.zig");
+const strparser = @import("../str/parser.zig");
+const resolveURL = @import("../url/url.zig").resolve;
+const UserContext = @import("../user_context.zig").UserContext;
+
+const user_agent = @import("../browser/loader.zig").user_agent;
+
+const log = std.log.scoped(.navigator);
+
+// https://html.spec.whatwg.org/multipage/system-state.html#the-navigator-object
+//
+// The values are given by the embedder with the browser's options. They
+// default to plausible values for a desktop browser.
+// The array properties, languages and userAgentData, are implemented by the
+// polyfill from these values.
+pub const Navigator = struct {
+    pub const mem_guarantied = true;
+    pub const Exception = DOMException;
+
+    agent: []const u8 = user_agent,
+    language: []const u8 = "en-US",
+    platform: []const u8 = switch (builtin.os.tag) {
+        .macos => "MacIntel",
+        .windows => "Win32",
+        else => "Linux " ++ switch (builtin.cpu.arch) {
+            .x86 => "i686",
+            else => |arch| @tagName(arch),
+        },
+    },
+    vendor: []const u8 = "",
+    hardware_concurrency: u32 = 4,
+    device_memory: f64 = 8,
+    max_touch_points: u32 = 0,
+    cookie_enabled: bool = true,
+    webdriver: bool = false,
+
+    pub fn get_userAgent(self: *Navigator) []const u8 {
+        return self.agent;
+    }
+
+    pub fn get_appName(_: *Navigator) []const u8 {
+        return "Netscape";
+    }
+
+    pub fn get_product(_: *Navigator) []const u8 {
+        return "Gecko";
+    }
+
+    pub fn get_language(self: *Navigator) []const u8 {
+        return self.language;
+    }
+
+    pub fn get_platform(self: *Navigator) []const u8 {
+        return self.platform;
+    }
+
+    pub fn get_vendor(self: *Navigator) []const u8 {
+        return self.vendor;
+    }
+
+    // https://html.spec.whatwg.org/multipage/workers.html#dom-navigator-hardwareconcurrency
+    pub fn get_hardwareConcurrency(self: *Navigator) u32 {
+        return self.hardware_concurrency;
+    }
+
+    // https://w3c.github.io/device-memory/#sec-device-memory-js-api
+    pub fn get_deviceMemory(self: *Navigator) f64 {
+        return self.device_memory;
+    }
+
+    // https://w3c.github.io/pointerevents/#dom-navigator-maxtouchpoints
+    pub fn get_maxTouchPoints(self: *Navigator) u32 {
+        return self.max_touch_points;
+    }
+
+    pub fn get_cookieEnabled(self: *Navigator) bool {
+        return self.cookie_enabled;
+    }
+
+    pub fn get_onLine(_: *Navigator) bool {
+        return true;
+    }
+
+    // https://w3c.github.io/webdriver/#dom-navigatorautomationinformation-webdriver
+    pub fn get_webdriver(self: *Navigator) bool {
+        return self.webdriver;
+    }
+
+    // https://w3c.github.io/beacon/#sendbeacon-method
+    //
+    // The polyfill extracts the data, a string, a Blob, a FormData, an
+    // URLSearchParams or a buffer, into bytes encoded with one code point per
+    // byte, and gives its content type.
+    pub fn _sendBeacon(
+        _: *Navigator,
+        loop: *Loop,
+        userctx: UserContext,
+        url: []const u8,
+        data: ?[]const u8,
+        content_type: ?[]const u8,
+    ) !bool {
+        // the beacon is allocated with the session's allocator b/c it can
+        // outlive the page.
+        const alloc = userctx.httpClient.allocator;
+
+        const base = try parser.documentGetDocumentURI(parser.documentHTMLToDocument(userctx.document));
+        const beacon = try Beacon.init(alloc, loop, userctx.httpClient, base, url, data, content_type);
+
+        // If the amount of data exceeds the quota, return false.
+        if (beacon.payload) |p| {
+            if (p.len > Beacon.quota) {
+                beacon.deinit();
+                return false;
+            }
+        }
+
+        log.debug("beacon {s}", .{url});
+
+        beacon.impl.yield(beacon);
+        return true;
+    }
+};
+
+// Beacon sends a POST request in background.
+// The response is ignored and the beacon is freed once done.
+const Beacon = struct {
+    const YieldImpl = Loop.Yield(Beacon);
+
+    // quota is the maximum beacon's payload size.
+    const quota = 64 * 1024;
+
+    const State = enum { open, send, write, finish, wait };
+
+    alloc: std.mem.Allocator,
+    cli: *Client,
+    impl: YieldImpl,
+
+    url: []const u8,
+    uri: std.Uri,
+    payload: ?[]const u8,
+    content_type: ?[]const u8,
+    headers: [1]std.http.Header = undefined,
+
+    state: State = .open,
+    req: ?Client.Request = null,
+    // used by zig client to parse response headers.
+    response_header_buffer: [1024 * 16]u8 = undefined,
+
+    fn init(
+        alloc: std.mem.Allocator,
+        loop: *Loop,
+        cli: *Client,
+        base: []const u8,
+        url: []const u8,
+        data: ?[]const u8,
+        content_type: ?[]const u8,
+    ) !*Beacon {
+        const self = try alloc.create(Beacon);
+        errdefer alloc.destroy(self);
+
+        // Set parsedUrl to the result of the URL parser steps with url and
+        // base. If the algorithm returns an error, or if parsedUrl's scheme
+        // is not "http" or "https", throw a "TypeError" exception.
+        // The polyfill checks the scheme before.
+        const u = resolveURL(alloc, base, url) catch |e| switch (e) {
+            error.OutOfMemory => return e,
+            else => return error.TypeError,
+        };
+        errdefer alloc.free(u);
+
+        const uri = std.Uri.parse(u) catch return error.TypeError;
+        if (!std.ascii.eqlIgnoreCase(uri.scheme, "http") and !std.ascii.eqlIgnoreCase(uri.scheme, "https")) {
+            return error.TypeError;
+        }
+
+        var payload: ?[]const u8 = null;
+        if (data) |d| {
+            payload = try strparser.binaryToBytes(alloc, d);
+        }
+        errdefer if (payload) |p| alloc.free(p);
+
+        const ct: ?[]const u8 = if (content_type) |c| try alloc.dupe(u8, c) else null;
+        errdefer if (ct) |c| alloc.free(c);
+
+        self.* = .{
+            .alloc = alloc,
+            .cli = cli,
+            .impl = YieldImpl.init(loop),
+            .url = u,
+            .uri = uri,
+            .payload = payload,
+            .content_type = ct,
+        };
+
+        return self;
+    }
+
+    fn deinit(self: *Beacon) void {
+        if (self.req) |*r| r.deinit();
+        if (self.payload) |v| self.alloc.free(v);
+        if (self.content_type) |v| self.alloc.free(v);
+        self.alloc.free(self.url);
+        self.alloc.destroy(self);
+    }
+
+    fn extraHeaders(self: *Beacon) []const std.http.Header {
+        // no content type without payload.
+        if (self.payload == null) return &.{};
+        const ct = self.content_type orelse return &.{};
+        self.headers = .{.{ .name = "Content-Type", .value = ct }};
+        return &self.headers;
+    }
+
+    pub fn onYield(self: *Beacon, err: ?anyerror) void {
+        if (err) |e| return self.onErr(e);
+
+        switch (self.state) {
+            .open => {
+                self.state = .send;
+                self.req = self.cli.open(.POST, self.uri, .{
+                    .server_header_buffer = &self.response_header_buffer,
+                    .extra_headers = self.extraHeaders(),
+                }) catch |e| return self.onErr(e);
+            },
+            .send => {
+                const ln = if (self.payload) |v| v.len else 0;
+                self.req.?.transfer_encoding = .{ .content_length = ln };
+
+                self.state = .write;
+                self.req.?.send() catch |e| return self.onErr(e);
+            },
+            .write => {
+                self.state = .finish;
+                if (self.payload) |v| {
+                    self.req.?.writeAll(v) catch |e| return self.onErr(e);
+                }
+            },
+            .finish => {
+                self.state = .wait;
+                self.req.?.finish() catch |e| return self.onErr(e);
+            },
+            .wait => {
+                self.req.?.wait() catch |e| return self.onErr(e);
+                log.info("beacon {s} {d}", .{ self.url, self.req.?.response.status });
+
+                // the response is ignored.
+                return self.deinit();
+            },
+        }
+
+        self.impl.yield(self);
+    }
+
+    fn onErr(self: *Beacon, err: anyerror) void {
+        log.debug("beacon {s} {any}", .{ self.url, err });
+        self.deinit();
+    }
+};
+
+// Tests
+// -----
+
+pub fn testExecFn(
+    _: std.mem.Allocator,
+    js_env: *jsruntime.Env,
+) anyerror!void {
+    var navigator = [_]Case{
+        .{ .src = "navigator.userAgent", .ex = "Lightpanda.io/1.0" },
+        .{ .src = "window.navigator === navigator", .ex = "true" },
+    };
+    try checkCases(js_env, &navigator);
+
+    var properties = [_]Case{
+        .{ .src = "navigator.language", .ex = "en-US" },
+        .{ .src = "navigator.platform", .ex = (Navigator{}).platform },
+        .{ .src = "navigator.hardwareConcurrency", .ex = "4" },
+        .{ .src = "navigator.deviceMemory", .ex = "8" },
+        .{ .src = "navigator.maxTouchPoints", .ex = "0" },
+        .{ .src = "navigator.vendor", .ex = "" },
+        .{ .src = "navigator.cookieEnabled", .ex = "true" },
+        .{ .src = "navigator.webdriver", .ex = "false" },
+        .{ .src = "navigator.onLine", .ex = "true" },
+
+        .{ .src = "Array.isArray(navigator.languages)", .ex = "true" },
+        .{ .src = "navigator.languages.join(',')", .ex = "en-US,en" },
+        .{ .src = "navigator.languages === navigator.languages", .ex = "true" },
+        .{ .src = "Object.isFrozen(navigator.languages)", .ex = "true" },
+
+        .{ .src = "navigator.userAgentData.mobile", .ex = "false" },
+        .{ .src = "navigator.userAgentData.brands[0].brand", .ex = "Lightpanda" },
+        .{ .src = "navigator.userAgentData.brands[0].version", .ex = "1" },
+        .{ .src = "navigator.userAgentData.platform.length > 0", .ex = "true" },
+        .{ .src = "navigator.userAgentData.toJSON().mobile", .ex = "false" },
+    };
+    try checkCases(js_env, &properties);
+
+    var beacon = [_]Case{
+        .{ .src = "navigator.sendBeacon('http://httpbin.io/post', 'foo=bar')", .ex = "true" },
+        .{ .src = "navigator.sendBeacon('http://httpbin.io/post')", .ex = "true" },
+        .{ .src = "navigator.sendBeacon('http://httpbin.io/post', 'a'.repeat(65 * 1024))", .ex = "false" },
+        .{ .src = "var err; try { navigator.sendBeacon('ftp://httpbin.io/post') } catch (e) { err = e } err instanceof TypeError", .ex = "true" },
+        .{ .src = "navigator.sendBeacon('http://httpbin.io/post', new Blob(['foo'], { type: 'application/json' }))", .ex = "true" },
+        .{ .src = "navigator.sendBeacon('http://httpbin.io/post', new URLSearchParams('foo=bar'))", .ex = "true" },
+        .{ .src = "navigator.sendBeacon('http://httpbin.io/post', new Uint8Array([0, 255]).buffer)", .ex = "true" },
+        .{ .src = "navigator.sendBeacon('http://httpbin.io/post', new Blob(['a'.repeat(65 * 1024)]))", .ex = "false" },
+    };
+    try checkCases(js_env, &beacon);
+}
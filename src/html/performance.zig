@@ -0,0 +1,143 @@
+// Copyright (C) 2023-2024  Lightpanda (Selecy SAS)
+//
+// Francis Bouvier <francis@lightpanda.io>
+// Pierre Tachoire <pierre@lightpanda.io>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+const std = @import("std");
+
+const jsruntime = @import("jsruntime");
+const Case = jsruntime.test_utils.Case;
+const checkCases = jsruntime.test_utils.checkCases;
+
+const generate = @import("../generate.zig");
+
+pub const Interfaces = generate.Tuple(.{
+    Performance,
+    PerformanceTiming,
+});
+
+// https://w3c.github.io/hr-time/#sec-performance
+pub const Performance = struct {
+    pub const mem_guarantied = true;
+
+    // time_origin is the wall clock time of the navigation start in
+    // milliseconds.
+    time_origin: f64 = 0,
+    // start is the monotonic time of the navigation start. The wall clock can
+    // change, so the elapsed time is always computed from start.
+    start: ?std.time.Instant = null,
+
+    timing: PerformanceTiming = .{},
+
+    pub fn init() Performance {
+        const origin = std.time.nanoTimestamp();
+        return .{
+            .time_origin = @as(f64, @floatFromInt(origin)) / std.time.ns_per_ms,
+            .start = std.time.Instant.now() catch null,
+            .timing = .{
+                .navigationStart = @intCast(@divFloor(origin, std.time.ns_per_ms)),
+            },
+        };
+    }
+
+    // reset sets the time origin at the navigation start.
+    pub fn reset(self: *Performance) void {
+        self.* = init();
+    }
+
+    pub fn get_timeOrigin(self: *Performance) f64 {
+        return self.time_origin;
+    }
+
+    pub fn get_timing(self: *Performance) *PerformanceTiming {
+        return &self.timing;
+    }
+
+    // https://w3c.github.io/hr-time/#dom-performance-now
+    pub fn _now(self: *Performance) f64 {
+        const start = self.start orelse return 0;
+        const now = std.time.Instant.now() catch return 0;
+        return @as(f64, @floatFromInt(now.since(start))) / std.time.ns_per_ms;
+    }
+};
+
+// https://www.w3.org/TR/navigation-timing/#sec-navigation-timing-interface
+// The values are wall clock times in milliseconds, 0 until the event occurs.
+// TODO add the remaining attributes.
+pub const PerformanceTiming = struct {
+    pub const mem_guarantied = true;
+
+    navigationStart: u64 = 0,
+    responseStart: u64 = 0,
+    domContentLoadedEventStart: u64 = 0,
+    domContentLoadedEventEnd: u64 = 0,
+    loadEventStart: u64 = 0,
+    loadEventEnd: u64 = 0,
+
+    // mark sets the field to the current time.
+    pub fn mark(self: *PerformanceTiming, comptime field: []const u8) void {
+        @field(self, field) = @intCast(std.time.milliTimestamp());
+    }
+
+    pub fn get_navigationStart(self: *PerformanceTiming) u64 {
+        return self.navigationStart;
+    }
+
+    pub fn get_responseStart(self: *PerformanceTiming) u64 {
+        return self.responseStart;
+    }
+
+    pub fn get_domContentLoadedEventStart(self: *PerformanceTiming) u64 {
+        return self.domContentLoadedEventStart;
+    }
+
+    pub fn get_domContentLoadedEventEnd(self: *PerformanceTiming) u64 {
+        return self.domContentLoadedEventEnd;
+    }
+
+    pub fn get_loadEventStart(self: *PerformanceTiming) u64 {
+        return self.loadEventStart;
+    }
+
+    pub fn get_loadEventEnd(self: *PerformanceTiming) u64 {
+        return self.loadEventEnd;
+    }
+};
+
+// Tests
+// -----
+
+pub fn testExecFn(
+    _: std.mem.Allocator,
+    js_env: *jsruntime.Env,
+) anyerror!void {
+    var performance = [_]Case{
+        .{ .src = "window.performance === performance", .ex = "true" },
+        .{ .src = "typeof performance.now()", .ex = "number" },
+        .{ .src = "let pn = performance.now()", .ex = "undefined" },
+        .{ .src = "pn >= 0", .ex = "true" },
+        .{ .src = "performance.now() >= pn", .ex = "true" },
+        .{ .src = "performance.timeOrigin > 0", .ex = "true" },
+    };
+    try checkCases(js_env, &performance);
+
+    var timing = [_]Case{
+        .{ .src = "performance.timing.navigationStart > 0", .ex = "true" },
+        .{ .src = "Math.abs(performance.timeOrigin - performance.timing.navigationStart) < 1", .ex = "true" },
+        .{ .src = "performance.timing.loadEventEnd", .ex = "0" },
+    };
+    try checkCases(js_env, &timing);
+}
@@ -0,0 +1,332 @@
+// Copyright (C) 2023-2024  Lightpanda (Selecy SAS)
+//
+// Francis Bouvier <francis@lightpanda.io>
+// Pierre Tachoire <pierre@lightpanda.io>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+const std = @import("std");
+
+const jsruntime = @import("jsruntime");
+const Case = jsruntime.test_utils.Case;
+const checkCases = jsruntime.test_utils.checkCases;
+
+const parser = @import("netsurf");
+
+const Node = @import("../dom/node.zig").Node;
+const NodeUnion = @import("../dom/node.zig").Union;
+const range = @import("../dom/range.zig");
+const Range = range.Range;
+const DOMException = @import("../dom/exceptions.zig").DOMException;
+
+const UserContext = @import("../user_context.zig").UserContext;
+
+// https://w3c.github.io/selection-api/#selection-interface
+//
+// The selection contains at most one range, like the browsers do.
+// There is no user interaction, so the selection only changes
+// programmatically.
+//
+// TODO the range is not updated on DOM mutations.
+pub const Selection = struct {
+    pub const mem_guarantied = true;
+    pub const Exception = DOMException;
+
+    const Direction = enum { forwards, backwards, directionless };
+
+    range: ?*Range = null,
+    direction: Direction = .directionless,
+
+    pub fn reset(self: *Selection) void {
+        self.range = null;
+        self.direction = .directionless;
+    }
+
+    // https://w3c.github.io/selection-api/#dom-selection-anchornode
+    pub fn get_anchorNode(self: *Selection) !?NodeUnion {
+        const r = self.range orelse return null;
+        return try Node.toInterface(self.anchor(r).node);
+    }
+
+    pub fn get_anchorOffset(self: *Selection) u32 {
+        const r = self.range orelse return 0;
+        return self.anchor(r).offset;
+    }
+
+    pub fn get_focusNode(self: *Selection) !?NodeUnion {
+        const r = self.range orelse return null;
+        return try Node.toInterface(self.focus(r).node);
+    }
+
+    pub fn get_focusOffset(self: *Selection) u32 {
+        const r = self.range orelse return 0;
+        return self.focus(r).offset;
+    }
+
+    pub fn get_isCollapsed(self: *Selection) bool {
+        const r = self.range orelse return true;
+        return r.get_collapsed();
+    }
+
+    pub fn get_rangeCount(self: *Selection) u32 {
+        return if (self.range == null) 0 else 1;
+    }
+
+    // https://w3c.github.io/selection-api/#dom-selection-type
+    pub fn get_type(self: *Selection) []const u8 {
+        const r = self.range orelse return "None";
+        return if (r.get_collapsed()) "Caret" else "Range";
+    }
+
+    pub fn _getRangeAt(self: *Selection, idx: u32) !*Range {
+        if (idx != 0) return parser.DOMError.IndexSize;
+        return self.range orelse parser.DOMError.IndexSize;
+    }
+
+    // https://w3c.github.io/selection-api/#dom-selection-addrange
+    pub fn _addRange(self: *Selection, userctx: UserContext, r: *Range) !void {
+        if (!try isInDocument(userctx, r.start_container)) return;
+        // the selection already has a range.
+        if (self.range != null) return;
+
+        self.range = r;
+        self.direction = .forwards;
+    }
+
+    // https://w3c.github.io/selection-api/#dom-selection-removerange
+    pub fn _removeRange(self: *Selection, r: *Range) !void {
+        if (self.range != r) return parser.DOMError.NotFound;
+        self.reset();
+    }
+
+    pub fn _removeAllRanges(self: *Selection) void {
+        self.reset();
+    }
+
+    pub fn _empty(self: *Selection) void {
+        self.reset();
+    }
+
+    // https://w3c.github.io/selection-api/#dom-selection-collapse
+    pub fn _collapse(
+        self: *Selection,
+        alloc: std.mem.Allocator,
+        userctx: UserContext,
+        node: ?*parser.Node,
+        offset: ?u32,
+    ) !void {
+        const n = node orelse return self.reset();
+
+        var r = Range.init(n);
+        try r._setStart(n, offset orelse 0);
+        r._collapse(true);
+
+        if (!try isInDocument(userctx, n)) return;
+        try self.set(alloc, r, .directionless);
+    }
+
+    pub fn _setPosition(
+        self: *Selection,
+        alloc: std.mem.Allocator,
+        userctx: UserContext,
+        node: ?*parser.Node,
+        offset: ?u32,
+    ) !void {
+        return self._collapse(alloc, userctx, node, offset);
+    }
+
+    // https://w3c.github.io/selection-api/#dom-selection-collapsetostart
+    pub fn _collapseToStart(self: *Selection, alloc: std.mem.Allocator) !void {
+        const r = self.range orelse return parser.DOMError.InvalidState;
+        var n = r.*;
+        n._collapse(true);
+        try self.set(alloc, n, .directionless);
+    }
+
+    pub fn _collapseToEnd(self: *Selection, alloc: std.mem.Allocator) !void {
+        const r = self.range orelse return parser.DOMError.InvalidState;
+        var n = r.*;
+        n._collapse(false);
+        try self.set(alloc, n, .directionless);
+    }
+
+    // https://w3c.github.io/selection-api/#dom-selection-extend
+    pub fn _extend(
+        self: *Selection,
+        alloc: std.mem.Allocator,
+        userctx: UserContext,
+        node: *parser.Node,
+        offset: ?u32,
+    ) !void {
+        if (!try isInDocument(userctx, node)) return;
+        const r = self.range orelse return parser.DOMError.InvalidState;
+        const off = offset orelse 0;
+
+        // check the boundary point.
+        var n = Range.init(node);
+        try n._setStart(node, off);
+
+        const old = self.anchor(r);
+        if (try range.root(node) != try range.root(old.node)) {
+            n._collapse(true);
+            return try self.set(alloc, n, .forwards);
+        }
+
+        if (try range.compare(old.node, old.offset, node, off) != .gt) {
+            n = .{
+                .start_container = old.node,
+                .start_offset = old.offset,
+                .end_container = node,
+                .end_offset = off,
+            };
+            return try self.set(alloc, n, .forwards);
+        }
+
+        n = .{
+            .start_container = node,
+            .start_offset = off,
+            .end_container = old.node,
+            .end_offset = old.offset,
+        };
+        try self.set(alloc, n, .backwards);
+    }
+
+    // https://w3c.github.io/selection-api/#dom-selection-selectallchildren
+    pub fn _selectAllChildren(
+        self: *Selection,
+        alloc: std.mem.Allocator,
+        userctx: UserContext,
+        node: *parser.Node,
+    ) !void {
+        var r = Range.init(node);
+        try r._selectNodeContents(node);
+
+        if (!try isInDocument(userctx, node)) return;
+        try self.set(alloc, r, .forwards);
+    }
+
+    // https://w3c.github.io/selection-api/#dom-selection-deletefromdocument
+    pub fn _deleteFromDocument(self: *Selection, alloc: std.mem.Allocator) !void {
+        const r = self.range orelse return;
+        try r._deleteContents(alloc);
+    }
+
+    // https://w3c.github.io/selection-api/#dom-selection-containsnode
+    pub fn _containsNode(self: *Selection, node: *parser.Node, allow_partial: ?bool) !bool {
+        const r = self.range orelse return false;
+        var n = Range.init(node);
+        n._selectNode(node) catch return false;
+
+        if (try range.root(node) != try range.root(r.start_container)) return false;
+
+        const start = try range.compare(n.start_container, n.start_offset, r.start_container, r.start_offset);
+        const end = try range.compare(n.end_container, n.end_offset, r.end_container, r.end_offset);
+        if (start != .lt and end != .gt) return true;
+        if (!(allow_partial orelse false)) return false;
+
+        // the node's range intersects the selection's range.
+        return try range.compare(n.start_container, n.start_offset, r.end_container, r.end_offset) == .lt and
+            try range.compare(n.end_container, n.end_offset, r.start_container, r.start_offset) == .gt;
+    }
+
+    // https://w3c.github.io/selection-api/#dom-selection-stringifier
+    pub fn _toString(self: *Selection, alloc: std.mem.Allocator) ![]const u8 {
+        const r = self.range orelse return "";
+        return try r._toString(alloc);
+    }
+
+    const Point = struct { node: *parser.Node, offset: u32 };
+
+    fn anchor(self: *Selection, r: *Range) Point {
+        if (self.direction == .backwards) return .{ .node = r.end_container, .offset = r.end_offset };
+        return .{ .node = r.start_container, .offset = r.start_offset };
+    }
+
+    fn focus(self: *Selection, r: *Range) Point {
+        if (self.direction == .backwards) return .{ .node = r.start_container, .offset = r.start_offset };
+        return .{ .node = r.end_container, .offset = r.end_offset };
+    }
+
+    // set replaces the selection's range by a new one.
+    fn set(self: *Selection, alloc: std.mem.Allocator, r: Range, direction: Direction) !void {
+        const n = try alloc.create(Range);
+        n.* = r;
+        self.range = n;
+        self.direction = direction;
+    }
+
+    // isInDocument returns true if the node's root is the document associated
+    // to the selection.
+    fn isInDocument(userctx: UserContext, node: *parser.Node) !bool {
+        const doc = parser.documentHTMLToNode(userctx.document);
+        return try range.root(node) == doc;
+    }
+};
+
+// Tests
+// -----
+
+pub fn testExecFn(
+    _: std.mem.Allocator,
+    js_env: *jsruntime.Env,
+) anyerror!void {
+    var selection = [_]Case{
+        .{ .src = "let sel = window.getSelection()", .ex = "undefined" },
+        .{ .src = "sel === window.getSelection()", .ex = "true" },
+        .{ .src = "sel.rangeCount", .ex = "0" },
+        .{ .src = "sel.type", .ex = "None" },
+        .{ .src = "sel.anchorNode", .ex = "null" },
+        .{ .src = "sel.isCollapsed", .ex = "true" },
+        .{ .src = "sel.toString()", .ex = "" },
+        .{ .src = "var err; try { sel.getRangeAt(0) } catch (e) { err = e } err.name", .ex = "IndexSizeError" },
+
+        .{ .src = "let p = document.getElementById('para')", .ex = "undefined" },
+        .{ .src = "sel.selectAllChildren(p)", .ex = "undefined" },
+        .{ .src = "sel.rangeCount", .ex = "1" },
+        .{ .src = "sel.type", .ex = "Range" },
+        .{ .src = "sel.anchorNode === p", .ex = "true" },
+        .{ .src = "sel.focusNode === p", .ex = "true" },
+        .{ .src = "sel.toString()", .ex = " And" },
+        .{ .src = "sel.containsNode(p.firstChild)", .ex = "true" },
+
+        .{ .src = "sel.collapse(p.firstChild, 1)", .ex = "undefined" },
+        .{ .src = "sel.isCollapsed", .ex = "true" },
+        .{ .src = "sel.type", .ex = "Caret" },
+        .{ .src = "sel.extend(p.firstChild, 3)", .ex = "undefined" },
+        .{ .src = "sel.toString()", .ex = "An" },
+        .{ .src = "sel.anchorOffset", .ex = "1" },
+        .{ .src = "sel.focusOffset", .ex = "3" },
+        .{ .src = "sel.extend(p.firstChild, 0)", .ex = "undefined" },
+        .{ .src = "sel.toString()", .ex = " " },
+        .{ .src = "sel.anchorOffset", .ex = "1" },
+        .{ .src = "sel.focusOffset", .ex = "0" },
+        .{ .src = "sel.getRangeAt(0).startOffset", .ex = "0" },
+
+        .{ .src = "sel.removeAllRanges()", .ex = "undefined" },
+        .{ .src = "sel.rangeCount", .ex = "0" },
+        .{ .src = "try { sel.extend(p, 0) } catch (e) { err = e } err.name", .ex = "InvalidStateError" },
+
+        .{ .src = "let r = document.createRange()", .ex = "undefined" },
+        .{ .src = "r.selectNodeContents(p)", .ex = "undefined" },
+        .{ .src = "sel.addRange(r)", .ex = "undefined" },
+        .{ .src = "sel.getRangeAt(0) === r", .ex = "true" },
+        .{ .src = "String(sel)", .ex = " And" },
+        .{ .src = "sel.removeRange(r)", .ex = "undefined" },
+        .{ .src = "sel.rangeCount", .ex = "0" },
+
+        // nodes outside the document are ignored.
+        .{ .src = "sel.selectAllChildren(document.createElement('div'))", .ex = "undefined" },
+        .{ .src = "sel.rangeCount", .ex = "0" },
+    };
+    try checkCases(js_env, &selection);
+}
@@ -0,0 +1,124 @@
+// Copyright (C) 2023-2024  Lightpanda (Selecy SAS)
+//
+// Francis Bouvier <francis@lightpanda.io>
+// Pierre Tachoire <pierre@lightpanda.io>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+const std = @import("std");
+
+const jsruntime = @import("jsruntime");
+const Callback = jsruntime.Callback;
+const Loop = jsruntime.Loop;
+const Case = jsruntime.test_utils.Case;
+const checkCases = jsruntime.test_utils.checkCases;
+
+const log = std.log.scoped(.timer);
+
+// Timers holds the window's pending timeouts, each one scheduled with the
+// loop's timeout, canceled on clear and forgotten once run.
+// The intervals, re-scheduled after each call, and the ids shared by the
+// timeouts and the intervals are handled by the polyfill.
+// https://html.spec.whatwg.org/multipage/timers-and-user-prompts.html#timers
+pub const Timers = struct {
+    // alloc is the session's allocator, the timers can outlive the page's
+    // env until they are canceled.
+    alloc: ?std.mem.Allocator = null,
+    // 0 is never used as an id, scripts often use it as "no timer".
+    last_id: u32 = 0,
+    // list maps the pending timers' ids to the loop's timeout ids. The loop
+    // releases a timeout once run, so its id must not be canceled after.
+    list: std.AutoHashMapUnmanaged(u32, usize) = .{},
+
+    // set schedules the callback after the delay in milliseconds.
+    pub fn set(
+        self: *Timers,
+        alloc: std.mem.Allocator,
+        loop: *Loop,
+        cbk: Callback,
+        delay: ?u32,
+    ) !u32 {
+        self.alloc = alloc;
+
+        const ns = @as(u63, delay orelse 0) * std.time.ns_per_ms;
+        const tid = try loop.timeout(ns, cbk);
+        errdefer loop.cancel(tid, null) catch {};
+
+        self.last_id += 1;
+        try self.list.put(alloc, self.last_id, tid);
+
+        return self.last_id;
+    }
+
+    // clear cancels the timer. Unknown ids are ignored.
+    pub fn clear(self: *Timers, loop: *Loop, id: u32) !void {
+        const kv = self.list.fetchRemove(id) orelse return;
+        try loop.cancel(kv.value, null);
+    }
+
+    // done removes the timer run by the loop. Unknown ids are ignored.
+    pub fn done(self: *Timers, id: u32) void {
+        _ = self.list.remove(id);
+    }
+
+    // reset cancels all the pending timers, the env running the callbacks is
+    // gone.
+    pub fn reset(self: *Timers, loop: *Loop) void {
+        var it = self.list.valueIterator();
+        while (it.next()) |tid| {
+            loop.cancel(tid.*, null) catch |e| log.err("cancel timer: {any}", .{e});
+        }
+        if (self.alloc) |alloc| self.list.clearAndFree(alloc);
+    }
+};
+
+// Tests
+// -----
+
+pub fn testExecFn(
+    _: std.mem.Allocator,
+    js_env: *jsruntime.Env,
+) anyerror!void {
+    var timeout = [_]Case{
+        .{ .src = "let tnb = 0", .ex = "undefined" },
+        .{ .src = "let tid = setTimeout(() => { tnb++ }, 1); tid > 0", .ex = "true" },
+        .{ .src = "tnb", .ex = "1" },
+        .{ .src = "let tid2 = setTimeout(() => { tnb++ }, 1); tid2 !== tid", .ex = "true" },
+        .{ .src = "clearTimeout(tid2)", .ex = "undefined" },
+        .{ .src = "tnb", .ex = "1" },
+        // unknown ids are ignored.
+        .{ .src = "clearTimeout(0); clearTimeout(tid)", .ex = "undefined" },
+
+        .{ .src = "let targs; setTimeout((a, b) => { targs = a + b }, 0, 'foo', 'bar'); true", .ex = "true" },
+        .{ .src = "targs", .ex = "foobar" },
+        .{ .src = "let torder = []; setTimeout(() => torder.push(2), 20); setTimeout(() => torder.push(1), 1); true", .ex = "true" },
+        .{ .src = "torder.join(',')", .ex = "1,2" },
+    };
+    try checkCases(js_env, &timeout);
+
+    var interval = [_]Case{
+        .{ .src = "let inb = 0", .ex = "undefined" },
+        .{ .src = "let iid = setInterval((inc) => { inb += inc; if (inb === 3) clearInterval(iid) }, 1, 1); true", .ex = "true" },
+        .{ .src = "inb", .ex = "3" },
+
+        // the ids are shared.
+        .{ .src = "let inb2 = 0", .ex = "undefined" },
+        .{ .src = "let iid2 = setInterval(() => { inb2++ }, 1); clearTimeout(iid2)", .ex = "undefined" },
+        .{ .src = "let tid3 = setTimeout(() => { inb2++ }, 1); clearInterval(tid3)", .ex = "undefined" },
+        .{ .src = "inb2", .ex = "0" },
+
+        // the native notification is hidden from the scripts.
+        .{ .src = "typeof timeoutDone", .ex = "undefined" },
+    };
+    try checkCases(js_env, &interval);
+}
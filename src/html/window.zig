@@ -18,11 +18,31 @@
 
 const std = @import("std");
 
+const jsruntime = @import("jsruntime");
+const Case = jsruntime.test_utils.Case;
+const checkCases = jsruntime.test_utils.checkCases;
+const Callback = jsruntime.Callback;
+const Loop = jsruntime.Loop;
+
 const parser = @import("netsurf");
 
 const EventTarget = @import("../dom/event_target.zig").EventTarget;
+const DOMException = @import("../dom/exceptions.zig").DOMException;
 
 const storage = @import("../storage/storage.zig");
+const strparser = @import("../str/parser.zig");
+
+const Navigator = @import("navigator.zig").Navigator;
+const History = @import("history.zig").History;
+const Performance = @import("performance.zig").Performance;
+const Selection = @import("selection.zig").Selection;
+const Timers = @import("timer.zig").Timers;
+
+const UserContext = @import("../user_context.zig").UserContext;
+const Crypto = @import("../crypto/crypto.zig").Crypto;
+
+const CSSStyleDeclaration = @import("../cssom/css_style_declaration.zig").CSSStyleDeclaration;
+const StyleSheets = @import("../cssom/css_style_declaration.zig").StyleSheets;
 
 // https://dom.spec.whatwg.org/#interface-window-extensions
 // https://html.spec.whatwg.org/multipage/nav-history-apis.html#window
@@ -30,6 +50,7 @@ pub const Window = struct {
     pub const prototype = *EventTarget;
     pub const mem_guarantied = true;
     pub const global_type = true;
+    pub const Exception = DOMException;
 
     // Extend libdom event target for pure zig struct.
     base: parser.EventTargetTBase = parser.EventTargetTBase{},
@@ -39,17 +60,36 @@ pub const Window = struct {
 
     storageShelf: ?*storage.Shelf = null,
 
+    navigator: Navigator = .{},
+    history: History = .{},
+    performance: Performance = .{},
+    crypto: Crypto = .{},
+    selection: Selection = .{},
+    style_sheets: StyleSheets = .{},
+    timers: Timers = .{},
+
     pub fn create(target: ?[]const u8) Window {
         return Window{
             .target = target orelse "",
+            .performance = Performance.init(),
         };
     }
 
     pub fn replaceDocument(self: *Window, doc: *parser.DocumentHTML) void {
         self.document = doc;
+        self.selection.reset();
+        self.history.reset();
+        self.style_sheets.reset();
+    }
+
+    // releaseDocument frees the document's caches and history allocated with
+    // the page's memory, before the page ends.
+    pub fn releaseDocument(self: *Window) void {
+        self.history.reset();
+        self.style_sheets.reset();
     }
 
-    pub fn setStorageShelf(self: *Window, shelf: *storage.Shelf) void {
+    pub fn setStorageShelf(self: *Window, shelf: ?*storage.Shelf) void {
         self.storageShelf = shelf;
     }
 
@@ -65,6 +105,26 @@ pub const Window = struct {
         return self;
     }
 
+    pub fn get_navigator(self: *Window) *Navigator {
+        return &self.navigator;
+    }
+
+    pub fn get_history(self: *Window) *History {
+        return &self.history;
+    }
+
+    pub fn get_performance(self: *Window) *Performance {
+        return &self.performance;
+    }
+
+    pub fn get_crypto(self: *Window) *Crypto {
+        return &self.crypto;
+    }
+
+    pub fn _getSelection(self: *Window) *Selection {
+        return &self.selection;
+    }
+
     pub fn get_document(self: *Window) ?*parser.DocumentHTML {
         return self.document;
     }
@@ -82,4 +142,148 @@ pub const Window = struct {
         if (self.storageShelf == null) return parser.DOMError.NotSupported;
         return &self.storageShelf.?.bucket.session;
     }
+
+    // The extra arguments, the string handlers and the intervals are
+    // supported by the polyfill.
+    // https://html.spec.whatwg.org/multipage/timers-and-user-prompts.html#dom-settimeout
+    pub fn _setTimeout(
+        self: *Window,
+        loop: *Loop,
+        userctx: UserContext,
+        cbk: Callback,
+        delay: ?u32,
+    ) !u32 {
+        // the timers are allocated with the session's allocator b/c they can
+        // outlive the page.
+        return try self.timers.set(userctx.httpClient.allocator, loop, cbk, delay);
+    }
+
+    pub fn _clearTimeout(self: *Window, loop: *Loop, id: ?u32) !void {
+        try self.timers.clear(loop, id orelse return);
+    }
+
+    // timeoutDone forgets the timeout once run, its loop's timeout is gone.
+    // The polyfill calls it at the start of each callback and hides it.
+    pub fn _timeoutDone(self: *Window, id: ?u32) void {
+        self.timers.done(id orelse return);
+    }
+
+    // clearTimers cancels the pending timers when the page ends.
+    pub fn clearTimers(self: *Window, loop: *Loop) void {
+        self.timers.reset(loop);
+    }
+
+    // The JS strings are exchanged in UTF-8, so the binary strings are
+    // converted from and to their bytes, one byte per code point.
+    // https://html.spec.whatwg.org/multipage/webappapis.html#dom-btoa
+    pub fn _btoa(_: *Window, alloc: std.mem.Allocator, data: []const u8) ![]const u8 {
+        const bytes = try strparser.binaryToBytes(alloc, data);
+        defer alloc.free(bytes);
+
+        const enc = std.base64.standard.Encoder;
+        const out = try alloc.alloc(u8, enc.calcSize(bytes.len));
+        return enc.encode(out, bytes);
+    }
+
+    // https://html.spec.whatwg.org/multipage/webappapis.html#dom-atob
+    pub fn _atob(_: *Window, alloc: std.mem.Allocator, data: []const u8) ![]const u8 {
+        const bytes = try forgivingBase64Decode(alloc, data);
+        defer alloc.free(bytes);
+
+        return try strparser.bytesToBinary(alloc, bytes);
+    }
+
+    // https://drafts.csswg.org/cssom/#dom-window-getcomputedstyle
+    // TODO support pseudo elements.
+    pub fn _getComputedStyle(
+        self: *Window,
+        alloc: std.mem.Allocator,
+        elt: *parser.Element,
+        _: ?[]const u8,
+    ) !CSSStyleDeclaration {
+        return try CSSStyleDeclaration.computed(alloc, &self.style_sheets, elt);
+    }
 };
+
+// https://infra.spec.whatwg.org/#forgiving-base64-decode
+fn forgivingBase64Decode(alloc: std.mem.Allocator, data: []const u8) ![]u8 {
+    // remove the ASCII whitespaces.
+    var input = try std.ArrayListUnmanaged(u8).initCapacity(alloc, data.len);
+    defer input.deinit(alloc);
+    for (data) |c| {
+        switch (c) {
+            '\t', '\n', '\x0C', '\r', ' ' => continue,
+            else => input.appendAssumeCapacity(c),
+        }
+    }
+
+    var str = input.items;
+    if (str.len % 4 == 0) {
+        if (std.mem.endsWith(u8, str, "==")) {
+            str = str[0 .. str.len - 2];
+        } else if (std.mem.endsWith(u8, str, "=")) {
+            str = str[0 .. str.len - 1];
+        }
+    }
+    if (str.len % 4 == 1) return parser.DOMError.InvalidCharacter;
+
+    var out = try std.ArrayListUnmanaged(u8).initCapacity(alloc, str.len / 4 * 3 + 2);
+    errdefer out.deinit(alloc);
+
+    // the bits remaining after the last full byte are discarded.
+    var acc: u32 = 0;
+    var nbits: u5 = 0;
+    for (str) |c| {
+        const v: u32 = switch (c) {
+            'A'...'Z' => c - 'A',
+            'a'...'z' => c - 'a' + 26,
+            '0'...'9' => c - '0' + 52,
+            '+' => 62,
+            '/' => 63,
+            else => return parser.DOMError.InvalidCharacter,
+        };
+        acc = (acc << 6) | v;
+        nbits += 6;
+        if (nbits >= 8) {
+            nbits -= 8;
+            out.appendAssumeCapacity(@truncate(acc >> nbits));
+            acc &= (@as(u32, 1) << nbits) - 1;
+        }
+    }
+    return try out.toOwnedSlice(alloc);
+}
+
+// Tests
+// -----
+
+pub fn testExecFn(
+    _: std.mem.Allocator,
+    js_env: *jsruntime.Env,
+) anyerror!void {
+    var base64 = [_]Case{
+        .{ .src = "btoa('')", .ex = "" },
+        .{ .src = "btoa('foo')", .ex = "Zm9v" },
+        .{ .src = "btoa('fo')", .ex = "Zm8=" },
+        .{ .src = "btoa('f')", .ex = "Zg==" },
+        .{ .src = "btoa('\\xff\\xfe\\x00\\x80')", .ex = "//4AgA==" },
+        .{ .src = "let err = null; try { btoa('\\u0100') } catch (e) { err = e } err.name", .ex = "InvalidCharacterError" },
+
+        .{ .src = "atob('')", .ex = "" },
+        .{ .src = "atob('Zm9v')", .ex = "foo" },
+        .{ .src = "atob('Zm8=')", .ex = "fo" },
+        .{ .src = "atob('Zm8')", .ex = "fo" },
+        .{ .src = "atob('Zg')", .ex = "f" },
+        .{ .src = "atob('Zh')", .ex = "f" },
+        .{ .src = "atob(' Zm9v\\n YmFy\\t')", .ex = "foobar" },
+        .{ .src = "atob('//4AgA==').length", .ex = "4" },
+        .{ .src = "Array.from(atob('//4AgA=='), (c) => c.charCodeAt(0)).join(',')", .ex = "255,254,0,128" },
+        .{ .src = "err = null; try { atob('Zm9v!') } catch (e) { err = e } err.name", .ex = "InvalidCharacterError" },
+        .{ .src = "err = null; try { atob('Zm9vY') } catch (e) { err = e } err.name", .ex = "InvalidCharacterError" },
+        .{ .src = "err = null; try { atob('Zg=') } catch (e) { err = e } err.name", .ex = "InvalidCharacterError" },
+        .{ .src = "err = null; try { atob('Z===') } catch (e) { err = e } err.name", .ex = "InvalidCharacterError" },
+        .{ .src = "err = null; try { atob('\\u00e9') } catch (e) { err = e } err.name", .ex = "InvalidCharacterError" },
+
+        .{ .src = "let latin1 = ''; for (let i = 0; i < 256; i++) latin1 += String.fromCharCode(i); atob(btoa(latin1)) === latin1", .ex = "true" },
+    };
+    try checkCases(js_env, &base64);
+}
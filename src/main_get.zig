@@ -80,7 +80,7 @@ pub fn main() !void {
     const vm = jsruntime.VM.init();
     defer vm.deinit();
 
-    var browser = try Browser.init(allocator, vm);
+    var browser = try Browser.init(allocator, vm, .{});
     defer browser.deinit();
 
     var page = try browser.currentSession().createPage();
@@ -24,6 +24,7 @@ const parser = @import("netsurf");
 const apiweb = @import("apiweb.zig");
 const Window = @import("html/window.zig").Window;
 const storage = @import("storage/storage.zig");
+const polyfill = @import("polyfill/polyfill.zig");
 
 const html_test = @import("html_test.zig").html;
 
@@ -58,6 +59,9 @@ fn execJS(
     window.setStorageShelf(&storageShelf);
     try js_env.bindGlobal(window);
 
+    // load polyfills
+    try polyfill.load(alloc, js_env);
+
     // launch shellExec
     try jsruntime.shellExec(alloc, js_env);
 }
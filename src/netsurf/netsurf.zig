@@ -30,6 +30,8 @@ const mimalloc = @import("mimalloc");
 
 const Callback = @import("jsruntime").Callback;
 
+const log = std.log.scoped(.netsurf);
+
 // init initializes netsurf lib.
 // init starts a mimalloc heap arena for the netsurf session. The caller must
 // call deinit() to free the arena memory.
@@ -400,10 +402,15 @@ fn DOMErr(except: DOMException) DOMError!void {
 // Event
 pub const Event = c.dom_event;
 
+// eventCreate creates an untrusted event, like the ones created by the
+// scripts. The events dispatched by the browser itself must be marked as
+// trusted with eventSetTrusted.
 pub fn eventCreate() !*Event {
     var evt: ?*Event = undefined;
     const err = c._dom_event_create(&evt);
     try DOMErr(err);
+    // libdom doesn't initialize the trusted flag.
+    eventSetTrusted(evt.?, false);
     return evt.?;
 }
 
@@ -421,6 +428,7 @@ pub fn eventInit(evt: *Event, typ: []const u8, opts: EventInit) !void {
     const s = try strFromData(typ);
     const err = c._dom_event_init(evt, s, opts.bubbles, opts.cancelable);
     try DOMErr(err);
+    try eventSetComposed(evt, opts.composed);
 }
 
 pub fn eventType(evt: *Event) ![]const u8 {
@@ -483,6 +491,11 @@ pub fn eventIsTrusted(evt: *Event) !bool {
     return res;
 }
 
+// libdom has no setter for the trusted flag.
+pub fn eventSetTrusted(evt: *Event, trusted: bool) void {
+    evt.is_trusted = trusted;
+}
+
 pub fn eventTimestamp(evt: *Event) !u32 {
     var ts: c_uint = undefined;
     const err = c._dom_event_get_timestamp(evt, &ts);
@@ -505,21 +518,46 @@ pub fn eventPreventDefault(evt: *Event) !void {
     try DOMErr(err);
 }
 
-pub fn eventGetInternalType(evt: *Event) !EventType {
+// libdom's event has no composed flag, it's kept in the internal type, above
+// the EventType bits.
+const event_composed_flag: u32 = 1 << 8;
+
+fn eventInternalType(evt: *Event) !u32 {
     var res: u32 = undefined;
     const err = c._dom_event_get_internal_type(evt, &res);
     try DOMErr(err);
-    return @enumFromInt(res);
+    return res;
+}
+
+pub fn eventGetInternalType(evt: *Event) !EventType {
+    const res = try eventInternalType(evt);
+    return @enumFromInt(res & ~event_composed_flag);
 }
 
 pub fn eventSetInternalType(evt: *Event, internal_type: EventType) !void {
-    const err = c._dom_event_set_internal_type(evt, @intFromEnum(internal_type));
+    const flags = try eventInternalType(evt) & event_composed_flag;
+    const err = c._dom_event_set_internal_type(evt, @intFromEnum(internal_type) | flags);
+    try DOMErr(err);
+}
+
+pub fn eventComposed(evt: *Event) !bool {
+    return try eventInternalType(evt) & event_composed_flag != 0;
+}
+
+pub fn eventSetComposed(evt: *Event, composed: bool) !void {
+    var res = try eventInternalType(evt) & ~event_composed_flag;
+    if (composed) res |= event_composed_flag;
+    const err = c._dom_event_set_internal_type(evt, res);
     try DOMErr(err);
 }
 
 pub const EventType = enum(u8) {
     event = 0,
     progress_event = 1,
+    ui_event = 2,
+    mouse_event = 3,
+    keyboard_event = 4,
+    input_event = 5,
 };
 
 pub const MutationEvent = c.dom_mutation_event;
@@ -616,7 +654,8 @@ pub fn eventTargetHasListener(
             defer c.dom_event_listener_unref(listener);
             const ehd = EventHandlerDataInternal.fromListener(listener);
             if (ehd) |d| {
-                if (cbk_id == d.data.cbk.id()) {
+                // a once listener already called is removed.
+                if (cbk_id == d.data.cbk.id() and !d.fired) {
                     return lst;
                 }
             }
@@ -647,6 +686,19 @@ const EventHandler = struct {
     fn handle(event: ?*Event, data: ?*anyopaque) callconv(.C) void {
         if (data) |d| {
             const ehd = EventHandlerDataInternal.get(d);
+
+            // A once listener is marked as fired before being called to
+            // avoid a double call on re-entrant dispatch.
+            // libdom still walks the target's listeners when the handler
+            // returns, so the listener is removed after the dispatch.
+            // https://dom.spec.whatwg.org/#concept-event-listener-inner-invoke
+            if (ehd.data.once) {
+                if (ehd.fired) return;
+                if (event) |evt| {
+                    ehd.fire(evt) catch |e| log.err("event listener fire: {any}", .{e});
+                }
+            }
+
             ehd.handler(event, ehd.data);
 
             // NOTE: we can not call func.deinit here
@@ -668,6 +720,11 @@ pub const EventHandlerData = struct {
     // deinitFunc implements the data deinitialization.
     deinitFunc: ?DeinitFunc = null,
 
+    // once removes the listener after its first call.
+    once: bool = false,
+    // TODO passive is stored but ignored for now.
+    passive: bool = false,
+
     pub const DeinitFunc = *const fn (data: ?*anyopaque, alloc: std.mem.Allocator) void;
 };
 
@@ -676,16 +733,74 @@ const EventHandlerDataInternal = struct {
     data: EventHandlerData,
     handler: EventHandlerFunc,
 
-    fn init(alloc: std.mem.Allocator, handler: EventHandlerFunc, data: EventHandlerData) !*EventHandlerDataInternal {
+    // alloc, capture and listener are used to detach a once listener.
+    alloc: std.mem.Allocator,
+    capture: bool,
+    listener: ?*EventListener = null,
+
+    // fired is set on the first call of a once listener, which is skipped
+    // after. The fired target and event type are kept to detach it.
+    fired: bool = false,
+    target: ?*EventTarget = null,
+    typ: ?*String = null,
+    // next links the fired listeners waiting to be detached.
+    next: ?*EventHandlerDataInternal = null,
+
+    fn init(
+        alloc: std.mem.Allocator,
+        handler: EventHandlerFunc,
+        data: EventHandlerData,
+        capture: bool,
+    ) !*EventHandlerDataInternal {
         const ptr = try alloc.create(EventHandlerDataInternal);
         ptr.* = .{
             .data = data,
             .handler = handler,
+            .alloc = alloc,
+            .capture = capture,
         };
         return ptr;
     }
 
+    // fire marks the once listener as called on the event's current target
+    // and queues it to be detached after the dispatch.
+    fn fire(self: *EventHandlerDataInternal, evt: *Event) !void {
+        self.fired = true;
+        self.target = try eventCurrentTarget(evt) orelse return;
+        self.typ = try strFromData(try eventType(evt));
+
+        self.next = fired_listeners;
+        fired_listeners = self;
+    }
+
+    // detach removes the fired listener from its target and frees the
+    // handler data.
+    fn detach(self: *EventHandlerDataInternal) !void {
+        defer self.deinit(self.alloc);
+
+        const lst = self.listener orelse return;
+        const et = self.target orelse return;
+        self.listener = null;
+
+        const err = eventTargetVtable(et).remove_event_listener.?(et, self.typ, lst, self.capture);
+        try DOMErr(err);
+    }
+
+    // unqueue removes the listener from the fired listeners waiting to be
+    // detached.
+    fn unqueue(self: *EventHandlerDataInternal) void {
+        var cur = &fired_listeners;
+        while (cur.*) |ehd| : (cur = &ehd.next) {
+            if (ehd == self) {
+                cur.* = self.next;
+                return;
+            }
+        }
+    }
+
     fn deinit(self: *EventHandlerDataInternal, alloc: std.mem.Allocator) void {
+        self.unqueue();
+        if (self.typ) |t| c.dom_string_unref(t);
         if (self.data.deinitFunc) |d| d(self.data.data, alloc);
         self.data.cbk.deinit(alloc);
         alloc.destroy(self);
@@ -716,7 +831,7 @@ pub fn eventTargetAddEventListener(
 ) !void {
     // this allocation will be removed either on
     // eventTargetRemoveEventListener or eventTargetRemoveAllEventListeners
-    const ehd = try EventHandlerDataInternal.init(alloc, handlerFunc, data);
+    const ehd = try EventHandlerDataInternal.init(alloc, handlerFunc, data, capture);
     errdefer ehd.deinit(alloc);
 
     // When a function is used as an event handler, its this parameter is bound
@@ -733,6 +848,8 @@ pub fn eventTargetAddEventListener(
     const s = try strFromData(typ);
     const err = eventTargetVtable(et).add_event_listener.?(et, s, listener, capture);
     try DOMErr(err);
+
+    ehd.listener = listener;
 }
 
 pub fn eventTargetRemoveEventListener(
@@ -794,7 +911,28 @@ pub fn eventTargetRemoveAllEventListeners(
     }
 }
 
+// fired_listeners contains the once listeners called during a dispatch.
+// They are detached when the outermost dispatch returns, once libdom no
+// longer walks the targets' listeners. The listeners called by libdom's own
+// events are detached after the next dispatch.
+var fired_listeners: ?*EventHandlerDataInternal = null;
+var dispatch_depth: usize = 0;
+
+fn detachFiredListeners() void {
+    while (fired_listeners) |ehd| {
+        fired_listeners = ehd.next;
+        ehd.next = null;
+        ehd.detach() catch |e| log.err("event listener detach: {any}", .{e});
+    }
+}
+
 pub fn eventTargetDispatchEvent(et: *EventTarget, event: *Event) !bool {
+    dispatch_depth += 1;
+    defer {
+        dispatch_depth -= 1;
+        if (dispatch_depth == 0) detachFiredListeners();
+    }
+
     var res: bool = undefined;
     const err = eventTargetVtable(et).dispatch_event.?(et, event, &res);
     try DOMErr(err);
@@ -997,6 +1135,17 @@ pub fn namedNodeMapRemoveNamedItemNS(
     return @as(*Attribute, @ptrCast(n));
 }
 
+// tree_version changes with each change of the nodes' trees, of the
+// elements' attributes or of the character data made through this module, so
+// the live collections and the style sheets can keep their state until the
+// next change.
+// The changes made by the parser are not counted: no script runs meanwhile.
+var tree_version: u64 = 0;
+
+pub fn treeVersion() u64 {
+    return tree_version;
+}
+
 // Node
 pub const Node = c.dom_node_internal;
 
@@ -1004,6 +1153,12 @@ fn nodeVtable(node: *Node) c.dom_node_vtable {
     return getVtable(c.dom_node_vtable, Node, node);
 }
 
+// nodeUnref releases a reference to the node, the node is destroyed with its
+// last reference.
+pub fn nodeUnref(node: *Node) void {
+    c.dom_node_unref(@as(*c.dom_node, @ptrCast(node)));
+}
+
 pub fn nodeLocalName(node: *Node) ![]const u8 {
     var s: ?*String = undefined;
     const err = nodeVtable(node).dom_node_get_local_name.?(node, &s);
@@ -1120,6 +1275,7 @@ pub fn nodeSetValue(node: *Node, value: []const u8) !void {
     const s = try strFromData(value);
     const err = nodeVtable(node).dom_node_set_node_value.?(node, s);
     try DOMErr(err);
+    tree_version +%= 1;
 }
 
 pub fn nodeTextContent(node: *Node) !?[]const u8 {
@@ -1141,6 +1297,7 @@ pub fn nodeSetTextContent(node: *Node, value: []const u8) !void {
     const s = try strFromData(value);
     const err = nodeVtable(node).dom_node_set_text_content.?(node, s);
     try DOMErr(err);
+    tree_version +%= 1;
 }
 
 pub fn nodeGetChildNodes(node: *Node) !*NodeList {
@@ -1154,6 +1311,7 @@ pub fn nodeAppendChild(node: *Node, child: *Node) !*Node {
     var res: ?*Node = undefined;
     const err = nodeVtable(node).dom_node_append_child.?(node, child, &res);
     try DOMErr(err);
+    tree_version +%= 1;
     return res.?;
 }
 
@@ -1182,6 +1340,7 @@ pub fn nodeInsertBefore(node: *Node, new_node: *Node, ref_node: *Node) !*Node {
     var res: ?*Node = undefined;
     const err = nodeVtable(node).dom_node_insert_before.?(node, new_node, ref_node, &res);
     try DOMErr(err);
+    tree_version +%= 1;
     return res.?;
 }
 
@@ -1232,6 +1391,7 @@ pub fn nodeRemoveChild(node: *Node, child: *Node) !*Node {
     var res: ?*Node = undefined;
     const err = nodeVtable(node).dom_node_remove_child.?(node, child, &res);
     try DOMErr(err);
+    tree_version +%= 1;
     return res.?;
 }
 
@@ -1239,6 +1399,7 @@ pub fn nodeReplaceChild(node: *Node, new_child: *Node, old_child: *Node) !*Node
     var res: ?*Node = undefined;
     const err = nodeVtable(node).dom_node_replace_child.?(node, new_child, old_child, &res);
     try DOMErr(err);
+    tree_version +%= 1;
     return res.?;
 }
 
@@ -1299,6 +1460,7 @@ pub fn characterDataSetData(cdata: *CharacterData, data: []const u8) !void {
     const s = try strFromData(data);
     const err = characterDataVtable(cdata).dom_characterdata_set_data.?(cdata, s);
     try DOMErr(err);
+    tree_version +%= 1;
 }
 
 pub fn characterDataLength(cdata: *CharacterData) !u32 {
@@ -1312,23 +1474,27 @@ pub fn characterDataAppendData(cdata: *CharacterData, data: []const u8) !void {
     const s = try strFromData(data);
     const err = characterDataVtable(cdata).dom_characterdata_append_data.?(cdata, s);
     try DOMErr(err);
+    tree_version +%= 1;
 }
 
 pub fn characterDataDeleteData(cdata: *CharacterData, offset: u32, count: u32) !void {
     const err = characterDataVtable(cdata).dom_characterdata_delete_data.?(cdata, offset, count);
     try DOMErr(err);
+    tree_version +%= 1;
 }
 
 pub fn characterDataInsertData(cdata: *CharacterData, offset: u32, data: []const u8) !void {
     const s = try strFromData(data);
     const err = characterDataVtable(cdata).dom_characterdata_insert_data.?(cdata, offset, s);
     try DOMErr(err);
+    tree_version +%= 1;
 }
 
 pub fn characterDataReplaceData(cdata: *CharacterData, offset: u32, count: u32, data: []const u8) !void {
     const s = try strFromData(data);
     const err = characterDataVtable(cdata).dom_characterdata_replace_data.?(cdata, offset, count, s);
     try DOMErr(err);
+    tree_version +%= 1;
 }
 
 pub fn characterDataSubstringData(cdata: *CharacterData, offset: u32, count: u32) ![]const u8 {
@@ -1412,6 +1578,7 @@ pub fn attributeSetValue(a: *Attribute, v: []const u8) !void {
 
     const err = attributeVtable(a).dom_attr_set_value.?(a, try strFromData(v));
     try DOMErr(err);
+    tree_version +%= 1;
 }
 
 pub fn attributeGetOwnerElement(a: *Attribute) !?*Element {
@@ -1465,6 +1632,7 @@ pub fn elementSetAttribute(elem: *Element, qname: []const u8, value: []const u8)
         try strFromData(value),
     );
     try DOMErr(err);
+    tree_version +%= 1;
 }
 
 pub fn elementSetAttributeNS(
@@ -1480,11 +1648,13 @@ pub fn elementSetAttributeNS(
         try strFromData(value),
     );
     try DOMErr(err);
+    tree_version +%= 1;
 }
 
 pub fn elementRemoveAttribute(elem: *Element, qname: []const u8) !void {
     const err = elementVtable(elem).dom_element_remove_attribute.?(elem, try strFromData(qname));
     try DOMErr(err);
+    tree_version +%= 1;
 }
 
 pub fn elementRemoveAttributeNS(elem: *Element, ns: []const u8, qname: []const u8) !void {
@@ -1494,6 +1664,7 @@ pub fn elementRemoveAttributeNS(elem: *Element, ns: []const u8, qname: []const u
         try strFromData(qname),
     );
     try DOMErr(err);
+    tree_version +%= 1;
 }
 
 pub fn elementHasAttribute(elem: *Element, qname: []const u8) !bool {
@@ -1526,6 +1697,7 @@ pub fn elementSetAttributeNode(elem: *Element, attr: *Attribute) !?*Attribute {
     var a: ?*Attribute = undefined;
     const err = elementVtable(elem).dom_element_set_attribute_node.?(elem, attr, &a);
     try DOMErr(err);
+    tree_version +%= 1;
     return a;
 }
 
@@ -1533,6 +1705,7 @@ pub fn elementSetAttributeNodeNS(elem: *Element, attr: *Attribute) !?*Attribute
     var a: ?*Attribute = undefined;
     const err = elementVtable(elem).dom_element_set_attribute_node_ns.?(elem, attr, &a);
     try DOMErr(err);
+    tree_version +%= 1;
     return a;
 }
 
@@ -1540,6 +1713,7 @@ pub fn elementRemoveAttributeNode(elem: *Element, attr: *Attribute) !*Attribute
     var a: ?*Attribute = undefined;
     const err = elementVtable(elem).dom_element_remove_attribute_node.?(elem, attr, &a);
     try DOMErr(err);
+    tree_version +%= 1;
     return a.?;
 }
 
@@ -1594,11 +1768,13 @@ pub fn tokenListContains(l: *TokenList, token: []const u8) !bool {
 pub fn tokenListAdd(l: *TokenList, token: []const u8) !void {
     const err = c.dom_tokenlist_add(l, try strFromData(token));
     try DOMErr(err);
+    tree_version +%= 1;
 }
 
 pub fn tokenListRemove(l: *TokenList, token: []const u8) !void {
     const err = c.dom_tokenlist_remove(l, try strFromData(token));
     try DOMErr(err);
+    tree_version +%= 1;
 }
 
 pub fn tokenListGetValue(l: *TokenList) !?[]const u8 {
@@ -1623,6 +1799,202 @@ pub fn elementHTMLGetTagType(elem_html: *ElementHTML) !Tag {
     return @as(Tag, @enumFromInt(tag_type));
 }
 
+// HTMLTemplateElement
+// https://html.spec.whatwg.org/#the-template-element
+
+// user data keys used to store the template's contents and the document's
+// inert template contents owner.
+const template_content_key = "__lightpanda_template_content";
+const template_owner_key = "__lightpanda_template_owner";
+
+fn nodeGetUserData(node: *Node, key: []const u8) !?*anyopaque {
+    const k = try strFromData(key);
+    defer c.dom_string_unref(k);
+
+    var res: ?*anyopaque = undefined;
+    const err = nodeVtable(node).dom_node_get_user_data.?(node, k, &res);
+    try DOMErr(err);
+    return res;
+}
+
+fn nodeSetUserData(node: *Node, key: []const u8, data: ?*anyopaque) !void {
+    const k = try strFromData(key);
+    defer c.dom_string_unref(k);
+
+    var prev: ?*anyopaque = undefined;
+    const err = nodeVtable(node).dom_node_set_user_data.?(node, k, data, null, &prev);
+    try DOMErr(err);
+}
+
+// templateContentsOwner returns the inert document owning the templates'
+// contents of the document.
+// An inert document is its own templates' contents owner.
+// https://html.spec.whatwg.org/#appropriate-template-contents-owner-document
+fn templateContentsOwner(doc: *Document) !*Document {
+    if (try nodeGetUserData(documentToNode(doc), template_owner_key)) |v| {
+        return @as(*Document, @ptrCast(@alignCast(v)));
+    }
+
+    const inert = documentHTMLToDocument(try documentCreateDocument(null));
+    errdefer nodeUnref(documentToNode(inert));
+    try nodeSetUserData(documentToNode(inert), template_owner_key, inert);
+    try nodeSetUserData(documentToNode(doc), template_owner_key, inert);
+    return inert;
+}
+
+// templateContent returns the template's contents: a document fragment owned
+// by an inert document, so scripts inside are not executed.
+// A template created by a script gets empty contents, its children stay in
+// the template.
+pub fn templateContent(t: *Template) !*DocumentFragment {
+    const node = @as(*Node, @ptrCast(t));
+    if (try nodeGetUserData(node, template_content_key)) |v| {
+        return @as(*DocumentFragment, @ptrCast(@alignCast(v)));
+    }
+
+    const doc = try nodeOwnerDocument(node) orelse return DOMError.WrongDocument;
+    const owner = try templateContentsOwner(doc);
+    const fragment = try documentCreateDocumentFragment(owner);
+    errdefer nodeUnref(documentFragmentToNode(fragment));
+
+    try nodeSetUserData(node, template_content_key, fragment);
+    return fragment;
+}
+
+// templatesContent creates the contents of all the templates descendants of
+// root, just parsed.
+// The parser inserts the template's children into the template itself, they
+// are moved into the contents.
+pub fn templatesContent(root: *Node) !void {
+    var next = try nodeFirstChild(root);
+    while (next) |child| {
+        next = try nodeNextSibling(child);
+
+        if (try nodeType(child) != .element) continue;
+
+        const tag = try elementHTMLGetTagType(@as(*ElementHTML, @ptrCast(child)));
+        if (tag == .template) {
+            const content = try templateContent(@as(*Template, @ptrCast(child)));
+            const fnode = documentFragmentToNode(content);
+            const owner = try nodeOwnerDocument(fnode) orelse return DOMError.WrongDocument;
+
+            while (try nodeFirstChild(child)) |n| {
+                _ = try nodeRemoveChild(child, n);
+                _ = try nodeAppendChild(fnode, try documentAdoptNode(owner, n));
+            }
+
+            // nested templates get their own contents.
+            try templatesContent(fnode);
+            continue;
+        }
+
+        try templatesContent(child);
+    }
+}
+
+// templatesRelease releases the templates' contents of the document and
+// their inert owner document.
+fn templatesRelease(doc: *Document) !void {
+    const node = documentToNode(doc);
+    try templatesReleaseContent(node);
+
+    const v = try nodeGetUserData(node, template_owner_key) orelse return;
+    const inert = @as(*Document, @ptrCast(@alignCast(v)));
+    try nodeSetUserData(node, template_owner_key, null);
+
+    // an inert document is its own owner.
+    if (inert == doc) return;
+    try nodeSetUserData(documentToNode(inert), template_owner_key, null);
+    nodeUnref(documentToNode(inert));
+}
+
+fn templatesReleaseContent(root: *Node) !void {
+    var next = try nodeFirstChild(root);
+    while (next) |child| {
+        next = try nodeNextSibling(child);
+
+        if (try nodeType(child) != .element) continue;
+
+        const tag = try elementHTMLGetTagType(@as(*ElementHTML, @ptrCast(child)));
+        if (tag == .template) {
+            if (try nodeGetUserData(child, template_content_key)) |v| {
+                const fnode = documentFragmentToNode(@as(*DocumentFragment, @ptrCast(@alignCast(v))));
+                try templatesReleaseContent(fnode);
+                try nodeSetUserData(child, template_content_key, null);
+                nodeUnref(fnode);
+            }
+        }
+
+        try templatesReleaseContent(child);
+    }
+}
+
+// templatesCloneContent copies the contents of the templates from the src
+// tree into the dst tree, its deep clone.
+// https://html.spec.whatwg.org/#the-template-element:concept-node-clone-ext
+pub fn templatesCloneContent(src: *Node, dst: *Node) !void {
+    if (try nodeType(src) != .element) return;
+
+    const tag = try elementHTMLGetTagType(@as(*ElementHTML, @ptrCast(src)));
+    if (tag == .template) {
+        const content = documentFragmentToNode(try templateContent(@as(*Template, @ptrCast(src))));
+        const clone = documentFragmentToNode(try templateContent(@as(*Template, @ptrCast(dst))));
+
+        var next = try nodeFirstChild(content);
+        while (next) |child| {
+            _ = try nodeAppendChild(clone, try nodeCloneNode(child, true));
+            next = try nodeNextSibling(child);
+        }
+        // clones of the nested templates need their contents too.
+        return try templatesCloneChildren(content, clone);
+    }
+
+    try templatesCloneChildren(src, dst);
+}
+
+fn templatesCloneChildren(src: *Node, dst: *Node) !void {
+    var s = try nodeFirstChild(src);
+    var d = try nodeFirstChild(dst);
+    while (s != null and d != null) {
+        try templatesCloneContent(s.?, d.?);
+        s = try nodeNextSibling(s.?);
+        d = try nodeNextSibling(d.?);
+    }
+}
+
+// HTMLInputElement and HTMLTextAreaElement
+
+// user data key used to store the form control's dirty value.
+const dirty_value_key = "__lightpanda_dirty_value";
+
+// controlDirtyValue returns the value set by a script on the form control,
+// null if it was never set.
+// https://html.spec.whatwg.org/multipage/form-control-infrastructure.html#concept-fe-dirty
+pub fn controlDirtyValue(e: *Element) !?[]const u8 {
+    const v = try nodeGetUserData(elementToNode(e), dirty_value_key) orelse return null;
+    const value = @as(*const []const u8, @ptrCast(@alignCast(v)));
+    return value.*;
+}
+
+// controlSetDirtyValue stores a copy of the value set by a script on the form
+// control. The value is allocated with alloc, which must outlive the element.
+pub fn controlSetDirtyValue(alloc: std.mem.Allocator, e: *Element, value: []const u8) !void {
+    const v = try alloc.create([]const u8);
+    errdefer alloc.destroy(v);
+    v.* = try alloc.dupe(u8, value);
+    errdefer alloc.free(v.*);
+
+    const node = elementToNode(e);
+    const prev = try nodeGetUserData(node, dirty_value_key);
+    try nodeSetUserData(node, dirty_value_key, v);
+
+    if (prev) |p| {
+        const old = @as(*[]const u8, @ptrCast(@alignCast(p)));
+        alloc.free(old.*);
+        alloc.destroy(old);
+    }
+}
+
 // HTMLScriptElement
 
 // scriptToElt is an helper to convert an script to an element.
@@ -1810,6 +2182,10 @@ fn documentTypeVtable(dt: *DocumentType) c.dom_document_type_vtable {
     return getVtable(c.dom_document_type_vtable, DocumentType, dt);
 }
 
+pub inline fn documentTypeToNode(dt: *DocumentType) *Node {
+    return @as(*Node, @ptrCast(dt));
+}
+
 pub inline fn documentTypeGetName(dt: *DocumentType) ![]const u8 {
     var s: ?*String = undefined;
     const err = documentTypeVtable(dt).dom_document_type_get_name.?(dt, &s);
@@ -2052,6 +2428,7 @@ pub inline fn documentAdoptNode(doc: *Document, node: *Node) !*Node {
     const nodeext = toNodeExternal(Node, node);
     const err = documentVtable(doc).dom_document_adopt_node.?(doc, nodeext, &res);
     try DOMErr(err);
+    tree_version +%= 1;
     return @as(*Node, @ptrCast(res));
 }
 
@@ -2142,6 +2519,9 @@ pub fn documentHTMLParse(reader: anytype, enc: ?[:0]const u8) !*DocumentHTML {
 
     try parseData(parser.?, reader);
 
+    // move templates children into their contents.
+    try templatesContent(@as(*Node, @ptrCast(doc.?)));
+
     return @as(*DocumentHTML, @ptrCast(doc.?));
 }
 
@@ -2162,6 +2542,9 @@ pub fn documentParseFragment(self: *Document, reader: anytype, enc: ?[:0]const u
 
     try parseData(parser.?, reader);
 
+    // move templates children into their contents.
+    try templatesContent(@as(*Node, @ptrCast(fragment.?)));
+
     return @as(*DocumentFragment, @ptrCast(fragment.?));
 }
 
@@ -2198,8 +2581,11 @@ fn parseData(parser: *c.dom_hubbub_parser, reader: anytype) !void {
     try parserErr(err);
 }
 
-// documentHTMLClose closes the document.
+// documentHTMLClose closes the document and releases its templates'
+// contents.
 pub fn documentHTMLClose(doc: *DocumentHTML) !void {
+    try templatesRelease(documentHTMLToDocument(doc));
+
     const err = documentHTMLVtable(doc).close.?(doc);
     try DOMErr(err);
 }
@@ -0,0 +1,146 @@
+// Blob keeps its bytes in a JS typed array, which is not supported by the
+// native bindings.
+// The object URLs are kept in a store per global object, and so per origin.
+// The bytes of a blob are read synchronously by the body polyfill, which
+// sends them to the native code. The accessor is handed over with a
+// temporary blobBytes global, captured and removed by body.js before any
+// script runs.
+// https://w3c.github.io/FileAPI/#blob-section
+// https://w3c.github.io/FileAPI/#url
+(function () {
+  if (typeof globalThis.Blob !== 'function') {
+    // normalizeType returns the lowercased type, or the empty string if it
+    // contains a character outside the U+0020 to U+007E range.
+    const normalizeType = function (t) {
+      if (t === undefined || t === null) return '';
+      const s = String(t);
+      if (/[^ -~]/.test(s)) return '';
+      return s.toLowerCase();
+    };
+
+    // relative converts a relative index into a position in [0, size].
+    const relative = function (v, size, def) {
+      if (v === undefined) return def;
+      const n = Math.trunc(Number(v)) || 0;
+      if (n < 0) return Math.max(size + n, 0);
+      return Math.min(n, size);
+    };
+
+    globalThis.Blob = class Blob {
+      #bytes;
+      #type;
+
+      constructor(blobParts, options) {
+        const opts = options ?? {};
+        const endings = opts.endings ?? 'transparent';
+        if (endings !== 'transparent' && endings !== 'native') {
+          throw new TypeError("Failed to construct 'Blob': The provided value '" + endings + "' is not a valid enum value of type EndingType.");
+        }
+
+        const parts = [];
+        if (blobParts !== undefined) {
+          if (blobParts === null || typeof blobParts[Symbol.iterator] !== 'function') {
+            throw new TypeError("Failed to construct 'Blob': The provided value cannot be converted to a sequence.");
+          }
+          for (const part of blobParts) parts.push(Blob.#bytesOf(part, endings));
+        }
+
+        let size = 0;
+        for (const p of parts) size += p.length;
+
+        this.#bytes = new Uint8Array(size);
+        let offset = 0;
+        for (const p of parts) {
+          this.#bytes.set(p, offset);
+          offset += p.length;
+        }
+        this.#type = normalizeType(opts.type);
+      }
+
+      static {
+        Object.defineProperty(globalThis, 'blobBytes', {
+          value: (blob) => blob.#bytes,
+          configurable: true,
+        });
+      }
+
+      // bytesOf returns the bytes of a blob part.
+      static #bytesOf(part, endings) {
+        if (part instanceof Blob) return part.#bytes;
+        if (part instanceof ArrayBuffer) return new Uint8Array(part);
+        if (ArrayBuffer.isView(part)) return new Uint8Array(part.buffer, part.byteOffset, part.byteLength);
+
+        let s = String(part);
+        if (endings === 'native') s = s.replace(/\r\n|\r/g, '\n');
+        return new TextEncoder().encode(s);
+      }
+
+      get size() {
+        return this.#bytes.length;
+      }
+
+      get type() {
+        return this.#type;
+      }
+
+      get [Symbol.toStringTag]() {
+        return 'Blob';
+      }
+
+      slice(start, end, contentType) {
+        const size = this.#bytes.length;
+        const from = relative(start, size, 0);
+        const to = relative(end, size, size);
+
+        const b = new Blob([], { type: contentType });
+        b.#bytes = this.#bytes.slice(from, Math.max(to, from));
+        return b;
+      }
+
+      text() {
+        return Promise.resolve(new TextDecoder().decode(this.#bytes));
+      }
+
+      arrayBuffer() {
+        return Promise.resolve(this.#bytes.slice().buffer);
+      }
+
+      bytes() {
+        return Promise.resolve(this.#bytes.slice());
+      }
+    };
+  }
+
+  if (typeof URL !== 'function' || typeof URL.createObjectURL === 'function') {
+    return;
+  }
+
+  // store contains the object URLs of the global object.
+  // TODO resolve the blob: URLs on fetch.
+  const store = new Map();
+
+  // origin returns the serialized origin of the document: an opaque origin
+  // is serialized as null.
+  const origin = function () {
+    try {
+      const u = new URL(document.URL);
+      if (u.protocol === 'http:' || u.protocol === 'https:') return u.origin;
+    } catch (e) {
+      // ignore invalid urls.
+    }
+    return 'null';
+  };
+
+  URL.createObjectURL = function createObjectURL(obj) {
+    if (!(obj instanceof Blob)) {
+      throw new TypeError("Failed to execute 'createObjectURL' on 'URL': Overload resolution failed.");
+    }
+    const url = 'blob:' + origin() + '/' + crypto.randomUUID();
+    store.set(url, obj);
+    return url;
+  };
+
+  URL.revokeObjectURL = function revokeObjectURL(url) {
+    store.delete(String(url));
+  };
+})();
@@ -0,0 +1,63 @@
+// Copyright (C) 2023-2024  Lightpanda (Selecy SAS)
+//
+// Francis Bouvier <francis@lightpanda.io>
+// Pierre Tachoire <pierre@lightpanda.io>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+const std = @import("std");
+
+const jsruntime = @import("jsruntime");
+const Case = jsruntime.test_utils.Case;
+const checkCases = jsruntime.test_utils.checkCases;
+
+// Tests of the Blob polyfill, see blob.js.
+
+pub fn testExecFn(
+    _: std.mem.Allocator,
+    js_env: *jsruntime.Env,
+) anyerror!void {
+    var blob = [_]Case{
+        .{ .src = "let blob = new Blob(['foo', new Uint8Array([98, 97]), new Blob(['r'])], { type: 'Text/Plain' })", .ex = "undefined" },
+        .{ .src = "blob.size", .ex = "6" },
+        .{ .src = "blob.type", .ex = "text/plain" },
+        .{ .src = "Object.prototype.toString.call(blob)", .ex = "[object Blob]" },
+        .{ .src = "new Blob().size", .ex = "0" },
+        .{ .src = "new Blob(['é']).size", .ex = "2" },
+        .{ .src = "new Blob([], { type: 'a\u00e9' }).type", .ex = "" },
+        .{ .src = "new Blob(['a\r\nb'], { endings: 'native' }).size", .ex = "3" },
+        // the bytes accessor is hidden from the scripts.
+        .{ .src = "typeof blobBytes", .ex = "undefined" },
+        .{ .src = "Object.getOwnPropertySymbols(Blob).length", .ex = "0" },
+
+        .{ .src = "let blobtext; blob.text().then((t) => { blobtext = t })", .ex = "[object Promise]" },
+        .{ .src = "blobtext", .ex = "foobar" },
+        .{ .src = "let blobbuf; blob.arrayBuffer().then((b) => { blobbuf = b })", .ex = "[object Promise]" },
+        .{ .src = "blobbuf instanceof ArrayBuffer", .ex = "true" },
+        .{ .src = "new Uint8Array(blobbuf).join(',')", .ex = "102,111,111,98,97,114" },
+
+        .{ .src = "let blobslice = blob.slice(1, -1, 'foo/bar')", .ex = "undefined" },
+        .{ .src = "blobslice.size", .ex = "4" },
+        .{ .src = "blobslice.type", .ex = "foo/bar" },
+        .{ .src = "blob.slice(4, 2).size", .ex = "0" },
+        .{ .src = "blob.slice(-2).size", .ex = "2" },
+
+        .{ .src = "let bloburl = URL.createObjectURL(blob)", .ex = "undefined" },
+        .{ .src = "/^blob:null\\/[0-9a-f-]{36}$/.test(bloburl)", .ex = "true" },
+        .{ .src = "URL.createObjectURL(blob) !== bloburl", .ex = "true" },
+        .{ .src = "URL.revokeObjectURL(bloburl)", .ex = "undefined" },
+        .{ .src = "var err; try { URL.createObjectURL('foo') } catch (e) { err = e } err instanceof TypeError", .ex = "true" },
+    };
+    try checkCases(js_env, &blob);
+}
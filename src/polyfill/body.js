@@ -0,0 +1,52 @@
+// The bodies given to XMLHttpRequest.send and navigator.sendBeacon are
+// typed arrays, Blobs or FormData, which are not supported by the native
+// bindings. They are extracted with a Request and their bytes are passed to
+// the native functions with one code point per byte, along with their
+// content type.
+// https://fetch.spec.whatwg.org/#concept-bodyinit-extract
+// https://xhr.spec.whatwg.org/#the-send()-method
+// https://w3c.github.io/beacon/#sendbeacon-method
+(function () {
+  // blobBytes returns the live bytes of a Blob, it's hidden once captured.
+  const blobBytes = globalThis.blobBytes;
+  delete globalThis.blobBytes;
+
+  if (typeof Request !== 'function') return;
+
+  // extract returns the body's bytes as a string with one code point per
+  // byte, and its content type.
+  // TODO serialize a Document body.
+  const extract = function (body) {
+    const req = new Request('about:blank', { method: 'POST', body: body });
+    const bytes = req.body instanceof Uint8Array ? req.body : blobBytes(req.body);
+    let s = '';
+    for (let i = 0; i < bytes.length; i += 0x8000) {
+      s += String.fromCharCode.apply(null, bytes.subarray(i, i + 0x8000));
+    }
+    return [s, req.headers.get('content-type')];
+  };
+
+  const send = globalThis.XMLHttpRequest?.prototype.send;
+  if (typeof send === 'function') {
+    XMLHttpRequest.prototype.send = function (body) {
+      if (body === undefined || body === null) return send.call(this, null, null);
+      return send.call(this, ...extract(body));
+    };
+  }
+
+  const sendBeacon = globalThis.Navigator?.prototype.sendBeacon;
+  if (typeof sendBeacon === 'function') {
+    const schemes = ['http', 'https'];
+
+    Navigator.prototype.sendBeacon = function (url, data) {
+      const target = String(url);
+      const m = /^([A-Za-z][A-Za-z0-9+.\-]*):/.exec(target);
+      if (m !== null && !schemes.includes(m[1].toLowerCase())) {
+        throw new TypeError("Failed to execute 'sendBeacon' on 'Navigator': Beacons are only supported over HTTP(S).");
+      }
+
+      if (data === undefined || data === null) return sendBeacon.call(this, target, null, null);
+      return sendBeacon.call(this, target, ...extract(data));
+    };
+  }
+})();
@@ -0,0 +1,31 @@
+// The ChildNode's before, after and replaceWith accept either nodes or
+// strings. Like for the ParentNode's functions, the strings are converted
+// here into text nodes before calling the native functions.
+// blocked by https://github.com/lightpanda-io/jsruntime-lib/issues/114
+// https://dom.spec.whatwg.org/#interface-childnode
+(function () {
+  if (typeof Node !== 'function') return;
+
+  const protos = [Element, CharacterData, DocumentType]
+    .filter((i) => typeof i === 'function')
+    .map((i) => i.prototype);
+
+  for (const proto of protos) {
+    for (const name of ['before', 'after', 'replaceWith']) {
+      const native = proto[name];
+      if (typeof native !== 'function') continue;
+
+      Object.defineProperty(proto, name, {
+        value: function (...nodes) {
+          const doc = this.ownerDocument;
+          return native.apply(this, nodes.map((n) => {
+            return n instanceof Node ? n : doc.createTextNode(String(n));
+          }));
+        },
+        writable: true,
+        enumerable: true,
+        configurable: true,
+      });
+    }
+  }
+})();
@@ -0,0 +1,114 @@
+// The native console only prints its arguments. This wrapper adds the
+// grouping, counting and table methods on top of it, the output going
+// through the native console methods.
+// https://console.spec.whatwg.org/
+(function () {
+  const c = globalThis.console;
+  if (typeof c !== 'object' || c === null) {
+    return;
+  }
+
+  const indent = '  ';
+  let level = 0;
+  const counts = new Map();
+
+  // wrap the printing methods to indent their output with the group level.
+  const wrap = function (name) {
+    const native = c[name];
+    if (typeof native !== 'function') return;
+
+    c[name] = function (...args) {
+      // the native console separates the arguments with a space.
+      if (level > 0) args.unshift(indent.repeat(level).slice(1));
+      return native.apply(c, args);
+    };
+  };
+  for (const name of ['log', 'info', 'warn', 'error', 'debug']) wrap(name);
+
+  // https://console.spec.whatwg.org/#group
+  c.group = function (...label) {
+    if (label.length > 0) c.log(...label);
+    level++;
+  };
+  c.groupCollapsed = c.group;
+
+  // https://console.spec.whatwg.org/#groupend
+  c.groupEnd = function () {
+    if (level > 0) level--;
+  };
+
+  // https://console.spec.whatwg.org/#count
+  c.count = function (label = 'default') {
+    label = String(label);
+    const n = (counts.get(label) ?? 0) + 1;
+    counts.set(label, n);
+    c.info(label + ': ' + n);
+  };
+
+  // https://console.spec.whatwg.org/#countreset
+  c.countReset = function (label = 'default') {
+    label = String(label);
+    if (!counts.has(label)) {
+      c.warn("Count for '" + label + "' does not exist");
+      return;
+    }
+    counts.set(label, 0);
+  };
+
+  const cell = function (v) {
+    if (v === undefined) return '';
+    if (typeof v === 'string') return v;
+    if (typeof v === 'object' && v !== null) {
+      try {
+        return JSON.stringify(v);
+      } catch (e) {
+        return String(v);
+      }
+    }
+    return String(v);
+  };
+
+  // table renders the tabular data as an aligned text table.
+  // The non tabular data is logged.
+  // https://console.spec.whatwg.org/#table
+  c.table = function (data, properties) {
+    if (typeof data !== 'object' || data === null) {
+      return c.log(data);
+    }
+
+    const index = '(index)';
+    const value = 'Value';
+    const columns = [];
+    let hasValue = false;
+    const rows = [];
+
+    for (const key of Object.keys(data)) {
+      const row = { [index]: key };
+      const v = data[key];
+      if (typeof v === 'object' && v !== null) {
+        for (const col of Object.keys(v)) {
+          if (properties && !properties.includes(col)) continue;
+          if (!columns.includes(col)) columns.push(col);
+          row[col] = cell(v[col]);
+        }
+      } else {
+        hasValue = true;
+        row[value] = cell(v);
+      }
+      rows.push(row);
+    }
+
+    const header = [index, ...(properties ?? columns)];
+    if (hasValue) header.push(value);
+
+    const widths = header.map((h) => {
+      return rows.reduce((w, row) => Math.max(w, (row[h] ?? '').length), h.length);
+    });
+    const line = (cells) => '| ' + cells.map((v, i) => v.padEnd(widths[i])).join(' | ') + ' |';
+    const sep = '|-' + widths.map((w) => '-'.repeat(w)).join('-|-') + '-|';
+
+    const out = [line(header), sep];
+    for (const row of rows) out.push(line(header.map((h) => row[h] ?? '')));
+    c.log('\n' + out.join('\n'));
+  };
+})();
@@ -0,0 +1,57 @@
+// Copyright (C) 2023-2024  Lightpanda (Selecy SAS)
+//
+// Francis Bouvier <francis@lightpanda.io>
+// Pierre Tachoire <pierre@lightpanda.io>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+const std = @import("std");
+
+const jsruntime = @import("jsruntime");
+const Case = jsruntime.test_utils.Case;
+const checkCases = jsruntime.test_utils.checkCases;
+
+// Tests of the console polyfill, see console.js.
+
+pub fn testExecFn(
+    _: std.mem.Allocator,
+    js_env: *jsruntime.Env,
+) anyerror!void {
+    var console = [_]Case{
+        .{ .src = "typeof console.group", .ex = "function" },
+        .{ .src = "typeof console.groupCollapsed", .ex = "function" },
+        .{ .src = "console.group('foo'); console.log('bar'); console.groupEnd()", .ex = "undefined" },
+        .{ .src = "console.groupEnd(); console.log('unbalanced groupEnd is ignored')", .ex = "undefined" },
+        .{ .src = "console.count(); console.count('foo'); console.countReset('foo')", .ex = "undefined" },
+        .{ .src = "console.table([{ a: 1, b: 'foo' }, { a: 2, c: true }]); console.table({ x: 1 }); console.table('foo')", .ex = "undefined" },
+    };
+    try checkCases(js_env, &console);
+
+    // The polyfill is evaluated again on top of a console capturing its
+    // output.
+    var output = [_]Case{
+        .{ .src = "var out = []; globalThis.console = {}; for (const m of ['log', 'info', 'warn', 'error', 'debug']) console[m] = (...args) => { out.push(m + ':' + args.join(' ')) }; true", .ex = "true" },
+        .{ .src = @embedFile("console.js"), .ex = "undefined" },
+
+        .{ .src = "console.group('foo'); console.log('bar'); console.group(); console.warn('baz'); console.groupEnd(); console.groupEnd(); console.groupEnd(); console.log('qux'); out.join('|')", .ex = "log:foo|log:  bar|warn:    baz|log:qux" },
+
+        .{ .src = "out = []; console.count(); console.count(); console.count('foo'); console.countReset('foo'); console.count('foo'); console.countReset('bar'); out.join('|')", .ex = "info:default: 1|info:default: 2|info:foo: 1|info:foo: 1|warn:Count for 'bar' does not exist" },
+
+        .{ .src = "out = []; console.table([{ a: 1, b: 'foo' }, { a: 2, c: true }]); out[0].split('\\n').join('/')", .ex = "log:/| (index) | a | b   | c    |/|---------|---|-----|------|/| 0       | 1 | foo |      |/| 1       | 2 |     | true |" },
+        .{ .src = "out = []; console.table({ x: 1 }); out[0].split('\\n').join('/')", .ex = "log:/| (index) | Value |/|---------|-------|/| x       | 1     |" },
+        .{ .src = "out = []; console.table([{ a: 1, b: 2 }], ['b']); out[0].split('\\n').join('/')", .ex = "log:/| (index) | b |/|---------|---|/| 0       | 2 |" },
+        .{ .src = "out = []; console.table('foo'); out.join('|')", .ex = "log:foo" },
+    };
+    try checkCases(js_env, &output);
+}
@@ -0,0 +1,31 @@
+// crypto.getRandomValues fills a typed array, which is not supported by the
+// native bindings. The random bytes come from the native randomHex, backed by
+// a cryptographically secure random source, which is hidden once captured.
+// https://w3c.github.io/webcrypto/#Crypto-method-getRandomValues
+if (typeof globalThis.crypto === 'object' && typeof globalThis.crypto.getRandomValues !== 'function') {
+  (function () {
+    const c = globalThis.crypto;
+    const proto = Object.getPrototypeOf(c);
+    const randomHex = proto.randomHex;
+    delete proto.randomHex;
+
+    const integers = [
+      Int8Array, Uint8Array, Uint8ClampedArray, Int16Array, Uint16Array,
+      Int32Array, Uint32Array, BigInt64Array, BigUint64Array,
+    ];
+
+    // the native randomHex checks the array's type and length to throw the
+    // TypeMismatchError and QuotaExceededError DOMExceptions.
+    c.getRandomValues = function getRandomValues(array) {
+      const integer = integers.some((t) => array instanceof t);
+      const n = integer ? array.byteLength : 0;
+      const hex = randomHex.call(c, n, integer);
+
+      const bytes = new Uint8Array(array.buffer, array.byteOffset, n);
+      for (let i = 0; i < n; i++) {
+        bytes[i] = parseInt(hex.substr(2 * i, 2), 16);
+      }
+      return array;
+    };
+  })();
+}
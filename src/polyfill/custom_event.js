@@ -0,0 +1,28 @@
+// CustomEvent carries an arbitrary JS value as detail. The native bindings
+// can't hold a JS value, so CustomEvent extends the native Event in JS.
+// The event is dispatched through the native Event, with the same JS object
+// given to the listeners.
+// https://dom.spec.whatwg.org/#interface-customevent
+if (typeof globalThis.CustomEvent !== 'function') {
+  globalThis.CustomEvent = class CustomEvent extends Event {
+    #detail = null;
+
+    constructor(type, eventInitDict) {
+      if (arguments.length < 1) {
+        throw new TypeError("Failed to construct 'CustomEvent': 1 argument required, but only 0 present.");
+      }
+      const init = eventInitDict ?? {};
+      super(type, { bubbles: !!init.bubbles, cancelable: !!init.cancelable });
+      if (init.detail !== undefined) this.#detail = init.detail;
+    }
+
+    get detail() {
+      return this.#detail;
+    }
+
+    initCustomEvent(type, bubbles, cancelable, detail) {
+      this.initEvent(type, bubbles, cancelable);
+      this.#detail = detail ?? null;
+    }
+  };
+}
@@ -0,0 +1,49 @@
+// Copyright (C) 2023-2024  Lightpanda (Selecy SAS)
+//
+// Francis Bouvier <francis@lightpanda.io>
+// Pierre Tachoire <pierre@lightpanda.io>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+const std = @import("std");
+
+const jsruntime = @import("jsruntime");
+const Case = jsruntime.test_utils.Case;
+const checkCases = jsruntime.test_utils.checkCases;
+
+// Tests of the CustomEvent polyfill, see custom_event.js.
+
+pub fn testExecFn(
+    _: std.mem.Allocator,
+    js_env: *jsruntime.Env,
+) anyerror!void {
+    var custom_event = [_]Case{
+        .{ .src = "let cdetail = { foo: 'bar' }", .ex = "undefined" },
+        .{ .src = "let cevt = new CustomEvent('ready', { bubbles: true, detail: cdetail })", .ex = "undefined" },
+        .{ .src = "cevt instanceof Event", .ex = "true" },
+        .{ .src = "cevt instanceof CustomEvent", .ex = "true" },
+        .{ .src = "cevt.type", .ex = "ready" },
+        .{ .src = "cevt.bubbles", .ex = "true" },
+        .{ .src = "cevt.cancelable", .ex = "false" },
+        .{ .src = "cevt.detail === cdetail", .ex = "true" },
+        .{ .src = "cevt.detail = 1; cevt.detail === cdetail", .ex = "true" },
+        .{ .src = "new CustomEvent('foo').detail", .ex = "null" },
+        .{ .src = "var cres = []", .ex = "undefined" },
+        .{ .src = "document.addEventListener('ready', (e) => cres.push('doc', e === cevt, e.detail === cdetail))", .ex = "undefined" },
+        .{ .src = "document.getElementById('content').addEventListener('ready', (e) => cres.push('content', e.detail.foo), true)", .ex = "undefined" },
+        .{ .src = "document.getElementById('para').dispatchEvent(cevt)", .ex = "true" },
+        .{ .src = "cres.join(',')", .ex = "content,bar,doc,true,true" },
+    };
+    try checkCases(js_env, &custom_event);
+}
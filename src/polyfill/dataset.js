@@ -0,0 +1,96 @@
+// HTMLElement.dataset exposes the element's data-* attributes as a
+// DOMStringMap. DOMStringMap relies on named properties which are not
+// supported by the native bindings, so the map is emulated with a Proxy.
+// https://html.spec.whatwg.org/multipage/dom.html#dom-dataset
+(function () {
+  if (typeof HTMLElement === 'undefined' || 'dataset' in HTMLElement.prototype) {
+    return;
+  }
+
+  const prefix = 'data-';
+
+  // attribute name (data-foo-bar) to property name (fooBar).
+  const toProperty = function (name) {
+    return name.slice(prefix.length).replace(/-([a-z])/g, (_, c) => c.toUpperCase());
+  };
+
+  // property name (fooBar) to attribute name (data-foo-bar).
+  const toAttribute = function (prop) {
+    if (/-[a-z]/.test(prop)) {
+      throw new SyntaxError("Failed to set the '" + prop + "' property on 'DOMStringMap': '" + prop + "' is not a valid property name.");
+    }
+    return prefix + prop.replace(/[A-Z]/g, (c) => '-' + c.toLowerCase());
+  };
+
+  // isData returns true if the attribute name is listed in the map.
+  const isData = function (attr) {
+    return attr.namespaceURI === null &&
+      attr.name.startsWith(prefix) &&
+      !/[A-Z]/.test(attr.name);
+  };
+
+  const names = function (elt) {
+    const res = [];
+    const attrs = elt.attributes;
+    for (let i = 0; i < attrs.length; i++) {
+      const attr = attrs.item(i);
+      if (isData(attr)) res.push(toProperty(attr.name));
+    }
+    return res;
+  };
+
+  // stringMap returns the handler of the element's map proxy.
+  // The proxy target is an empty object to keep the invariants of the
+  // proxy independent from the element's own properties.
+  const stringMap = function (elt) {
+    const handler = {
+      get(_, prop) {
+        if (typeof prop !== 'string') return undefined;
+        if (/-[a-z]/.test(prop)) return undefined;
+        const v = elt.getAttribute(toAttribute(prop));
+        return v === null ? undefined : v;
+      },
+      set(_, prop, value) {
+        if (typeof prop !== 'string') return false;
+        elt.setAttribute(toAttribute(prop), String(value));
+        return true;
+      },
+      deleteProperty(_, prop) {
+        if (typeof prop !== 'string') return true;
+        if (/-[a-z]/.test(prop)) return true;
+        elt.removeAttribute(toAttribute(prop));
+        return true;
+      },
+      has(_, prop) {
+        if (typeof prop !== 'string') return false;
+        if (/-[a-z]/.test(prop)) return false;
+        return elt.hasAttribute(toAttribute(prop));
+      },
+      ownKeys() {
+        return names(elt);
+      },
+      getOwnPropertyDescriptor(target, prop) {
+        const v = handler.get(target, prop);
+        if (v === undefined) return undefined;
+        return { value: v, writable: true, enumerable: true, configurable: true };
+      },
+    };
+    return handler;
+  };
+
+  // keep the same map for an element, ie. elt.dataset === elt.dataset.
+  const maps = new WeakMap();
+
+  Object.defineProperty(HTMLElement.prototype, 'dataset', {
+    get() {
+      let map = maps.get(this);
+      if (map === undefined) {
+        map = new Proxy({}, stringMap(this));
+        maps.set(this, map);
+      }
+      return map;
+    },
+    enumerable: true,
+    configurable: true,
+  });
+})();
@@ -0,0 +1,50 @@
+// Copyright (C) 2023-2024  Lightpanda (Selecy SAS)
+//
+// Francis Bouvier <francis@lightpanda.io>
+// Pierre Tachoire <pierre@lightpanda.io>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+const std = @import("std");
+
+const jsruntime = @import("jsruntime");
+const Case = jsruntime.test_utils.Case;
+const checkCases = jsruntime.test_utils.checkCases;
+
+// Tests of the HTMLElement.dataset polyfill, see dataset.js.
+
+pub fn testExecFn(
+    _: std.mem.Allocator,
+    js_env: *jsruntime.Env,
+) anyerror!void {
+    var dataset = [_]Case{
+        .{ .src = "let ds = document.createElement('div')", .ex = "undefined" },
+        .{ .src = "ds.setAttribute('data-user-id', '42')", .ex = "undefined" },
+        .{ .src = "ds.dataset.userId", .ex = "42" },
+        .{ .src = "ds.dataset === ds.dataset", .ex = "true" },
+        .{ .src = "ds.dataset.unknown", .ex = "undefined" },
+        .{ .src = "ds.dataset.fooBar = 'x'", .ex = "x" },
+        .{ .src = "ds.getAttribute('data-foo-bar')", .ex = "x" },
+        .{ .src = "ds.dataset.fooBar = 'y'", .ex = "y" },
+        .{ .src = "ds.getAttribute('data-foo-bar')", .ex = "y" },
+        .{ .src = "'fooBar' in ds.dataset", .ex = "true" },
+        .{ .src = "Object.keys(ds.dataset).join(',')", .ex = "userId,fooBar" },
+        .{ .src = "delete ds.dataset.fooBar", .ex = "true" },
+        .{ .src = "ds.hasAttribute('data-foo-bar')", .ex = "false" },
+        .{ .src = "ds.dataset.a1B = 'z'", .ex = "z" },
+        .{ .src = "ds.getAttribute('data-a1-b')", .ex = "z" },
+        .{ .src = "var err; try { ds.dataset['foo-bar'] = 'x' } catch (e) { err = e } err.name", .ex = "SyntaxError" },
+    };
+    try checkCases(js_env, &dataset);
+}
@@ -0,0 +1,17 @@
+// Document.elementsFromPoint returns a sequence, which the native bindings
+// can't convert into an array.
+// There is no layout, so the hit-test stack is the element returned by
+// elementFromPoint followed by its ancestors.
+// https://drafts.csswg.org/cssom-view/#dom-document-elementsfrompoint
+(function () {
+  if (typeof HTMLDocument !== 'function') return;
+  if (typeof HTMLDocument.prototype.elementsFromPoint === 'function') return;
+
+  HTMLDocument.prototype.elementsFromPoint = function (x, y) {
+    const res = [];
+    for (let elt = this.elementFromPoint(x, y); elt !== null; elt = elt.parentElement) {
+      res.push(elt);
+    }
+    return res;
+  };
+})();
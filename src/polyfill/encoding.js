@@ -0,0 +1,296 @@
+// TextEncoder and TextDecoder work on typed arrays, which are not supported
+// by the native bindings.
+// https://encoding.spec.whatwg.org/
+(function () {
+  if (typeof globalThis.TextEncoder === 'function' && typeof globalThis.TextDecoder === 'function') {
+    return;
+  }
+
+  const replacement = 0xFFFD;
+
+  // utf8 returns the UTF-8 bytes of the code point.
+  // A lone surrogate is replaced by U+FFFD.
+  const utf8 = function (cp) {
+    if (cp >= 0xD800 && cp <= 0xDFFF) cp = replacement;
+    if (cp < 0x80) return [cp];
+    if (cp < 0x800) return [0xC0 | (cp >> 6), 0x80 | (cp & 0x3F)];
+    if (cp < 0x10000) return [0xE0 | (cp >> 12), 0x80 | ((cp >> 6) & 0x3F), 0x80 | (cp & 0x3F)];
+    return [0xF0 | (cp >> 18), 0x80 | ((cp >> 12) & 0x3F), 0x80 | ((cp >> 6) & 0x3F), 0x80 | (cp & 0x3F)];
+  };
+
+  // https://encoding.spec.whatwg.org/#interface-textencoder
+  class TextEncoder {
+    get encoding() {
+      return 'utf-8';
+    }
+
+    encode(input = '') {
+      const out = [];
+      for (const c of String(input)) out.push(...utf8(c.codePointAt(0)));
+      return new Uint8Array(out);
+    }
+
+    encodeInto(source, destination) {
+      if (!(destination instanceof Uint8Array)) {
+        throw new TypeError("Failed to execute 'encodeInto' on 'TextEncoder': parameter 2 is not of type 'Uint8Array'.");
+      }
+
+      let read = 0;
+      let written = 0;
+      for (const c of String(source)) {
+        const bytes = utf8(c.codePointAt(0));
+        if (written + bytes.length > destination.length) break;
+        destination.set(bytes, written);
+        read += c.length;
+        written += bytes.length;
+      }
+      return { read, written };
+    }
+  }
+
+  // decoders contains the decoders' constructors by encoding name.
+  // A decoder's handle function returns the code points decoded from the
+  // byte, or null if more bytes are needed. The byte is given again if
+  // reprocess is set.
+  // The flush function returns true if the decoder has pending bytes.
+  const decoders = {
+    // https://encoding.spec.whatwg.org/#utf-8-decoder
+    'utf-8': function () {
+      let cp = 0;
+      let needed = 0;
+      let seen = 0;
+      let lower = 0x80;
+      let upper = 0xBF;
+
+      const reset = function () {
+        cp = needed = seen = 0;
+        lower = 0x80;
+        upper = 0xBF;
+      };
+
+      return {
+        handle(b, err) {
+          if (needed === 0) {
+            if (b <= 0x7F) return b;
+            if (b >= 0xC2 && b <= 0xDF) {
+              needed = 1;
+              cp = b & 0x1F;
+            } else if (b >= 0xE0 && b <= 0xEF) {
+              if (b === 0xE0) lower = 0xA0;
+              if (b === 0xED) upper = 0x9F;
+              needed = 2;
+              cp = b & 0xF;
+            } else if (b >= 0xF0 && b <= 0xF4) {
+              if (b === 0xF0) lower = 0x90;
+              if (b === 0xF4) upper = 0x8F;
+              needed = 3;
+              cp = b & 0x7;
+            } else {
+              return err();
+            }
+            return null;
+          }
+
+          if (b < lower || b > upper) {
+            reset();
+            this.reprocess = true;
+            return err();
+          }
+
+          lower = 0x80;
+          upper = 0xBF;
+          cp = (cp << 6) | (b & 0x3F);
+          seen++;
+          if (seen !== needed) return null;
+
+          const res = cp;
+          reset();
+          return res;
+        },
+        flush() {
+          const pending = needed !== 0;
+          reset();
+          return pending;
+        },
+      };
+    },
+
+    // https://encoding.spec.whatwg.org/#shared-utf-16-decoder
+    'utf-16le': function () {
+      let leadByte = null;
+      let leadSurrogate = null;
+
+      return {
+        handle(b, err) {
+          if (leadByte === null) {
+            leadByte = b;
+            return null;
+          }
+
+          const cu = leadByte + (b << 8);
+          leadByte = null;
+
+          if (leadSurrogate !== null) {
+            const ls = leadSurrogate;
+            leadSurrogate = null;
+            if (cu >= 0xDC00 && cu <= 0xDFFF) {
+              return 0x10000 + ((ls - 0xD800) << 10) + (cu - 0xDC00);
+            }
+            // the code unit is processed after the error.
+            const e = err();
+            const next = this.unit(cu, err);
+            return next === null ? e : [e, next];
+          }
+
+          return this.unit(cu, err);
+        },
+        unit(cu, err) {
+          if (cu >= 0xD800 && cu <= 0xDBFF) {
+            leadSurrogate = cu;
+            return null;
+          }
+          if (cu >= 0xDC00 && cu <= 0xDFFF) return err();
+          return cu;
+        },
+        flush() {
+          const pending = leadByte !== null || leadSurrogate !== null;
+          leadByte = leadSurrogate = null;
+          return pending;
+        },
+      };
+    },
+
+    // https://encoding.spec.whatwg.org/#single-byte-decoder
+    'windows-1252': function () {
+      const table = [
+        0x20AC, 0x0081, 0x201A, 0x0192, 0x201E, 0x2026, 0x2020, 0x2021,
+        0x02C6, 0x2030, 0x0160, 0x2039, 0x0152, 0x008D, 0x017D, 0x008F,
+        0x0090, 0x2018, 0x2019, 0x201C, 0x201D, 0x2022, 0x2013, 0x2014,
+        0x02DC, 0x2122, 0x0161, 0x203A, 0x0153, 0x009D, 0x017E, 0x0178,
+      ];
+
+      return {
+        handle(b) {
+          if (b >= 0x80 && b <= 0x9F) return table[b - 0x80];
+          return b;
+        },
+        flush() {
+          return false;
+        },
+      };
+    },
+  };
+
+  // https://encoding.spec.whatwg.org/#names-and-labels
+  const labels = {
+    'utf-8': [
+      'unicode-1-1-utf-8', 'unicode11utf8', 'unicode20utf8', 'utf-8', 'utf8',
+      'x-unicode20utf8',
+    ],
+    'utf-16le': [
+      'csunicode', 'iso-10646-ucs-2', 'ucs-2', 'unicode', 'unicodefeff',
+      'utf-16', 'utf-16le',
+    ],
+    'windows-1252': [
+      'ansi_x3.4-1968', 'ascii', 'cp1252', 'cp819', 'csisolatin1', 'ibm819',
+      'iso-8859-1', 'iso-ir-100', 'iso8859-1', 'iso88591', 'iso_8859-1',
+      'iso_8859-1:1987', 'l1', 'latin1', 'us-ascii', 'windows-1252',
+      'x-cp1252',
+    ],
+  };
+
+  // https://encoding.spec.whatwg.org/#concept-encoding-get
+  const getEncoding = function (label) {
+    label = String(label).trim().toLowerCase();
+    for (const name of Object.keys(labels)) {
+      if (labels[name].includes(label)) return name;
+    }
+    return null;
+  };
+
+  const toBytes = function (input) {
+    if (input === undefined) return new Uint8Array(0);
+    if (input instanceof ArrayBuffer) return new Uint8Array(input);
+    if (ArrayBuffer.isView(input)) {
+      return new Uint8Array(input.buffer, input.byteOffset, input.byteLength);
+    }
+    throw new TypeError("Failed to execute 'decode' on 'TextDecoder': The provided value is not of type '(ArrayBuffer or ArrayBufferView)'.");
+  };
+
+  // https://encoding.spec.whatwg.org/#interface-textdecoder
+  class TextDecoder {
+    #encoding;
+    #fatal;
+    #ignoreBOM;
+    #decoder = null;
+    #bomSeen = false;
+    #doNotFlush = false;
+
+    constructor(label = 'utf-8', options = {}) {
+      const encoding = getEncoding(label);
+      if (encoding === null) {
+        throw new RangeError("Failed to construct 'TextDecoder': The encoding label provided ('" + label + "') is invalid.");
+      }
+
+      this.#encoding = encoding;
+      this.#fatal = !!(options && options.fatal);
+      this.#ignoreBOM = !!(options && options.ignoreBOM);
+    }
+
+    get encoding() {
+      return this.#encoding;
+    }
+
+    get fatal() {
+      return this.#fatal;
+    }
+
+    get ignoreBOM() {
+      return this.#ignoreBOM;
+    }
+
+    decode(input, options = {}) {
+      const bytes = toBytes(input);
+
+      if (!this.#doNotFlush) {
+        this.#decoder = decoders[this.#encoding]();
+        this.#bomSeen = false;
+      }
+      this.#doNotFlush = !!(options && options.stream);
+
+      const err = () => {
+        if (this.#fatal) {
+          throw new TypeError("Failed to execute 'decode' on 'TextDecoder': The encoded data was not valid.");
+        }
+        return replacement;
+      };
+
+      const decoder = this.#decoder;
+      let out = '';
+      const emit = function (cp) {
+        if (cp === null) return;
+        if (Array.isArray(cp)) cp.forEach(emit);
+        else out += String.fromCodePoint(cp);
+      };
+
+      for (let i = 0; i < bytes.length; i++) {
+        decoder.reprocess = false;
+        emit(decoder.handle(bytes[i], err));
+        if (decoder.reprocess) i--;
+      }
+
+      if (!this.#doNotFlush && decoder.flush()) emit(err());
+
+      // https://encoding.spec.whatwg.org/#concept-td-serialize
+      if (!this.#ignoreBOM && !this.#bomSeen && out.length > 0 && this.#encoding !== 'windows-1252') {
+        this.#bomSeen = true;
+        if (out.charCodeAt(0) === 0xFEFF) out = out.slice(1);
+      }
+
+      return out;
+    }
+  }
+
+  globalThis.TextEncoder = TextEncoder;
+  globalThis.TextDecoder = TextDecoder;
+})();
@@ -0,0 +1,66 @@
+// Copyright (C) 2023-2024  Lightpanda (Selecy SAS)
+//
+// Francis Bouvier <francis@lightpanda.io>
+// Pierre Tachoire <pierre@lightpanda.io>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+const std = @import("std");
+
+const jsruntime = @import("jsruntime");
+const Case = jsruntime.test_utils.Case;
+const checkCases = jsruntime.test_utils.checkCases;
+
+// Tests of the TextEncoder and TextDecoder polyfill, see encoding.js.
+
+pub fn testExecFn(
+    _: std.mem.Allocator,
+    js_env: *jsruntime.Env,
+) anyerror!void {
+    var encoding = [_]Case{
+        .{ .src = "let enc = new TextEncoder()", .ex = "undefined" },
+        .{ .src = "enc.encoding", .ex = "utf-8" },
+        .{ .src = "enc.encode('aé€😀').join(',')", .ex = "97,195,169,226,130,172,240,159,152,128" },
+        .{ .src = "enc.encode('\\ud800').join(',')", .ex = "239,191,189" },
+        .{ .src = "enc.encode() instanceof Uint8Array", .ex = "true" },
+        .{ .src = "let encbuf = new Uint8Array(4)", .ex = "undefined" },
+        .{ .src = "let encres = enc.encodeInto('aé€', encbuf)", .ex = "undefined" },
+        .{ .src = "encres.read + ',' + encres.written", .ex = "2,3" },
+        .{ .src = "encbuf.join(',')", .ex = "97,195,169,0" },
+
+        .{ .src = "let dec = new TextDecoder()", .ex = "undefined" },
+        .{ .src = "dec.encoding", .ex = "utf-8" },
+        .{ .src = "dec.decode(enc.encode('aé€😀'))", .ex = "aé€😀" },
+        .{ .src = "dec.decode(new Uint8Array([0xEF, 0xBB, 0xBF, 0x61]).buffer)", .ex = "a" },
+        .{ .src = "new TextDecoder('utf8', { ignoreBOM: true }).decode(new Uint8Array([0xEF, 0xBB, 0xBF, 0x61])).length", .ex = "2" },
+        .{ .src = "dec.decode(new Uint8Array([0x61, 0xFF, 0x62]))", .ex = "a\u{FFFD}b" },
+        .{ .src = "dec.decode(new Uint8Array([0xE2, 0x82]))", .ex = "\u{FFFD}" },
+        .{ .src = "var err; try { new TextDecoder('utf-8', { fatal: true }).decode(new Uint8Array([0xFF])) } catch (e) { err = e } err instanceof TypeError", .ex = "true" },
+        // streaming doesn't split the multi-bytes characters.
+        .{ .src = "dec.decode(new Uint8Array([0x61, 0xE2, 0x82]), { stream: true })", .ex = "a" },
+        .{ .src = "dec.decode(new Uint8Array([0xAC, 0x62]))", .ex = "€b" },
+
+        .{ .src = "let dec16 = new TextDecoder('utf-16le')", .ex = "undefined" },
+        .{ .src = "dec16.encoding", .ex = "utf-16le" },
+        .{ .src = "dec16.decode(new Uint8Array([0x61, 0x00, 0x3D, 0xD8, 0x00, 0xDE]))", .ex = "a😀" },
+        .{ .src = "dec16.decode(new Uint8Array([0x61, 0x00, 0x3D]), { stream: true })", .ex = "a" },
+        .{ .src = "dec16.decode(new Uint8Array([0xD8, 0x00, 0xDE]))", .ex = "😀" },
+
+        .{ .src = "let declatin = new TextDecoder('iso-8859-1')", .ex = "undefined" },
+        .{ .src = "declatin.encoding", .ex = "windows-1252" },
+        .{ .src = "declatin.decode(new Uint8Array([0x61, 0xE9, 0x80]))", .ex = "aé€" },
+        .{ .src = "try { new TextDecoder('foo') } catch (e) { err = e } err instanceof RangeError", .ex = "true" },
+    };
+    try checkCases(js_env, &encoding);
+}
@@ -0,0 +1,49 @@
+// The native addEventListener and removeEventListener can't take an argument
+// being either a boolean or an options dictionary. The options are flattened
+// here into the capture, once, passive and signal native arguments.
+// see https://github.com/lightpanda-io/jsruntime-lib/issues/114
+// https://dom.spec.whatwg.org/#concept-flatten-options
+(function () {
+  if (typeof EventTarget !== 'function') return;
+
+  function flatten(options) {
+    if (typeof options === 'object' && options !== null) return options;
+    return { capture: options };
+  }
+
+  const targets = [EventTarget.prototype];
+  // the global object can hold its own copy of the native methods.
+  if (Object.prototype.hasOwnProperty.call(globalThis, 'addEventListener')) targets.push(globalThis);
+
+  for (const target of targets) {
+    const nativeAdd = target.addEventListener;
+    const nativeRemove = target.removeEventListener;
+
+    Object.defineProperty(target, 'addEventListener', {
+      value: function addEventListener(type, listener, options) {
+        // a null callback is ignored.
+        if (listener === null || listener === undefined) return;
+
+        const opts = flatten(options);
+        const signal = opts.signal;
+        if (signal !== undefined && !(signal instanceof AbortSignal)) {
+          throw new TypeError("Failed to execute 'addEventListener' on 'EventTarget': member signal is not of type 'AbortSignal'.");
+        }
+        return nativeAdd.call(this, String(type), listener, !!opts.capture, !!opts.once, !!opts.passive, signal ?? null);
+      },
+      writable: true,
+      enumerable: true,
+      configurable: true,
+    });
+
+    Object.defineProperty(target, 'removeEventListener', {
+      value: function removeEventListener(type, listener, options) {
+        if (listener === null || listener === undefined) return;
+        return nativeRemove.call(this, String(type), listener, !!flatten(options).capture);
+      },
+      writable: true,
+      enumerable: true,
+      configurable: true,
+    });
+  }
+})();
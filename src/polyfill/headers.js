@@ -0,0 +1,136 @@
+// Headers is implemented in JS because its constructor takes either a
+// sequence of name/value pairs or a record, which the native bindings can't
+// convert.
+// https://fetch.spec.whatwg.org/#headers-class
+if (typeof globalThis.Headers !== 'function') {
+  (function () {
+    const token = /^[!#$%&'*+\-.^_`|~0-9A-Za-z]+$/;
+    const invalidValue = /[\0\r\n]/;
+
+    // https://fetch.spec.whatwg.org/#concept-header-value-normalize
+    const normalize = function (value) {
+      return String(value).replace(/^[\t\n\r ]+|[\t\n\r ]+$/g, '');
+    };
+
+    const validName = function (name) {
+      name = String(name);
+      if (!token.test(name)) {
+        throw new TypeError("Failed to execute on 'Headers': Invalid name");
+      }
+      return name.toLowerCase();
+    };
+
+    const validValue = function (value) {
+      value = normalize(value);
+      if (invalidValue.test(value)) {
+        throw new TypeError("Failed to execute on 'Headers': Invalid value");
+      }
+      return value;
+    };
+
+    globalThis.Headers = class Headers {
+      // list contains the [lowercase name, value] pairs in insertion order.
+      #list = [];
+
+      constructor(init) {
+        if (init === undefined || init === null) return;
+
+        if (init instanceof Headers) {
+          for (const [name, value] of init.#list) this.#list.push([name, value]);
+          return;
+        }
+
+        if (typeof init !== 'object') {
+          throw new TypeError("Failed to construct 'Headers': The provided value is not of type '(record<ByteString, ByteString> or sequence<sequence<ByteString>>)'.");
+        }
+
+        if (typeof init[Symbol.iterator] === 'function') {
+          for (const pair of init) {
+            const p = Array.from(pair);
+            if (p.length !== 2) {
+              throw new TypeError("Failed to construct 'Headers': Invalid value");
+            }
+            this.append(p[0], p[1]);
+          }
+          return;
+        }
+
+        for (const name of Object.keys(init)) this.append(name, init[name]);
+      }
+
+      append(name, value) {
+        this.#list.push([validName(name), validValue(value)]);
+      }
+
+      delete(name) {
+        name = validName(name);
+        this.#list = this.#list.filter((h) => h[0] !== name);
+      }
+
+      // get returns the values of the header combined with ", ".
+      get(name) {
+        name = validName(name);
+        const values = this.#list.filter((h) => h[0] === name).map((h) => h[1]);
+        return values.length === 0 ? null : values.join(', ');
+      }
+
+      getSetCookie() {
+        return this.#list.filter((h) => h[0] === 'set-cookie').map((h) => h[1]);
+      }
+
+      has(name) {
+        name = validName(name);
+        return this.#list.some((h) => h[0] === name);
+      }
+
+      // set replaces the first header with the same name and removes the
+      // others.
+      set(name, value) {
+        name = validName(name);
+        value = validValue(value);
+
+        const i = this.#list.findIndex((h) => h[0] === name);
+        if (i < 0) {
+          this.#list.push([name, value]);
+          return;
+        }
+        this.#list[i][1] = value;
+        this.#list = this.#list.filter((h, j) => j <= i || h[0] !== name);
+      }
+
+      forEach(callback, thisArg) {
+        for (const [name, value] of this.entries()) {
+          callback.call(thisArg, value, name, this);
+        }
+      }
+
+      // https://fetch.spec.whatwg.org/#concept-header-list-sort-and-combine
+      * entries() {
+        const names = [...new Set(this.#list.map((h) => h[0]))].sort();
+        for (const name of names) {
+          if (name === 'set-cookie') {
+            for (const value of this.getSetCookie()) yield [name, value];
+            continue;
+          }
+          yield [name, this.get(name)];
+        }
+      }
+
+      * keys() {
+        for (const [name] of this.entries()) yield name;
+      }
+
+      * values() {
+        for (const [, value] of this.entries()) yield value;
+      }
+
+      [Symbol.iterator]() {
+        return this.entries();
+      }
+
+      get [Symbol.toStringTag]() {
+        return 'Headers';
+      }
+    };
+  })();
+}
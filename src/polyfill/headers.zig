@@ -0,0 +1,54 @@
+// Copyright (C) 2023-2024  Lightpanda (Selecy SAS)
+//
+// Francis Bouvier <francis@lightpanda.io>
+// Pierre Tachoire <pierre@lightpanda.io>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+const std = @import("std");
+
+const jsruntime = @import("jsruntime");
+const Case = jsruntime.test_utils.Case;
+const checkCases = jsruntime.test_utils.checkCases;
+
+// Tests of the Headers polyfill, see headers.js.
+
+pub fn testExecFn(
+    _: std.mem.Allocator,
+    js_env: *jsruntime.Env,
+) anyerror!void {
+    var headers = [_]Case{
+        .{ .src = "let hdrs = new Headers({ 'Content-Type': 'text/html', 'X-Foo': ' bar ' })", .ex = "undefined" },
+        .{ .src = "hdrs.get('content-type')", .ex = "text/html" },
+        .{ .src = "hdrs.get('x-foo')", .ex = "bar" },
+        .{ .src = "hdrs.has('CONTENT-TYPE')", .ex = "true" },
+        .{ .src = "hdrs.get('unknown')", .ex = "null" },
+        .{ .src = "hdrs.append('X-Foo', 'baz')", .ex = "undefined" },
+        .{ .src = "hdrs.get('X-FOO')", .ex = "bar, baz" },
+        .{ .src = "hdrs.set('x-foo', 'qux')", .ex = "undefined" },
+        .{ .src = "hdrs.get('x-foo')", .ex = "qux" },
+        .{ .src = "hdrs.delete('x-foo')", .ex = "undefined" },
+        .{ .src = "hdrs.has('x-foo')", .ex = "false" },
+        .{ .src = "hdrs = new Headers([['b', '2'], ['A', '1'], ['b', '3'], ['Set-Cookie', 'x=1'], ['set-cookie', 'y=2']])", .ex = "[object Headers]" },
+        .{ .src = "Array.from(hdrs.keys()).join(',')", .ex = "a,b,set-cookie,set-cookie" },
+        .{ .src = "Array.from(hdrs.values()).join('|')", .ex = "1|2, 3|x=1|y=2" },
+        .{ .src = "Array.from(hdrs).length", .ex = "4" },
+        .{ .src = "hdrs.getSetCookie().join(',')", .ex = "x=1,y=2" },
+        .{ .src = "var hres = []; hdrs.forEach((v, k) => hres.push(k + '=' + v)); hres.join(';')", .ex = "a=1;b=2, 3;set-cookie=x=1;set-cookie=y=2" },
+        .{ .src = "new Headers(hdrs).get('b')", .ex = "2, 3" },
+        .{ .src = "try { new Headers([['a']]) } catch (e) { e instanceof TypeError }", .ex = "true" },
+        .{ .src = "try { hdrs.append('in valid', 'x') } catch (e) { e instanceof TypeError }", .ex = "true" },
+    };
+    try checkCases(js_env, &headers);
+}
@@ -0,0 +1,88 @@
+// The native History stores the state as a string and can't dispatch an event
+// carrying a JS value. The state is serialized here with JSON, as an
+// approximation of the structured clone, and the popstate event is fired once
+// the history traversal is done.
+// https://html.spec.whatwg.org/multipage/nav-history-apis.html#the-history-interface
+(function () {
+  if (typeof History !== 'function') {
+    return;
+  }
+
+  if (typeof globalThis.PopStateEvent !== 'function') {
+    globalThis.PopStateEvent = class PopStateEvent extends Event {
+      #state = null;
+
+      constructor(type, eventInitDict) {
+        if (arguments.length < 1) {
+          throw new TypeError("Failed to construct 'PopStateEvent': 1 argument required, but only 0 present.");
+        }
+        const init = eventInitDict ?? {};
+        super(type, { bubbles: !!init.bubbles, cancelable: !!init.cancelable });
+        if (init.state !== undefined) this.#state = init.state;
+      }
+
+      get state() {
+        return this.#state;
+      }
+    };
+  }
+
+  const proto = History.prototype;
+  const stateDesc = Object.getOwnPropertyDescriptor(proto, 'state');
+  const pushState = proto.pushState;
+  const replaceState = proto.replaceState;
+  const go = proto.go;
+
+  // serialize returns null if the state can't be serialized, the native
+  // functions throw a DataCloneError DOMException then.
+  const serialize = function (state) {
+    try {
+      return JSON.stringify(state === undefined ? null : state) ?? null;
+    } catch (e) {
+      return null;
+    }
+  };
+
+  const url = function (u) {
+    return u === undefined || u === null ? null : String(u);
+  };
+
+  // cache keeps the deserialized state, ie. history.state === history.state.
+  let cache = { src: null, state: null };
+
+  Object.defineProperty(proto, 'state', {
+    get() {
+      const src = stateDesc.get.call(this);
+      if (src === null) return null;
+      if (cache.src !== src) cache = { src: src, state: JSON.parse(src) };
+      return cache.state;
+    },
+    enumerable: stateDesc.enumerable,
+    configurable: true,
+  });
+
+  proto.pushState = function (state, title, u) {
+    pushState.call(this, serialize(state), String(title ?? ''), url(u));
+  };
+
+  proto.replaceState = function (state, title, u) {
+    replaceState.call(this, serialize(state), String(title ?? ''), url(u));
+  };
+
+  proto.go = function (delta) {
+    if (!go.call(this, Math.trunc(Number(delta ?? 0)) || 0)) return;
+
+    const state = this.state;
+    queueMicrotask(() => {
+      globalThis.dispatchEvent(new PopStateEvent('popstate', { state: state }));
+    });
+  };
+
+  proto.back = function () {
+    this.go(-1);
+  };
+
+  proto.forward = function () {
+    this.go(1);
+  };
+})();
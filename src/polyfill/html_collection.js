@@ -0,0 +1,30 @@
+// The native bindings can't intercept the indexed and named properties of
+// an HTMLCollection, which must stay live. A Proxy is inserted at the end of
+// the collections' prototype chain: the lookups missing the prototypes reach
+// it and are resolved with the live item and namedItem.
+// https://dom.spec.whatwg.org/#interface-htmlcollection
+(function () {
+  if (typeof HTMLCollection !== 'function') return;
+
+  const proto = HTMLCollection.prototype;
+  const parent = Object.getPrototypeOf(proto);
+  if (parent === null || typeof proto.item !== 'function') return;
+
+  const index = /^(?:0|[1-9][0-9]*)$/;
+
+  Object.setPrototypeOf(proto, new Proxy(parent, {
+    get(target, key, receiver) {
+      if (typeof key !== 'string') return Reflect.get(target, key, receiver);
+      const indexed = index.test(key) && Number(key) < 0xffffffff;
+      if (!indexed && key in target) return Reflect.get(target, key, receiver);
+
+      // the receiver may not be a native collection, ie. the prototype.
+      try {
+        const res = indexed ? proto.item.call(receiver, Number(key)) : proto.namedItem.call(receiver, key);
+        return res ?? undefined;
+      } catch (e) {
+        return undefined;
+      }
+    },
+  }));
+})();
@@ -0,0 +1,157 @@
+// IntersectionObserver is implemented in JS because its callback and its
+// entries are JS values, which the native bindings can't hold.
+// There is no layout, so every observed target is considered as fully
+// visible: observe queues an entry with isIntersecting true and
+// intersectionRatio 1, delivered to the callback on a microtask.
+// The targets are never reported again since nothing moves.
+// https://w3c.github.io/IntersectionObserver/
+(function () {
+  if (typeof globalThis.IntersectionObserver === 'function') return;
+
+  const lengths = /^(-?(?:\d+|\d*\.\d+))(px|%)$/;
+
+  // parseMargin returns the four margins of a rootMargin string.
+  // https://w3c.github.io/IntersectionObserver/#parse-a-margin
+  const parseMargin = function (margin) {
+    const tokens = String(margin).trim().split(/\s+/).filter((t) => t !== '');
+    if (tokens.length === 0) tokens.push('0px');
+    if (tokens.length > 4) {
+      throw new SyntaxError("Failed to construct 'IntersectionObserver': Extra text found at the end of rootMargin.");
+    }
+
+    const values = tokens.map((t) => {
+      const m = lengths.exec(t);
+      if (m === null) {
+        throw new SyntaxError("Failed to construct 'IntersectionObserver': rootMargin must be specified in pixels or percent.");
+      }
+      return (Number(m[1]) === 0 ? '0' : m[1]) + m[2];
+    });
+
+    // top, right, bottom and left, like the CSS margins: the bottom defaults
+    // to the top and the left to the right.
+    while (values.length < 4) values.push(values[values.length === 3 ? 1 : 0]);
+    return values.join(' ');
+  };
+
+  // parseThresholds returns the sorted list of thresholds.
+  const parseThresholds = function (threshold) {
+    const list = Array.isArray(threshold) ? threshold : [threshold ?? 0];
+    const res = list.map((t) => {
+      const n = Number(t);
+      if (Number.isNaN(n) || n < 0 || n > 1) {
+        throw new RangeError("Failed to construct 'IntersectionObserver': Threshold values must be numbers between 0 and 1");
+      }
+      return n;
+    });
+    if (res.length === 0) res.push(0);
+    return Object.freeze(res.sort((a, b) => a - b));
+  };
+
+  const now = function () {
+    return typeof performance === 'object' ? performance.now() : 0;
+  };
+
+  globalThis.IntersectionObserverEntry = class IntersectionObserverEntry {
+    #init;
+
+    constructor(init) {
+      this.#init = Object.assign({
+        time: 0,
+        rootBounds: null,
+        boundingClientRect: new DOMRectReadOnly(),
+        intersectionRect: new DOMRectReadOnly(),
+        isIntersecting: false,
+        intersectionRatio: 0,
+        target: null,
+      }, init);
+    }
+
+    get time() { return this.#init.time; }
+    get rootBounds() { return this.#init.rootBounds; }
+    get boundingClientRect() { return this.#init.boundingClientRect; }
+    get intersectionRect() { return this.#init.intersectionRect; }
+    get isIntersecting() { return this.#init.isIntersecting; }
+    get intersectionRatio() { return this.#init.intersectionRatio; }
+    get target() { return this.#init.target; }
+  };
+
+  globalThis.IntersectionObserver = class IntersectionObserver {
+    #callback;
+    #root;
+    #rootMargin;
+    #thresholds;
+    #targets = [];
+    #records = [];
+    #pending = false;
+
+    constructor(callback, options) {
+      if (typeof callback !== 'function') {
+        throw new TypeError("Failed to construct 'IntersectionObserver': parameter 1 is not of type 'Function'.");
+      }
+
+      const opts = options ?? {};
+      const root = opts.root ?? null;
+      if (root !== null && !(root instanceof Element) && !(root instanceof Document)) {
+        throw new TypeError("Failed to construct 'IntersectionObserver': Failed to read the 'root' property from 'IntersectionObserverInit': The provided value is not of type '(Document or Element)'.");
+      }
+
+      this.#callback = callback;
+      this.#root = root;
+      this.#rootMargin = parseMargin(opts.rootMargin ?? '0px');
+      this.#thresholds = parseThresholds(opts.threshold);
+    }
+
+    get root() { return this.#root; }
+    get rootMargin() { return this.#rootMargin; }
+    get thresholds() { return this.#thresholds; }
+
+    observe(target) {
+      if (!(target instanceof Element)) {
+        throw new TypeError("Failed to execute 'observe' on 'IntersectionObserver': parameter 1 is not of type 'Element'.");
+      }
+      if (this.#targets.includes(target)) return;
+      this.#targets.push(target);
+
+      const rect = target.getBoundingClientRect();
+      this.#records.push(new IntersectionObserverEntry({
+        time: now(),
+        rootBounds: this.#root instanceof Element ? this.#root.getBoundingClientRect() : null,
+        boundingClientRect: rect,
+        intersectionRect: rect,
+        isIntersecting: true,
+        intersectionRatio: 1,
+        target: target,
+      }));
+      this.#notify();
+    }
+
+    unobserve(target) {
+      this.#targets = this.#targets.filter((t) => t !== target);
+      this.#records = this.#records.filter((r) => r.target !== target);
+    }
+
+    disconnect() {
+      this.#targets = [];
+      this.#records = [];
+    }
+
+    takeRecords() {
+      const records = this.#records;
+      this.#records = [];
+      return records;
+    }
+
+    // notify delivers the queued entries to the callback on a microtask.
+    #notify() {
+      if (this.#pending) return;
+      this.#pending = true;
+
+      queueMicrotask(() => {
+        this.#pending = false;
+        const records = this.takeRecords();
+        if (records.length === 0) return;
+        this.#callback.call(this, records, this);
+      });
+    }
+  };
+})();
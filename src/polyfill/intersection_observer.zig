@@ -0,0 +1,57 @@
+// Copyright (C) 2023-2024  Lightpanda (Selecy SAS)
+//
+// Francis Bouvier <francis@lightpanda.io>
+// Pierre Tachoire <pierre@lightpanda.io>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+const std = @import("std");
+
+const jsruntime = @import("jsruntime");
+const Case = jsruntime.test_utils.Case;
+const checkCases = jsruntime.test_utils.checkCases;
+
+// Tests of the IntersectionObserver polyfill, see intersection_observer.js.
+
+pub fn testExecFn(
+    _: std.mem.Allocator,
+    js_env: *jsruntime.Env,
+) anyerror!void {
+    var intersection_observer = [_]Case{
+        .{ .src = "let ioentries = []; let iocalls = 0", .ex = "undefined" },
+        .{ .src = "let io = new IntersectionObserver((entries, obs) => { iocalls++; ioentries = ioentries.concat(entries); }, { rootMargin: '10px 5%', threshold: [1, 0.5] })", .ex = "undefined" },
+        .{ .src = "io.rootMargin", .ex = "10px 5% 10px 5%" },
+        .{ .src = "io.thresholds.join(',')", .ex = "0.5,1" },
+        .{ .src = "io.root", .ex = "null" },
+        .{ .src = "io.observe(document.getElementById('para')); io.observe(document.getElementById('link')); io.observe(document.getElementById('link'))", .ex = "undefined" },
+        .{ .src = "iocalls", .ex = "1" },
+        .{ .src = "ioentries.length", .ex = "2" },
+        .{ .src = "ioentries[0].target.id", .ex = "para" },
+        .{ .src = "ioentries[0].isIntersecting", .ex = "true" },
+        .{ .src = "ioentries[0].intersectionRatio", .ex = "1" },
+        .{ .src = "ioentries[0].boundingClientRect instanceof DOMRectReadOnly", .ex = "true" },
+        .{ .src = "typeof ioentries[0].time", .ex = "number" },
+        .{ .src = "ioentries[0] instanceof IntersectionObserverEntry", .ex = "true" },
+
+        .{ .src = "io.observe(document.getElementById('content')); io.takeRecords().length", .ex = "1" },
+        .{ .src = "io.observe(document.getElementById('para-empty')); io.unobserve(document.getElementById('para-empty'))", .ex = "undefined" },
+        .{ .src = "io.disconnect()", .ex = "undefined" },
+        .{ .src = "iocalls", .ex = "1" },
+
+        .{ .src = "var err; try { new IntersectionObserver(() => {}, { threshold: 2 }) } catch (e) { err = e } err instanceof RangeError", .ex = "true" },
+        .{ .src = "try { new IntersectionObserver(() => {}, { rootMargin: '10em' }) } catch (e) { err = e } err.name", .ex = "SyntaxError" },
+        .{ .src = "try { io.observe('foo') } catch (e) { err = e } err instanceof TypeError", .ex = "true" },
+    };
+    try checkCases(js_env, &intersection_observer);
+}
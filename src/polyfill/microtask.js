@@ -0,0 +1,61 @@
+// queueMicrotask is not provided by v8 but by the embedder.
+// Scheduling a promise reaction job queues the callback into the v8
+// microtask queue, after the previously queued promise reactions.
+// https://html.spec.whatwg.org/multipage/timers-and-user-prompts.html#microtask-queuing
+(function () {
+  // ErrorEvent carries the reported exception, which the native Event can't
+  // hold.
+  // https://html.spec.whatwg.org/multipage/webappapis.html#errorevent
+  if (typeof globalThis.ErrorEvent !== 'function') {
+    globalThis.ErrorEvent = class ErrorEvent extends Event {
+      #init;
+
+      constructor(type, eventInitDict) {
+        if (arguments.length < 1) {
+          throw new TypeError("Failed to construct 'ErrorEvent': 1 argument required, but only 0 present.");
+        }
+        const init = eventInitDict ?? {};
+        super(type, { bubbles: !!init.bubbles, cancelable: !!init.cancelable });
+        this.#init = init;
+      }
+
+      get message() { return String(this.#init.message ?? ''); }
+      get filename() { return String(this.#init.filename ?? ''); }
+      get lineno() { return this.#init.lineno >>> 0; }
+      get colno() { return this.#init.colno >>> 0; }
+      get error() { return this.#init.error; }
+    };
+  }
+
+  // reportException fires an error event on the window, after calling the
+  // onerror handler which cancels the event by returning true.
+  // Uncanceled exceptions are logged.
+  // https://html.spec.whatwg.org/multipage/webappapis.html#report-an-exception
+  function reportException(e) {
+    const message = e instanceof Error ? e.message : String(e);
+    const evt = new ErrorEvent('error', { cancelable: true, message: message, error: e });
+
+    let canceled = false;
+    if (typeof globalThis.onerror === 'function') {
+      try {
+        canceled = globalThis.onerror.call(globalThis, message, '', 0, 0, e) === true;
+      } catch (err) {
+        console.error('Uncaught', err);
+      }
+    }
+    if (globalThis.dispatchEvent(evt) === false) canceled = true;
+    if (!canceled) console.error('Uncaught', e);
+  }
+
+  if (typeof globalThis.queueMicrotask !== 'function') {
+    globalThis.queueMicrotask = function queueMicrotask(callback) {
+      if (typeof callback !== 'function') {
+        throw new TypeError("Failed to execute 'queueMicrotask': parameter 1 is not of type 'Function'.");
+      }
+
+      // report the exception instead of swallowing it into a rejected
+      // promise.
+      Promise.resolve().then(() => callback()).catch(reportException);
+    };
+  }
+})();
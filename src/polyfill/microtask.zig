@@ -0,0 +1,52 @@
+// Copyright (C) 2023-2024  Lightpanda (Selecy SAS)
+//
+// Francis Bouvier <francis@lightpanda.io>
+// Pierre Tachoire <pierre@lightpanda.io>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+const std = @import("std");
+
+const jsruntime = @import("jsruntime");
+const Case = jsruntime.test_utils.Case;
+const checkCases = jsruntime.test_utils.checkCases;
+
+// Tests of the queueMicrotask polyfill, see microtask.js.
+
+pub fn testExecFn(
+    _: std.mem.Allocator,
+    js_env: *jsruntime.Env,
+) anyerror!void {
+    var microtask = [_]Case{
+        .{ .src = "typeof queueMicrotask", .ex = "function" },
+        .{ .src = 
+        \\var order = [];
+        \\Promise.resolve().then(() => order.push('promise'));
+        \\queueMicrotask(() => order.push('microtask'));
+        \\order.push('sync');
+        \\order.length;
+        , .ex = "1" },
+        .{ .src = "order.join(',')", .ex = "sync,promise,microtask" },
+        .{ .src = "try { queueMicrotask(1) } catch (e) { e instanceof TypeError }", .ex = "true" },
+
+        // the exceptions are reported on the window.
+        .{ .src = "let mterrs = []; window.addEventListener('error', (e) => mterrs.push(e instanceof ErrorEvent, e.message, e.error.name))", .ex = "undefined" },
+        .{ .src = "window.onerror = (msg) => { mterrs.push('onerror', msg) }; true", .ex = "true" },
+        .{ .src = "queueMicrotask(() => { throw new RangeError('foo') })", .ex = "undefined" },
+        .{ .src = "mterrs.join(',')", .ex = "onerror,foo,true,foo,RangeError" },
+        .{ .src = "window.onerror = null", .ex = "null" },
+        .{ .src = "new ErrorEvent('error', { message: 'bar', lineno: 2 }).lineno", .ex = "2" },
+    };
+    try checkCases(js_env, &microtask);
+}
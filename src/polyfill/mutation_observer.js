@@ -0,0 +1,36 @@
+// The native MutationObserver queues the mutation records and calls the
+// function given to its constructor each time its queue becomes non-empty.
+// This wrapper delivers the queued records to the user's callback on a
+// microtask, batching all the mutations of the current task.
+// https://dom.spec.whatwg.org/#queue-a-mutation-observer-compound-microtask
+(function () {
+  const Native = globalThis.MutationObserver;
+  if (typeof Native !== 'function') {
+    return;
+  }
+
+  globalThis.MutationObserver = class MutationObserver extends Native {
+    constructor(callback) {
+      if (typeof callback !== 'function') {
+        throw new TypeError("Failed to construct 'MutationObserver': parameter 1 is not of type 'Function'.");
+      }
+
+      let pending = false;
+      super(() => {
+        if (pending) return;
+        pending = true;
+
+        queueMicrotask(() => {
+          pending = false;
+          const records = this.takeRecords();
+          if (records.length === 0) return;
+          callback.call(this, records, this);
+        });
+      });
+    }
+
+    takeRecords() {
+      return Array.from(super.takeRecords());
+    }
+  };
+})();
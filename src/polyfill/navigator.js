@@ -0,0 +1,101 @@
+// navigator.languages and navigator.userAgentData return JS arrays and
+// objects, which are not supported by the native bindings. They are built
+// from the native navigator's values.
+// The data given to navigator.sendBeacon is converted by body.js.
+// https://html.spec.whatwg.org/multipage/system-state.html#dom-navigator-languages
+// https://wicg.github.io/ua-client-hints/#navigatoruadata
+(function () {
+  if (typeof Navigator !== 'function') {
+    return;
+  }
+
+  const proto = Navigator.prototype;
+
+  if (!('languages' in proto)) {
+    // cache keeps the same frozen array while the language is unchanged, ie.
+    // navigator.languages === navigator.languages.
+    let cache = { lang: null, list: null };
+
+    Object.defineProperty(proto, 'languages', {
+      get() {
+        const lang = this.language;
+        if (cache.lang !== lang) {
+          const list = [lang];
+          const primary = lang.split('-')[0];
+          if (primary !== lang) list.push(primary);
+          cache = { lang: lang, list: Object.freeze(list) };
+        }
+        return cache.list;
+      },
+      enumerable: true,
+      configurable: true,
+    });
+  }
+
+  if (!('userAgentData' in proto)) {
+    // brands returns the brands consistent with the user agent string, ie.
+    // Lightpanda.io/1.0 gives the Lightpanda brand with the major version 1.
+    const brands = function (ua) {
+      const m = /^([A-Za-z]+)[^/]*\/(\d+)/.exec(ua);
+      if (m === null) return [];
+      return [Object.freeze({ brand: m[1], version: m[2] })];
+    };
+
+    // platform returns the platform brand from the navigator.platform value.
+    const platform = function (p) {
+      if (p.startsWith('Mac')) return 'macOS';
+      if (p.startsWith('Win')) return 'Windows';
+      if (p.startsWith('Linux')) return 'Linux';
+      return '';
+    };
+
+    class NavigatorUAData {
+      #brands;
+      #platform;
+
+      constructor(nav) {
+        this.#brands = Object.freeze(brands(nav.userAgent));
+        this.#platform = platform(nav.platform);
+      }
+
+      get brands() {
+        return this.#brands;
+      }
+
+      get mobile() {
+        return false;
+      }
+
+      get platform() {
+        return this.#platform;
+      }
+
+      getHighEntropyValues(hints) {
+        const res = { brands: this.brands, mobile: this.mobile, platform: this.platform };
+        for (const h of hints ?? []) {
+          if (h === 'fullVersionList') res.fullVersionList = this.brands;
+        }
+        return Promise.resolve(res);
+      }
+
+      toJSON() {
+        return { brands: this.brands, mobile: this.mobile, platform: this.platform };
+      }
+    }
+
+    const data = new WeakMap();
+
+    Object.defineProperty(proto, 'userAgentData', {
+      get() {
+        let d = data.get(this);
+        if (d === undefined) {
+          d = new NavigatorUAData(this);
+          data.set(this, d);
+        }
+        return d;
+      },
+      enumerable: true,
+      configurable: true,
+    });
+  }
+})();
@@ -0,0 +1,32 @@
+// The ParentNode's append, prepend and replaceChildren accept either nodes or
+// strings. The native bindings can't convert an argument into one or the
+// other, so the strings are converted here into text nodes before calling the
+// native functions.
+// blocked by https://github.com/lightpanda-io/jsruntime-lib/issues/114
+// https://dom.spec.whatwg.org/#interface-parentnode
+(function () {
+  if (typeof Node !== 'function') return;
+
+  const protos = [Element, Document, DocumentFragment]
+    .filter((i) => typeof i === 'function')
+    .map((i) => i.prototype);
+
+  for (const proto of protos) {
+    for (const name of ['append', 'prepend', 'replaceChildren']) {
+      const native = proto[name];
+      if (typeof native !== 'function') continue;
+
+      Object.defineProperty(proto, name, {
+        value: function (...nodes) {
+          const doc = this.ownerDocument ?? this;
+          return native.apply(this, nodes.map((n) => {
+            return n instanceof Node ? n : doc.createTextNode(String(n));
+          }));
+        },
+        writable: true,
+        enumerable: true,
+        configurable: true,
+      });
+    }
+  }
+})();
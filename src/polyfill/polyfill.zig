@@ -0,0 +1,68 @@
+// Copyright (C) 2023-2024  Lightpanda (Selecy SAS)
+//
+// Francis Bouvier <francis@lightpanda.io>
+// Pierre Tachoire <pierre@lightpanda.io>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+const std = @import("std");
+
+const jsruntime = @import("jsruntime");
+const Env = jsruntime.Env;
+
+const log = std.log.scoped(.polyfill);
+
+// Polyfills are JS implementations of web APIs which can't be implemented
+// natively for now. They are evaluated once the global object is bound.
+const modules = [_]struct {
+    name: []const u8,
+    source: []const u8,
+}{
+    .{ .name = "polyfill-event-target", .source = @embedFile("event_target.js") },
+    .{ .name = "polyfill-microtask", .source = @embedFile("microtask.js") },
+    .{ .name = "polyfill-dataset", .source = @embedFile("dataset.js") },
+    .{ .name = "polyfill-custom-event", .source = @embedFile("custom_event.js") },
+    .{ .name = "polyfill-mutation-observer", .source = @embedFile("mutation_observer.js") },
+    .{ .name = "polyfill-html-collection", .source = @embedFile("html_collection.js") },
+    .{ .name = "polyfill-headers", .source = @embedFile("headers.js") },
+    .{ .name = "polyfill-console", .source = @embedFile("console.js") },
+    .{ .name = "polyfill-encoding", .source = @embedFile("encoding.js") },
+    .{ .name = "polyfill-crypto", .source = @embedFile("crypto.js") },
+    .{ .name = "polyfill-xhr", .source = @embedFile("xhr.js") },
+    .{ .name = "polyfill-history", .source = @embedFile("history.js") },
+    .{ .name = "polyfill-blob", .source = @embedFile("blob.js") },
+    .{ .name = "polyfill-request", .source = @embedFile("request.js") },
+    .{ .name = "polyfill-body", .source = @embedFile("body.js") },
+    .{ .name = "polyfill-parent-node", .source = @embedFile("parent_node.js") },
+    .{ .name = "polyfill-child-node", .source = @embedFile("child_node.js") },
+    .{ .name = "polyfill-timers", .source = @embedFile("timers.js") },
+    .{ .name = "polyfill-scroll", .source = @embedFile("scroll.js") },
+    .{ .name = "polyfill-intersection-observer", .source = @embedFile("intersection_observer.js") },
+    .{ .name = "polyfill-elements-from-point", .source = @embedFile("elements_from_point.js") },
+    .{ .name = "polyfill-validity", .source = @embedFile("validity.js") },
+    .{ .name = "polyfill-navigator", .source = @embedFile("navigator.js") },
+};
+
+pub fn load(alloc: std.mem.Allocator, env: *Env) !void {
+    for (modules) |m| {
+        var res = try env.execTryCatch(alloc, m.source, m.name);
+        defer res.deinit(alloc);
+
+        if (!res.success) {
+            log.err("load {s}: {s}", .{ m.name, res.result });
+            if (res.stack) |stack| log.debug("{s}", .{stack});
+            return error.JsErr;
+        }
+    }
+}
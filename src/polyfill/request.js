@@ -0,0 +1,229 @@
+// Request is implemented in JS because its body is kept in a typed array or
+// a Blob, which are not supported by the native bindings.
+// TODO fetch is not implemented yet, so a request can't be sent.
+// TODO expose the body as a ReadableStream.
+// https://fetch.spec.whatwg.org/#request-class
+(function () {
+  if (typeof globalThis.Request === 'function') return;
+
+  const forbiddenMethods = ['CONNECT', 'TRACE', 'TRACK'];
+  const normalizedMethods = ['DELETE', 'GET', 'HEAD', 'OPTIONS', 'POST', 'PUT'];
+  const token = /^[!#$%&'*+\-.^_`|~0-9A-Za-z]+$/;
+
+  const typeError = function (msg) {
+    return new TypeError("Failed to construct 'Request': " + msg);
+  };
+
+  // resolve returns the URL resolved against the document's URL.
+  const resolve = function (input) {
+    const base = globalThis.document?.URL ?? 'about:blank';
+    try {
+      return new URL(input, base).href;
+    } catch (e) {
+      throw typeError('Invalid URL');
+    }
+  };
+
+  const enumValue = function (name, value, values) {
+    if (values.includes(value)) return value;
+    throw typeError("The provided value '" + value + "' is not a valid enum value of type " + name + '.');
+  };
+
+  // https://fetch.spec.whatwg.org/#concept-method-normalize
+  const normalizeMethod = function (method) {
+    const m = String(method);
+    if (!token.test(m)) throw typeError("'" + m + "' is not a valid HTTP method.");
+
+    const upper = m.toUpperCase();
+    if (forbiddenMethods.includes(upper)) throw typeError("'" + m + "' HTTP method is unsupported.");
+    if (normalizedMethods.includes(upper)) return upper;
+    return m;
+  };
+
+  // escapeName escapes a name of the multipart/form-data encoding.
+  const escapeName = function (name) {
+    return String(name).replace(/\n/g, '%0A').replace(/\r/g, '%0D').replace(/"/g, '%22');
+  };
+
+  // multipart returns the form data entries encoded as a Blob.
+  // https://html.spec.whatwg.org/multipage/form-control-infrastructure.html#multipart/form-data-encoding-algorithm
+  const multipart = function (fd) {
+    const boundary = '----LightpandaFormBoundary' + crypto.randomUUID().replace(/-/g, '');
+    const parts = [];
+    for (const [name, value] of fd) {
+      parts.push('--' + boundary + '\r\nContent-Disposition: form-data; name="' + escapeName(name) + '"');
+      if (value instanceof Blob) {
+        const filename = typeof value.name === 'string' ? value.name : 'blob';
+        parts.push('; filename="' + escapeName(filename) + '"\r\nContent-Type: ' + (value.type || 'application/octet-stream') + '\r\n\r\n', value);
+      } else {
+        parts.push('\r\n\r\n' + String(value).replace(/\r\n|\r|\n/g, '\r\n'));
+      }
+      parts.push('\r\n');
+    }
+    parts.push('--' + boundary + '--\r\n');
+    return [new Blob(parts), 'multipart/form-data; boundary=' + boundary];
+  };
+
+  // extract returns the body's source, a Uint8Array or a Blob, and its
+  // content type.
+  // https://fetch.spec.whatwg.org/#concept-bodyinit-extract
+  const extract = function (init) {
+    if (typeof Blob === 'function' && init instanceof Blob) {
+      return [init, init.type === '' ? null : init.type];
+    }
+    if (init instanceof ArrayBuffer) return [new Uint8Array(init.slice(0)), null];
+    if (ArrayBuffer.isView(init)) {
+      return [new Uint8Array(init.buffer.slice(init.byteOffset, init.byteOffset + init.byteLength)), null];
+    }
+    if (typeof FormData === 'function' && init instanceof FormData) return multipart(init);
+    if (typeof URLSearchParams === 'function' && init instanceof URLSearchParams) {
+      return [new TextEncoder().encode(init.toString()), 'application/x-www-form-urlencoded;charset=UTF-8'];
+    }
+    return [new TextEncoder().encode(String(init)), 'text/plain;charset=UTF-8'];
+  };
+
+  globalThis.Request = class Request {
+    #url;
+    #method = 'GET';
+    #headers;
+    #mode = 'cors';
+    #credentials = 'same-origin';
+    #cache = 'default';
+    #redirect = 'follow';
+    #referrer = 'about:client';
+    #integrity = '';
+    #keepalive = false;
+    #signal = null;
+    // body is null, a Uint8Array or a Blob.
+    #body = null;
+    #bodyUsed = false;
+
+    constructor(input, init) {
+      const opts = init ?? {};
+
+      if (input instanceof Request) {
+        if (input.#bodyUsed) throw typeError('Cannot construct a Request with a Request object that has already been used.');
+
+        this.#url = input.#url;
+        this.#method = input.#method;
+        this.#headers = new Headers(input.#headers);
+        this.#mode = input.#mode;
+        this.#credentials = input.#credentials;
+        this.#cache = input.#cache;
+        this.#redirect = input.#redirect;
+        this.#referrer = input.#referrer;
+        this.#integrity = input.#integrity;
+        this.#keepalive = input.#keepalive;
+        this.#signal = input.#signal;
+        this.#body = input.#body;
+        // the body moves to the new request.
+        if (opts.body === undefined || opts.body === null) input.#bodyUsed = input.#body !== null;
+      } else {
+        const url = resolve(String(input));
+        if (/^[A-Za-z][A-Za-z0-9+.\-]*:\/\/[^/?#]*@/.test(url)) {
+          throw typeError('Request cannot be constructed from a URL that includes credentials: ' + url);
+        }
+        this.#url = url;
+        this.#headers = new Headers();
+      }
+
+      if (opts.method !== undefined) this.#method = normalizeMethod(opts.method);
+      if (opts.mode !== undefined) {
+        const mode = enumValue('RequestMode', opts.mode, ['same-origin', 'no-cors', 'cors', 'navigate']);
+        if (mode === 'navigate') throw typeError("Cannot construct a Request with a RequestInit whose mode member is set as 'navigate'.");
+        this.#mode = mode;
+      }
+      if (opts.credentials !== undefined) {
+        this.#credentials = enumValue('RequestCredentials', opts.credentials, ['omit', 'same-origin', 'include']);
+      }
+      if (opts.cache !== undefined) {
+        this.#cache = enumValue('RequestCache', opts.cache, ['default', 'no-store', 'reload', 'no-cache', 'force-cache', 'only-if-cached']);
+      }
+      if (opts.redirect !== undefined) {
+        this.#redirect = enumValue('RequestRedirect', opts.redirect, ['follow', 'error', 'manual']);
+      }
+      if (opts.referrer !== undefined) this.#referrer = opts.referrer === '' ? '' : resolve(String(opts.referrer));
+      if (opts.integrity !== undefined) this.#integrity = String(opts.integrity);
+      if (opts.keepalive !== undefined) this.#keepalive = Boolean(opts.keepalive);
+      if (opts.signal !== undefined) this.#signal = opts.signal;
+      if (opts.headers !== undefined) this.#headers = new Headers(opts.headers);
+
+      if (opts.body !== undefined && opts.body !== null) {
+        if (this.#method === 'GET' || this.#method === 'HEAD') {
+          throw typeError('Request with GET/HEAD method cannot have body.');
+        }
+        const [body, type] = extract(opts.body);
+        this.#body = body;
+        if (type !== null && !this.#headers.has('content-type')) {
+          this.#headers.append('content-type', type);
+        }
+      } else if (this.#body !== null && (this.#method === 'GET' || this.#method === 'HEAD')) {
+        throw typeError('Request with GET/HEAD method cannot have body.');
+      }
+    }
+
+    get url() { return this.#url; }
+    get method() { return this.#method; }
+    get headers() { return this.#headers; }
+    get mode() { return this.#mode; }
+    get credentials() { return this.#credentials; }
+    get cache() { return this.#cache; }
+    get redirect() { return this.#redirect; }
+    get referrer() { return this.#referrer; }
+    get integrity() { return this.#integrity; }
+    get keepalive() { return this.#keepalive; }
+    get signal() { return this.#signal; }
+    get destination() { return ''; }
+
+    // TODO return a ReadableStream.
+    get body() { return this.#body; }
+    get bodyUsed() { return this.#bodyUsed; }
+
+    clone() {
+      if (this.#bodyUsed) throw new TypeError("Failed to execute 'clone' on 'Request': Request body is already used");
+      const clone = new Request(this);
+      // the constructor moves the body, but a clone shares it.
+      this.#bodyUsed = false;
+      return clone;
+    }
+
+    // consume returns a promise of the body's bytes, rejected if the body
+    // has already been read.
+    // https://fetch.spec.whatwg.org/#concept-body-consume-body
+    async #consume() {
+      if (this.#bodyUsed) throw new TypeError('Body is already used');
+      this.#bodyUsed = this.#body !== null;
+
+      const body = this.#body;
+      if (body === null) return new Uint8Array(0);
+      if (body instanceof Uint8Array) return body;
+      return new Uint8Array(await body.arrayBuffer());
+    }
+
+    async arrayBuffer() {
+      const bytes = await this.#consume();
+      return bytes.buffer.slice(bytes.byteOffset, bytes.byteOffset + bytes.byteLength);
+    }
+
+    async bytes() {
+      return new Uint8Array(await this.arrayBuffer());
+    }
+
+    async text() {
+      return new TextDecoder().decode(await this.#consume());
+    }
+
+    async json() {
+      return JSON.parse(await this.text());
+    }
+
+    async blob() {
+      const type = this.#headers.get('content-type') ?? '';
+      return new Blob([await this.#consume()], { type: type });
+    }
+
+    get [Symbol.toStringTag]() {
+      return 'Request';
+    }
+  };
+})();
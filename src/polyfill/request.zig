@@ -0,0 +1,69 @@
+// Copyright (C) 2023-2024  Lightpanda (Selecy SAS)
+//
+// Francis Bouvier <francis@lightpanda.io>
+// Pierre Tachoire <pierre@lightpanda.io>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+const std = @import("std");
+
+const jsruntime = @import("jsruntime");
+const Case = jsruntime.test_utils.Case;
+const checkCases = jsruntime.test_utils.checkCases;
+
+// Tests of the Request polyfill, see request.js.
+
+pub fn testExecFn(
+    _: std.mem.Allocator,
+    js_env: *jsruntime.Env,
+) anyerror!void {
+    var request = [_]Case{
+        .{ .src = "let request = new Request('http://localhost/foo', { method: 'post', body: 'bar', headers: { 'X-Foo': 'foo' } })", .ex = "undefined" },
+        .{ .src = "request.url", .ex = "http://localhost/foo" },
+        .{ .src = "request.method", .ex = "POST" },
+        .{ .src = "request.headers.get('x-foo')", .ex = "foo" },
+        .{ .src = "request.headers.get('content-type')", .ex = "text/plain;charset=UTF-8" },
+        .{ .src = "request.mode", .ex = "cors" },
+        .{ .src = "request.credentials", .ex = "same-origin" },
+        .{ .src = "Object.prototype.toString.call(request)", .ex = "[object Request]" },
+
+        .{ .src = "let request2 = new Request(request, { credentials: 'include' })", .ex = "undefined" },
+        .{ .src = "request2.url", .ex = "http://localhost/foo" },
+        .{ .src = "request2.method", .ex = "POST" },
+        .{ .src = "request2.credentials", .ex = "include" },
+        .{ .src = "request.bodyUsed", .ex = "true" },
+
+        .{ .src = "let request3 = request2.clone()", .ex = "undefined" },
+        .{ .src = "request2.bodyUsed", .ex = "false" },
+        .{ .src = "let reqtext; request2.text().then((t) => { reqtext = t })", .ex = "[object Promise]" },
+        .{ .src = "reqtext", .ex = "bar" },
+        .{ .src = "request2.bodyUsed", .ex = "true" },
+        .{ .src = "let reqerr; request2.text().catch((e) => { reqerr = e })", .ex = "[object Promise]" },
+        .{ .src = "reqerr instanceof TypeError", .ex = "true" },
+        .{ .src = "var err; try { request2.clone() } catch (e) { err = e } err instanceof TypeError", .ex = "true" },
+
+        .{ .src = "let reqjson; new Request('http://localhost/', { method: 'PUT', body: '{\"a\":1}' }).json().then((j) => { reqjson = j })", .ex = "[object Promise]" },
+        .{ .src = "reqjson.a", .ex = "1" },
+        .{ .src = "let reqbuf; request3.arrayBuffer().then((b) => { reqbuf = b })", .ex = "[object Promise]" },
+        .{ .src = "new Uint8Array(reqbuf).join(',')", .ex = "98,97,114" },
+
+        .{ .src = "err = undefined; try { new Request('http://localhost/', { body: 'foo' }) } catch (e) { err = e } err instanceof TypeError", .ex = "true" },
+        .{ .src = "err = undefined; try { new Request('http://localhost/', { method: 'TRACE' }) } catch (e) { err = e } err instanceof TypeError", .ex = "true" },
+        .{ .src = "err = undefined; try { new Request('http://localhost/', { mode: 'navigate' }) } catch (e) { err = e } err instanceof TypeError", .ex = "true" },
+        .{ .src = "new Request('http://localhost/', { method: 'patch' }).method", .ex = "patch" },
+        .{ .src = "new Request('http://localhost/a/b').url", .ex = "http://localhost/a/b" },
+        .{ .src = "err = undefined; try { new Request('/foo') } catch (e) { err = e } err instanceof TypeError", .ex = "true" },
+    };
+    try checkCases(js_env, &request);
+}
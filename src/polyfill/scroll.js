@@ -0,0 +1,61 @@
+// Element.scrollIntoView takes either a boolean or a ScrollIntoViewOptions
+// dictionary, which the native bindings can't convert. The argument is
+// validated here before calling the native function.
+// There is no layout, so nothing is really scrolled, but a scroll event is
+// dispatched to the document, like after a scroll, so the scripts observing
+// the scroll can progress. The events are coalesced until dispatched.
+// https://drafts.csswg.org/cssom-view/#dom-element-scrollintoview
+(function () {
+  if (typeof Element !== 'function') return;
+
+  const native = Element.prototype.scrollIntoView;
+  if (typeof native !== 'function') return;
+
+  const enums = {
+    behavior: ['auto', 'instant', 'smooth'],
+    block: ['start', 'center', 'end', 'nearest'],
+    inline: ['start', 'center', 'end', 'nearest'],
+  };
+  const types = {
+    behavior: 'ScrollBehavior',
+    block: 'ScrollLogicalPosition',
+    inline: 'ScrollLogicalPosition',
+  };
+
+  const validate = function (arg) {
+    if (arg === undefined || arg === null) return;
+    if (typeof arg !== 'object' && typeof arg !== 'function') return;
+
+    for (const key of Object.keys(enums)) {
+      const v = arg[key];
+      if (v === undefined) continue;
+      if (!enums[key].includes(String(v))) {
+        throw new TypeError("Failed to execute 'scrollIntoView' on 'Element': Failed to read the '" + key +
+          "' property from 'ScrollIntoViewOptions': The provided value '" + v +
+          "' is not a valid enum value of type " + types[key] + '.');
+      }
+    }
+  };
+
+  // pending contains the documents waiting for a scroll event.
+  const pending = new Set();
+
+  Object.defineProperty(Element.prototype, 'scrollIntoView', {
+    value: function scrollIntoView(arg) {
+      validate(arg);
+      native.call(this);
+
+      const doc = this.ownerDocument;
+      if (doc === null || !doc.contains(this) || pending.has(doc)) return;
+
+      pending.add(doc);
+      queueMicrotask(() => {
+        pending.delete(doc);
+        doc.dispatchEvent(new Event('scroll', { bubbles: true }));
+      });
+    },
+    writable: true,
+    enumerable: true,
+    configurable: true,
+  });
+})();
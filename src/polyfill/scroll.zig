@@ -0,0 +1,43 @@
+// Copyright (C) 2023-2024  Lightpanda (Selecy SAS)
+//
+// Francis Bouvier <francis@lightpanda.io>
+// Pierre Tachoire <pierre@lightpanda.io>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+const std = @import("std");
+
+const jsruntime = @import("jsruntime");
+const Case = jsruntime.test_utils.Case;
+const checkCases = jsruntime.test_utils.checkCases;
+
+// Tests of the Element.scrollIntoView polyfill, see scroll.js.
+
+pub fn testExecFn(
+    _: std.mem.Allocator,
+    js_env: *jsruntime.Env,
+) anyerror!void {
+    var scroll = [_]Case{
+        .{ .src = "let nbscroll = 0; document.addEventListener('scroll', () => { nbscroll++ })", .ex = "undefined" },
+        .{ .src = "let scrolled = document.getElementById('para')", .ex = "undefined" },
+        .{ .src = "scrolled.scrollIntoView()", .ex = "undefined" },
+        .{ .src = "scrolled.scrollIntoView(false); scrolled.scrollIntoView({ behavior: 'smooth', block: 'center', inline: 'nearest' })", .ex = "undefined" },
+        .{ .src = "nbscroll", .ex = "2" },
+        .{ .src = "var err; try { scrolled.scrollIntoView({ block: 'middle' }) } catch (e) { err = e } err instanceof TypeError", .ex = "true" },
+        // elements outside the document don't scroll.
+        .{ .src = "document.createElement('div').scrollIntoView()", .ex = "undefined" },
+        .{ .src = "nbscroll", .ex = "2" },
+    };
+    try checkCases(js_env, &scroll);
+}
@@ -0,0 +1,89 @@
+// The native setTimeout takes only a callback and a delay: the bindings
+// can't hold the extra arguments, which are JS values. The handler is
+// wrapped here to forward them, and a string handler is evaluated in the
+// global scope.
+// The native timeouts run once, the intervals are re-scheduled here after
+// each call, from the intended time of the previous call so the callback's
+// duration doesn't shift the cadence. The calls missed are skipped.
+// The timeouts and the intervals share the same ids, so clearTimeout can
+// cancel an interval and vice versa.
+// Each native timeout notifies the native timeoutDone when it runs, so the
+// window forgets it instead of canceling it later. timeoutDone is hidden
+// once captured.
+// https://html.spec.whatwg.org/multipage/timers-and-user-prompts.html#timer-initialisation-steps
+(function () {
+  const nativeSet = globalThis.setTimeout;
+  const nativeClear = globalThis.clearTimeout;
+  const nativeDone = globalThis.timeoutDone;
+  if (typeof nativeSet !== 'function') return;
+  delete globalThis.timeoutDone;
+  delete Object.getPrototypeOf(globalThis).timeoutDone;
+
+  // active maps the timers' ids to the timers. 0 is never used as an id.
+  const active = new Map();
+  let lastId = 0;
+
+  function schedule(timer, delay) {
+    const native = nativeSet.call(globalThis, () => {
+      nativeDone.call(globalThis, native);
+      run(timer);
+    }, delay);
+    timer.native = native;
+  }
+
+  function run(timer) {
+    if (active.get(timer.id) !== timer) return;
+    if (!timer.repeat) active.delete(timer.id);
+
+    try {
+      timer.fn();
+    } finally {
+      // the callback can cancel its own interval.
+      if (timer.repeat && active.get(timer.id) === timer) {
+        const interval = Math.max(timer.delay, 1);
+        const elapsed = performance.now() - timer.start;
+        timer.next += interval;
+        if (timer.next <= elapsed) {
+          timer.next += (Math.floor((elapsed - timer.next) / interval) + 1) * interval;
+        }
+        schedule(timer, Math.ceil(timer.next - elapsed));
+      }
+    }
+  }
+
+  function start(handler, timeout, args, repeat) {
+    let fn = handler;
+    if (typeof handler !== 'function') {
+      const code = String(handler);
+      fn = () => (0, eval)(code);
+    } else if (args.length > 0) {
+      fn = () => handler.apply(globalThis, args);
+    }
+
+    const delay = Math.min(Math.max(Math.trunc(Number(timeout)) || 0, 0), 0x7fffffff);
+    const timer = { id: ++lastId, fn: fn, delay: delay, repeat: repeat, start: performance.now(), next: delay };
+    active.set(timer.id, timer);
+    schedule(timer, delay);
+    return timer.id;
+  }
+
+  function clear(id) {
+    const timer = active.get(id);
+    if (timer === undefined) return;
+    active.delete(id);
+    nativeClear.call(globalThis, timer.native);
+  }
+
+  globalThis.setTimeout = function setTimeout(handler, timeout, ...args) {
+    return start(handler, timeout, args, false);
+  };
+  globalThis.setInterval = function setInterval(handler, timeout, ...args) {
+    return start(handler, timeout, args, true);
+  };
+  globalThis.clearTimeout = function clearTimeout(id) {
+    clear(id);
+  };
+  globalThis.clearInterval = function clearInterval(id) {
+    clear(id);
+  };
+})();
@@ -0,0 +1,439 @@
+// The constraint validation API is implemented in JS because the pattern
+// attribute is a JS regular expression, which the native code can't
+// evaluate, and the custom validity messages are kept by element.
+// The validation reads the native value of the input and textarea
+// elements, which is the value set by a script, the dirty value, or the
+// default value. The form submission reads the same values.
+// requestSubmit validates the form's controls before submitting unless
+// novalidate or formnovalidate is set.
+// TODO support disabled fieldsets.
+// https://html.spec.whatwg.org/multipage/form-control-infrastructure.html#the-constraint-validation-api
+(function () {
+  if (typeof HTMLFormElement !== 'function') return;
+  if (typeof HTMLFormElement.prototype.checkValidity === 'function') return;
+
+  const customValidity = new WeakMap();
+
+  const textTypes = ['text', 'search', 'url', 'tel', 'email', 'password'];
+  const requiredTypes = textTypes.concat([
+    'date', 'month', 'week', 'time', 'datetime-local', 'number', 'checkbox', 'radio', 'file',
+  ]);
+  const barredTypes = ['hidden', 'reset', 'button'];
+
+  // https://html.spec.whatwg.org/multipage/input.html#valid-e-mail-address
+  const email = /^[a-zA-Z0-9.!#$%&'*+\/=?^_`{|}~-]+@[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?(?:\.[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*$/;
+  const absoluteURL = /^[a-zA-Z][a-zA-Z0-9+.\-]*:[^\s]*$/;
+  // https://html.spec.whatwg.org/multipage/common-microsyntaxes.html#valid-floating-point-number
+  const float = /^-?(?:\d+|\d*\.\d+)(?:[eE][+-]?\d+)?$/;
+
+  const messages = {
+    valueMissing: 'Please fill out this field.',
+    typeMismatch: 'Please enter a valid value.',
+    patternMismatch: 'Please match the requested format.',
+    tooLong: 'Please shorten this text.',
+    tooShort: 'Please lengthen this text.',
+    rangeUnderflow: 'Value must be greater than or equal to the minimum.',
+    rangeOverflow: 'Value must be less than or equal to the maximum.',
+    stepMismatch: 'Please enter a valid value.',
+  };
+
+  const inputType = function (el) {
+    const t = (el.getAttribute('type') ?? 'text').toLowerCase();
+    return requiredTypes.includes(t) || barredTypes.includes(t) || ['submit', 'image', 'range', 'color'].includes(t) ? t : 'text';
+  };
+
+  const parseNumber = function (v) {
+    return v !== null && float.test(v) ? Number(v) : null;
+  };
+
+  const parseLength = function (v) {
+    if (v === null || !/^\s*\d+\s*$/.test(v)) return null;
+    return Number(v);
+  };
+
+  const defaultValue = function (el) {
+    if (el.localName === 'textarea') return el.textContent;
+    return el.getAttribute('value') ?? '';
+  };
+
+  // valueOf returns the value set by a script or the default value.
+  const valueOf = function (el) {
+    const v = typeof el.value === 'string' ? el.value : defaultValue(el);
+    if (el.localName !== 'input') return v;
+
+    switch (inputType(el)) {
+      case 'email':
+      case 'url':
+        return v.replace(/[\r\n]/g, '').trim();
+      case 'number':
+        return float.test(v) ? v : '';
+      default:
+        return v.replace(/[\r\n]/g, '');
+    }
+  };
+
+  const optionValue = function (option) {
+    return option.getAttribute('value') ?? option.textContent;
+  };
+
+  // https://html.spec.whatwg.org/multipage/form-control-infrastructure.html#form-owner
+  const formOwner = function (el) {
+    const id = el.getAttribute('form');
+    if (id !== null) {
+      const f = el.ownerDocument?.getElementById(id) ?? null;
+      return f instanceof HTMLFormElement ? f : null;
+    }
+    return el.parentElement?.closest('form') ?? null;
+  };
+
+  // https://html.spec.whatwg.org/multipage/forms.html#concept-submit-button
+  const isSubmitButton = function (el) {
+    switch (el.localName) {
+      case 'button': {
+        const t = (el.getAttribute('type') ?? 'submit').toLowerCase();
+        return t !== 'reset' && t !== 'button';
+      }
+      case 'input': {
+        const t = (el.getAttribute('type') ?? '').toLowerCase();
+        return t === 'submit' || t === 'image';
+      }
+      default:
+        return false;
+    }
+  };
+
+  // treeRoot returns the root of the element's tree.
+  const treeRoot = function (el) {
+    let root = el;
+    while (root.parentNode !== null) root = root.parentNode;
+    return root;
+  };
+
+  // https://html.spec.whatwg.org/multipage/form-control-infrastructure.html#candidate-for-constraint-validation
+  const willValidate = function (el) {
+    switch (el.localName) {
+      case 'input':
+        if (barredTypes.includes(inputType(el))) return false;
+        if (el.hasAttribute('readonly')) return false;
+        break;
+      case 'button': {
+        const t = (el.getAttribute('type') ?? 'submit').toLowerCase();
+        if (t === 'reset' || t === 'button') return false;
+        break;
+      }
+      case 'textarea':
+        if (el.hasAttribute('readonly')) return false;
+        break;
+      case 'select':
+        break;
+      default:
+        return false;
+    }
+    if (el.hasAttribute('disabled')) return false;
+    if (el.closest('datalist') !== null) return false;
+    return true;
+  };
+
+  // https://html.spec.whatwg.org/multipage/input.html#the-required-attribute
+  const valueMissing = function (el, value) {
+    if (!el.hasAttribute('required')) {
+      // a required radio makes the whole group required.
+      if (el.localName !== 'input' || inputType(el) !== 'radio') return false;
+    }
+
+    switch (el.localName) {
+      case 'textarea':
+        return value === '';
+      case 'select':
+        return selectValueMissing(el);
+      case 'input':
+        break;
+      default:
+        return false;
+    }
+
+    const t = inputType(el);
+    if (!requiredTypes.includes(t)) return false;
+    if (t === 'checkbox') return !el.hasAttribute('checked');
+    if (t === 'radio') return radioValueMissing(el);
+    // TODO the file inputs have no selected files.
+    return value === '';
+  };
+
+  // https://html.spec.whatwg.org/multipage/form-elements.html#placeholder-label-option
+  const selectValueMissing = function (el) {
+    const multiple = el.hasAttribute('multiple');
+    const options = Array.from(el.querySelectorAll('option'));
+    let selected = options.filter((o) => o.hasAttribute('selected'));
+    if (!multiple && selected.length === 0) {
+      const first = options.find((o) => !o.hasAttribute('disabled'));
+      if (first !== undefined) selected = [first];
+    }
+    if (selected.length === 0) return true;
+    if (multiple) return false;
+
+    const size = parseLength(el.getAttribute('size')) ?? 1;
+    const first = options[0];
+    const placeholder = size === 1 && first.parentElement === el && optionValue(first) === '';
+    return placeholder && selected[selected.length - 1] === first;
+  };
+
+  // https://html.spec.whatwg.org/multipage/input.html#radio-button-group
+  const radioValueMissing = function (el) {
+    const name = el.getAttribute('name') ?? '';
+    let group = [el];
+    if (name !== '') {
+      const owner = formOwner(el);
+      group = Array.from(treeRoot(el).querySelectorAll('input')).filter((i) => {
+        return inputType(i) === 'radio' && i.getAttribute('name') === name && formOwner(i) === owner;
+      });
+    }
+    if (!group.some((i) => i.hasAttribute('required'))) return false;
+    return !group.some((i) => i.hasAttribute('checked'));
+  };
+
+  const typeMismatch = function (el, value) {
+    if (el.localName !== 'input' || value === '') return false;
+    switch (inputType(el)) {
+      case 'email': {
+        const values = el.hasAttribute('multiple') ? value.split(',').map((v) => v.trim()) : [value];
+        return !values.every((v) => email.test(v));
+      }
+      case 'url':
+        return !absoluteURL.test(value);
+      default:
+        return false;
+    }
+  };
+
+  // compile returns the regular expression with the v flag, or the u flag
+  // when the v flag isn't supported yet.
+  const compile = function (source) {
+    for (const flags of ['v', 'u']) {
+      try {
+        return new RegExp(source, flags);
+      } catch (e) {
+        continue;
+      }
+    }
+    return null;
+  };
+
+  // https://html.spec.whatwg.org/multipage/input.html#the-pattern-attribute
+  const patternMismatch = function (el, value) {
+    if (el.localName !== 'input' || value === '') return false;
+    if (!textTypes.includes(inputType(el))) return false;
+
+    const pattern = el.getAttribute('pattern');
+    if (pattern === null) return false;
+
+    const re = compile('^(?:' + pattern + ')$');
+    // an invalid pattern is ignored.
+    if (re === null) return false;
+
+    const values = inputType(el) === 'email' && el.hasAttribute('multiple') ? value.split(',').map((v) => v.trim()) : [value];
+    return !values.every((v) => re.test(v));
+  };
+
+  const hasLength = function (el) {
+    return el.localName === 'textarea' || (el.localName === 'input' && textTypes.includes(inputType(el)));
+  };
+
+  const tooLong = function (el, value) {
+    if (!hasLength(el) || value === '') return false;
+    const max = parseLength(el.getAttribute('maxlength'));
+    return max !== null && value.length > max;
+  };
+
+  const tooShort = function (el, value) {
+    if (!hasLength(el) || value === '') return false;
+    const min = parseLength(el.getAttribute('minlength'));
+    return min !== null && value.length < min;
+  };
+
+  const isNumber = function (el) {
+    return el.localName === 'input' && inputType(el) === 'number';
+  };
+
+  const rangeUnderflow = function (el, value) {
+    if (!isNumber(el) || value === '') return false;
+    const min = parseNumber(el.getAttribute('min'));
+    return min !== null && Number(value) < min;
+  };
+
+  const rangeOverflow = function (el, value) {
+    if (!isNumber(el) || value === '') return false;
+    const max = parseNumber(el.getAttribute('max'));
+    return max !== null && Number(value) > max;
+  };
+
+  // https://html.spec.whatwg.org/multipage/input.html#the-step-attribute
+  const stepMismatch = function (el, value) {
+    if (!isNumber(el) || value === '') return false;
+    const attr = el.getAttribute('step');
+    if (attr !== null && attr.toLowerCase() === 'any') return false;
+
+    const s = parseNumber(attr);
+    const step = s !== null && s > 0 ? s : 1;
+    const base = parseNumber(el.getAttribute('min')) ?? parseNumber(el.getAttribute('value')) ?? 0;
+    const n = (Number(value) - base) / step;
+    return Math.abs(n - Math.round(n)) > 1e-9;
+  };
+
+  const token = Symbol('ValidityState');
+
+  globalThis.ValidityState = class ValidityState {
+    #flags;
+
+    constructor(t, flags) {
+      if (t !== token) throw new TypeError('Illegal constructor');
+      this.#flags = flags;
+    }
+
+    get valueMissing() { return this.#flags.valueMissing; }
+    get typeMismatch() { return this.#flags.typeMismatch; }
+    get patternMismatch() { return this.#flags.patternMismatch; }
+    get tooLong() { return this.#flags.tooLong; }
+    get tooShort() { return this.#flags.tooShort; }
+    get rangeUnderflow() { return this.#flags.rangeUnderflow; }
+    get rangeOverflow() { return this.#flags.rangeOverflow; }
+    get stepMismatch() { return this.#flags.stepMismatch; }
+    get badInput() { return this.#flags.badInput; }
+    get customError() { return this.#flags.customError; }
+
+    get valid() {
+      return !Object.values(this.#flags).some((v) => v);
+    }
+  };
+
+  // validityOf returns the element's current ValidityState.
+  const validityOf = function (el) {
+    const flags = {
+      valueMissing: false,
+      typeMismatch: false,
+      patternMismatch: false,
+      tooLong: false,
+      tooShort: false,
+      rangeUnderflow: false,
+      rangeOverflow: false,
+      stepMismatch: false,
+      badInput: false,
+      customError: false,
+    };
+    if (willValidate(el)) {
+      const value = valueOf(el);
+      flags.valueMissing = valueMissing(el, value);
+      flags.typeMismatch = typeMismatch(el, value);
+      flags.patternMismatch = patternMismatch(el, value);
+      flags.tooLong = tooLong(el, value);
+      flags.tooShort = tooShort(el, value);
+      flags.rangeUnderflow = rangeUnderflow(el, value);
+      flags.rangeOverflow = rangeOverflow(el, value);
+      flags.stepMismatch = stepMismatch(el, value);
+      flags.customError = (customValidity.get(el) ?? '') !== '';
+    }
+    return new ValidityState(token, flags);
+  };
+
+  // https://html.spec.whatwg.org/multipage/form-control-infrastructure.html#check-validity-steps
+  const checkValidity = function (el) {
+    if (validityOf(el).valid) return true;
+    el.dispatchEvent(new Event('invalid', { cancelable: true }));
+    return false;
+  };
+
+  // https://html.spec.whatwg.org/multipage/form-control-infrastructure.html#statically-validate-the-constraints
+  const checkFormValidity = function (form) {
+    const controls = Array.from(treeRoot(form).querySelectorAll('button, input, select, textarea'));
+    let valid = true;
+    for (const el of controls) {
+      if (formOwner(el) !== form) continue;
+      if (!checkValidity(el)) valid = false;
+    }
+    return valid;
+  };
+
+  const controls = [
+    globalThis.HTMLInputElement,
+    globalThis.HTMLButtonElement,
+    globalThis.HTMLSelectElement,
+    globalThis.HTMLTextAreaElement,
+    globalThis.HTMLFieldSetElement,
+    globalThis.HTMLOutputElement,
+    globalThis.HTMLObjectElement,
+  ];
+
+  for (const ctor of controls) {
+    if (typeof ctor !== 'function') continue;
+
+    Object.defineProperties(ctor.prototype, {
+      willValidate: {
+        get: function () { return willValidate(this); },
+        configurable: true,
+        enumerable: true,
+      },
+      validity: {
+        get: function () { return validityOf(this); },
+        configurable: true,
+        enumerable: true,
+      },
+      validationMessage: {
+        get: function () {
+          const validity = validityOf(this);
+          if (validity.customError) return customValidity.get(this);
+          for (const k in messages) {
+            if (validity[k]) return messages[k];
+          }
+          return '';
+        },
+        configurable: true,
+        enumerable: true,
+      },
+      checkValidity: {
+        value: function () { return checkValidity(this); },
+        configurable: true,
+        writable: true,
+      },
+      // There is no UI to report the problems to the user.
+      reportValidity: {
+        value: function () { return checkValidity(this); },
+        configurable: true,
+        writable: true,
+      },
+      setCustomValidity: {
+        value: function (error) { customValidity.set(this, String(error)); },
+        configurable: true,
+        writable: true,
+      },
+    });
+  }
+
+  Object.defineProperties(HTMLFormElement.prototype, {
+    checkValidity: {
+      value: function () { return checkFormValidity(this); },
+      configurable: true,
+      writable: true,
+    },
+    reportValidity: {
+      value: function () { return checkFormValidity(this); },
+      configurable: true,
+      writable: true,
+    },
+  });
+
+  // https://html.spec.whatwg.org/multipage/form-control-infrastructure.html#concept-form-submit
+  const requestSubmit = HTMLFormElement.prototype.requestSubmit;
+  if (typeof requestSubmit === 'function') {
+    HTMLFormElement.prototype.requestSubmit = function (...args) {
+      const submitter = args[0] ?? null;
+      // the submitter is checked before the validation.
+      if (submitter !== null && !(submitter instanceof Element && isSubmitButton(submitter))) {
+        throw new TypeError('The submitter is not a submit button');
+      }
+      const novalidate = this.hasAttribute('novalidate') ||
+        (submitter instanceof Element && submitter.hasAttribute('formnovalidate'));
+      if (!novalidate && !checkFormValidity(this)) return;
+      return requestSubmit.apply(this, args);
+    };
+  }
+})();
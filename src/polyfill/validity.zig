@@ -0,0 +1,95 @@
+// Copyright (C) 2023-2024  Lightpanda (Selecy SAS)
+//
+// Francis Bouvier <francis@lightpanda.io>
+// Pierre Tachoire <pierre@lightpanda.io>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+const std = @import("std");
+
+const jsruntime = @import("jsruntime");
+const Case = jsruntime.test_utils.Case;
+const checkCases = jsruntime.test_utils.checkCases;
+
+// Tests of the constraint validation polyfill, see validity.js.
+
+pub fn testExecFn(
+    _: std.mem.Allocator,
+    js_env: *jsruntime.Env,
+) anyerror!void {
+    var validity = [_]Case{
+        .{ .src = "let vform = document.createElement('form')", .ex = "undefined" },
+        .{ .src = "vform.innerHTML = '<input id=vtext name=t required><input id=vmail type=email value=foo><input id=vnum type=number min=1 max=10 value=12><input id=vpat pattern=[a-z]+ value=abc1><input id=vlen maxlength=2 minlength=1 value=abc><input id=vhidden type=hidden required><select id=vsel required><option value=\"\">none</option><option>foo</option></select><button id=vsubmit>ok</button>'; true", .ex = "true" },
+        .{ .src = "document.getElementById('content').appendChild(vform) === vform", .ex = "true" },
+        .{ .src = "let vtext = document.getElementById('vtext')", .ex = "undefined" },
+
+        .{ .src = "vtext.validity instanceof ValidityState", .ex = "true" },
+        .{ .src = "vtext.willValidate", .ex = "true" },
+        .{ .src = "vtext.validity.valueMissing", .ex = "true" },
+        .{ .src = "vtext.validity.valid", .ex = "false" },
+        .{ .src = "vtext.validationMessage !== ''", .ex = "true" },
+        .{ .src = "vtext.setAttribute('value', 'foo'); vtext.validity.valid", .ex = "true" },
+
+        // a value set by a script is preferred to the value attribute.
+        // TODO the value set on a select isn't supported.
+        .{ .src = "vtext.value = ''; vtext.validity.valueMissing", .ex = "true" },
+        .{ .src = "vtext.getAttribute('value')", .ex = "foo" },
+        .{ .src = "vtext.value = 'bar'; vtext.validity.valid", .ex = "true" },
+        .{ .src = "vtext.value", .ex = "bar" },
+        .{ .src = "let varea = document.createElement('textarea'); varea.setAttribute('required', ''); varea.textContent = 'foo'; varea.validity.valid", .ex = "true" },
+        .{ .src = "varea.value = ''; varea.validity.valueMissing", .ex = "true" },
+        // the checkbox's value is its attribute.
+        .{ .src = "let vcb = document.createElement('input'); vcb.setAttribute('type', 'checkbox'); vcb.value", .ex = "on" },
+        .{ .src = "vcb.value = 'x'; vcb.getAttribute('value')", .ex = "x" },
+
+        .{ .src = "document.getElementById('vmail').validity.typeMismatch", .ex = "true" },
+        .{ .src = "document.getElementById('vmail').setAttribute('value', 'foo@bar.com'); document.getElementById('vmail').validity.typeMismatch", .ex = "false" },
+        .{ .src = "document.getElementById('vnum').validity.rangeOverflow", .ex = "true" },
+        .{ .src = "document.getElementById('vnum').validity.rangeUnderflow", .ex = "false" },
+        .{ .src = "document.getElementById('vnum').setAttribute('value', '0'); document.getElementById('vnum').validity.rangeUnderflow", .ex = "true" },
+        .{ .src = "document.getElementById('vpat').validity.patternMismatch", .ex = "true" },
+        .{ .src = "document.getElementById('vlen').validity.tooLong", .ex = "true" },
+        .{ .src = "document.getElementById('vlen').validity.tooShort", .ex = "false" },
+        .{ .src = "document.getElementById('vhidden').willValidate", .ex = "false" },
+        .{ .src = "document.getElementById('vhidden').validity.valid", .ex = "true" },
+        .{ .src = "document.getElementById('vsel').validity.valueMissing", .ex = "true" },
+        .{ .src = "document.getElementById('vsel').lastChild.setAttribute('selected', ''); document.getElementById('vsel').validity.valueMissing", .ex = "false" },
+
+        .{ .src = "let vinvalid = []", .ex = "undefined" },
+        .{ .src = "vform.addEventListener('invalid', (e) => vinvalid.push(e.target.id), true)", .ex = "undefined" },
+        .{ .src = "vform.checkValidity()", .ex = "false" },
+        .{ .src = "vinvalid.join(',')", .ex = "vnum,vpat,vlen" },
+        .{ .src = "document.getElementById('vpat').checkValidity()", .ex = "false" },
+        .{ .src = "vtext.checkValidity()", .ex = "true" },
+        .{ .src = "vtext.reportValidity()", .ex = "true" },
+
+        .{ .src = "vtext.setCustomValidity('bad')", .ex = "undefined" },
+        .{ .src = "vtext.validity.customError", .ex = "true" },
+        .{ .src = "vtext.validationMessage", .ex = "bad" },
+        .{ .src = "vtext.setCustomValidity(''); vtext.validity.customError", .ex = "false" },
+
+        // the invalid controls block the submission.
+        .{ .src = "let vsubmit = 0; vform.addEventListener('submit', (e) => { vsubmit++; e.preventDefault() })", .ex = "undefined" },
+        .{ .src = "vform.requestSubmit()", .ex = "undefined" },
+        .{ .src = "vsubmit", .ex = "0" },
+        .{ .src = "vform.setAttribute('novalidate', ''); vform.requestSubmit()", .ex = "undefined" },
+        .{ .src = "vsubmit", .ex = "1" },
+        .{ .src = "vform.removeAttribute('novalidate'); ['vnum', 'vpat', 'vlen'].forEach((id) => document.getElementById(id).remove())", .ex = "undefined" },
+        .{ .src = "vform.reportValidity()", .ex = "true" },
+        .{ .src = "vform.requestSubmit(document.getElementById('vsubmit'))", .ex = "undefined" },
+        .{ .src = "vsubmit", .ex = "2" },
+        .{ .src = "vform.remove()", .ex = "undefined" },
+    };
+    try checkCases(js_env, &validity);
+}
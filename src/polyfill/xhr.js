@@ -0,0 +1,37 @@
+// ArrayBuffer is not supported by the native bindings. For the
+// "arraybuffer" response type, the native XMLHttpRequest.response returns the
+// received bytes as a string with one code point per byte, converted here into
+// an ArrayBuffer.
+// The body given to send is converted by body.js.
+// https://xhr.spec.whatwg.org/#the-response-attribute
+(function () {
+  if (typeof XMLHttpRequest !== 'function') {
+    return;
+  }
+
+  const desc = Object.getOwnPropertyDescriptor(XMLHttpRequest.prototype, 'response');
+  if (desc === undefined || typeof desc.get !== 'function') {
+    return;
+  }
+
+  // buffers keeps the response object, ie. xhr.response === xhr.response.
+  const buffers = new WeakMap();
+
+  Object.defineProperty(XMLHttpRequest.prototype, 'response', {
+    get() {
+      const res = desc.get.call(this);
+      if (this.responseType !== 'arraybuffer' || res === null) return res;
+
+      const cached = buffers.get(this);
+      if (cached !== undefined && cached.src === res) return cached.buf;
+
+      const bytes = new Uint8Array(res.length);
+      for (let i = 0; i < res.length; i++) bytes[i] = res.charCodeAt(i);
+
+      buffers.set(this, { src: res, buf: bytes.buffer });
+      return bytes.buffer;
+    },
+    enumerable: desc.enumerable,
+    configurable: true,
+  });
+})();
@@ -27,10 +27,12 @@ const parser = @import("netsurf");
 const apiweb = @import("apiweb.zig");
 const Window = @import("html/window.zig").Window;
 const xhr = @import("xhr/xhr.zig");
+const form = @import("html/form.zig");
 const storage = @import("storage/storage.zig");
 const url = @import("url/url.zig");
 const urlquery = @import("url/query.zig");
 const Client = @import("async/Client.zig");
+const polyfill = @import("polyfill/polyfill.zig");
 
 const documentTestExecFn = @import("dom/document.zig").testExecFn;
 const HTMLDocumentTestExecFn = @import("html/document.zig").testExecFn;
@@ -52,10 +54,34 @@ const DocumentFragmentTestExecFn = @import("dom/document_fragment.zig").testExec
 const EventTestExecFn = @import("events/event.zig").testExecFn;
 const XHRTestExecFn = xhr.testExecFn;
 const ProgressEventTestExecFn = @import("xhr/progress_event.zig").testExecFn;
+const UIEventTestExecFn = @import("events/ui_event.zig").testExecFn;
 const StorageTestExecFn = storage.testExecFn;
 const URLTestExecFn = url.testExecFn;
 const HTMLElementTestExecFn = @import("html/elements.zig").testExecFn;
 const MutationObserverTestExecFn = @import("dom/mutation_observer.zig").testExecFn;
+const AbortControllerTestExecFn = @import("dom/abort_controller.zig").testExecFn;
+const MicrotaskTestExecFn = @import("polyfill/microtask.zig").testExecFn;
+const DatasetTestExecFn = @import("polyfill/dataset.zig").testExecFn;
+const CustomEventTestExecFn = @import("polyfill/custom_event.zig").testExecFn;
+const HeadersTestExecFn = @import("polyfill/headers.zig").testExecFn;
+const ConsoleTestExecFn = @import("polyfill/console.zig").testExecFn;
+const EncodingTestExecFn = @import("polyfill/encoding.zig").testExecFn;
+const BlobTestExecFn = @import("polyfill/blob.zig").testExecFn;
+const RequestTestExecFn = @import("polyfill/request.zig").testExecFn;
+const ScrollTestExecFn = @import("polyfill/scroll.zig").testExecFn;
+const IntersectionObserverTestExecFn = @import("polyfill/intersection_observer.zig").testExecFn;
+const ValidityTestExecFn = @import("polyfill/validity.zig").testExecFn;
+const CSSStyleDeclarationTestExecFn = @import("cssom/css_style_declaration.zig").testExecFn;
+const NavigatorTestExecFn = @import("html/navigator.zig").testExecFn;
+const CryptoTestExecFn = @import("crypto/crypto.zig").testExecFn;
+const DOMParserTestExecFn = @import("dom/dom_parser.zig").testExecFn;
+const DOMRectTestExecFn = @import("dom/dom_rect.zig").testExecFn;
+const HistoryTestExecFn = @import("html/history.zig").testExecFn;
+const RangeTestExecFn = @import("dom/range.zig").testExecFn;
+const PerformanceTestExecFn = @import("html/performance.zig").testExecFn;
+const SelectionTestExecFn = @import("html/selection.zig").testExecFn;
+const TimerTestExecFn = @import("html/timer.zig").testExecFn;
+const WindowTestExecFn = @import("html/window.zig").testExecFn;
 
 pub const Types = jsruntime.reflect(apiweb.Interfaces);
 pub const UserContext = @import("user_context.zig").UserContext;
@@ -102,6 +128,9 @@ fn testExecFn(
 
     try js_env.bindGlobal(window);
 
+    // load polyfills
+    try polyfill.load(alloc, js_env);
+
     // run test
     try execFn(alloc, js_env);
 }
@@ -130,11 +159,35 @@ fn testsAllExecFn(
         EventTestExecFn,
         XHRTestExecFn,
         ProgressEventTestExecFn,
+        UIEventTestExecFn,
         ProcessingInstructionTestExecFn,
         StorageTestExecFn,
         URLTestExecFn,
         HTMLElementTestExecFn,
         MutationObserverTestExecFn,
+        AbortControllerTestExecFn,
+        MicrotaskTestExecFn,
+        DatasetTestExecFn,
+        CustomEventTestExecFn,
+        HeadersTestExecFn,
+        ConsoleTestExecFn,
+        EncodingTestExecFn,
+        BlobTestExecFn,
+        RequestTestExecFn,
+        ScrollTestExecFn,
+        IntersectionObserverTestExecFn,
+        ValidityTestExecFn,
+        CSSStyleDeclarationTestExecFn,
+        NavigatorTestExecFn,
+        CryptoTestExecFn,
+        DOMParserTestExecFn,
+        DOMRectTestExecFn,
+        HistoryTestExecFn,
+        RangeTestExecFn,
+        PerformanceTestExecFn,
+        SelectionTestExecFn,
+        TimerTestExecFn,
+        WindowTestExecFn,
     };
 
     inline for (testFns) |testFn| {
@@ -318,6 +371,9 @@ test {
 
     const queryTest = @import("url/query.zig");
     std.testing.refAllDecls(queryTest);
+
+    const strTest = @import("str/parser.zig");
+    std.testing.refAllDecls(strTest);
 }
 
 fn testJSRuntime(alloc: std.mem.Allocator) !void {
@@ -364,6 +420,110 @@ test "Window is a libdom event target" {
     _ = try parser.eventTargetDispatchEvent(et, event);
 }
 
+test "Window.replaceDocument resets the history" {
+    const alloc = std.testing.allocator;
+    var window = Window.create(null);
+
+    const urls = [_][]const u8{ "http://localhost/foo", "http://localhost/bar" };
+    for (urls) |u| {
+        doc = try parser.documentHTMLParseFromStr("<body></body>");
+        defer parser.documentHTMLClose(doc) catch {};
+        try parser.documentSetDocumentURI(parser.documentHTMLToDocument(doc), u);
+
+        window.replaceDocument(doc);
+        try std.testing.expectEqual(@as(usize, 1), window.history.get_length());
+        try std.testing.expect(window.history.get_state() == null);
+
+        const userctx = UserContext{ .document = doc, .httpClient = undefined };
+        try window.history._pushState(alloc, userctx, "{}", "", "#baz");
+        try std.testing.expectEqual(@as(usize, 2), window.history.get_length());
+    }
+
+    window.history.reset();
+}
+
+test "Form submission with a submitter" {
+    const alloc = std.testing.allocator;
+
+    doc = try parser.documentHTMLParseFromStr(
+        \\<form id="f" action="/foo">
+        \\<input name="a" value="1">
+        \\<button id="s" name="b" value="2" formaction="bar?x" formmethod="post" formenctype="text/plain">ok</button>
+        \\<button id="o" name="c" value="3">ko</button>
+        \\</form>
+    );
+    defer parser.documentHTMLClose(doc) catch {};
+
+    const d = parser.documentHTMLToDocument(doc);
+    try parser.documentSetDocumentURI(d, "http://localhost/dir/page.html");
+
+    const f = try parser.documentGetElementById(d, "f") orelse return error.TestUnexpectedResult;
+    const submitter = try parser.documentGetElementById(d, "s") orelse return error.TestUnexpectedResult;
+
+    var buf = std.ArrayList(u8).init(alloc);
+    defer buf.deinit();
+
+    // without submitter, the form's attributes are used and no button is
+    // submitted.
+    var s = try form.Submission.init(alloc, @ptrCast(f), null);
+    defer s.deinit(alloc);
+
+    try std.testing.expectEqualStrings("http://localhost/foo", s.action);
+    try std.testing.expectEqual(form.Method.get, s.method);
+    try std.testing.expectEqual(form.Enctype.urlencoded, s.enctype);
+    try s.encode(buf.writer());
+    try std.testing.expectEqualStrings("a=1", buf.items);
+
+    // the submitter overrides the form's attributes and is the only button
+    // submitted.
+    var ss = try form.Submission.init(alloc, @ptrCast(f), submitter);
+    defer ss.deinit(alloc);
+
+    try std.testing.expectEqualStrings("http://localhost/dir/bar?x", ss.action);
+    try std.testing.expectEqual(form.Method.post, ss.method);
+    try std.testing.expectEqual(form.Enctype.text, ss.enctype);
+    try std.testing.expectEqual(@as(usize, 2), ss.entries.items.len);
+    try std.testing.expectEqualStrings("b", ss.entries.items[1].name);
+    try std.testing.expectEqualStrings("2", ss.entries.items[1].value);
+
+    buf.clearRetainingCapacity();
+    try ss.encode(buf.writer());
+    try std.testing.expectEqualStrings("a=1\r\nb=2\r\n", buf.items);
+}
+
+test "Form submission with a dirty value" {
+    var arena = std.heap.ArenaAllocator.init(std.testing.allocator);
+    defer arena.deinit();
+    const alloc = arena.allocator();
+
+    doc = try parser.documentHTMLParseFromStr(
+        \\<form id="f" action="/foo">
+        \\<input id="a" name="a" value="1">
+        \\<textarea id="b" name="b">2</textarea>
+        \\</form>
+    );
+    defer parser.documentHTMLClose(doc) catch {};
+
+    const d = parser.documentHTMLToDocument(doc);
+    try parser.documentSetDocumentURI(d, "http://localhost/");
+
+    const f = try parser.documentGetElementById(d, "f") orelse return error.TestUnexpectedResult;
+    const a = try parser.documentGetElementById(d, "a") orelse return error.TestUnexpectedResult;
+    const b = try parser.documentGetElementById(d, "b") orelse return error.TestUnexpectedResult;
+
+    // the values set by a script are submitted instead of the defaults.
+    try parser.controlSetDirtyValue(alloc, a, "x");
+    try parser.controlSetDirtyValue(alloc, b, "y");
+    try parser.controlSetDirtyValue(alloc, b, "z");
+
+    var s = try form.Submission.init(alloc, @ptrCast(f), null);
+    defer s.deinit(alloc);
+
+    var buf = std.ArrayList(u8).init(alloc);
+    try s.encode(buf.writer());
+    try std.testing.expectEqualStrings("a=x&b=z", buf.items);
+}
+
 test "DocumentHTML is a libdom event target" {
     doc = try parser.documentHTMLParseFromStr("<body></body>");
     parser.documentHTMLClose(doc) catch {};
@@ -42,11 +42,15 @@ pub const Shed = struct {
 
     alloc: std.mem.Allocator,
     map: Map,
+    // opaque contains the shelves of opaque origins (eg. data: URLs).
+    // These shelves are never shared.
+    opaques: std.ArrayListUnmanaged(*Shelf),
 
     pub fn init(alloc: std.mem.Allocator) Shed {
         return .{
             .alloc = alloc,
             .map = .{},
+            .opaques = .{},
         };
     }
 
@@ -58,6 +62,12 @@ pub const Shed = struct {
             self.alloc.free(entry.key_ptr.*);
         }
         self.map.deinit(self.alloc);
+
+        for (self.opaques.items) |shelf| {
+            shelf.deinit();
+            self.alloc.destroy(shelf);
+        }
+        self.opaques.deinit(self.alloc);
     }
 
     pub fn getOrPut(self: *Shed, origin: []const u8) !*Shelf {
@@ -68,6 +78,30 @@ pub const Shed = struct {
         try self.map.put(self.alloc, oorigin, Shelf.init(self.alloc));
         return self.map.getPtr(origin).?;
     }
+
+    // createOpaque returns a new isolated shelf for an opaque origin.
+    // https://html.spec.whatwg.org/multipage/browsers.html#concept-origin-opaque
+    pub fn createOpaque(self: *Shed) !*Shelf {
+        const shelf = try self.alloc.create(Shelf);
+        errdefer self.alloc.destroy(shelf);
+
+        shelf.* = Shelf.init(self.alloc);
+        try self.opaques.append(self.alloc, shelf);
+        return shelf;
+    }
+
+    // releaseOpaque frees a shelf returned by createOpaque, once the document
+    // of the opaque origin is replaced.
+    pub fn releaseOpaque(self: *Shed, shelf: *Shelf) void {
+        for (self.opaques.items, 0..) |s, i| {
+            if (s != shelf) continue;
+
+            _ = self.opaques.swapRemove(i);
+            s.deinit();
+            self.alloc.destroy(s);
+            return;
+        }
+    }
 };
 
 pub const Shelf = struct {
@@ -248,3 +282,28 @@ test "storage bottle" {
     try std.testing.expect(0 == bottle.get_length());
     try std.testing.expect(null == bottle._getItem("foo"));
 }
+
+test "storage shed" {
+    var shed = Shed.init(std.testing.allocator);
+    defer shed.deinit();
+
+    const a = try shed.getOrPut("https://a.com");
+    const b = try shed.getOrPut("https://b.com");
+    try std.testing.expect(a != b);
+    try std.testing.expect(a == try shed.getOrPut("https://a.com"));
+
+    try a.bucket.local._setItem("foo", "bar");
+    try std.testing.expect(null == b.bucket.local._getItem("foo"));
+
+    // opaque origin's shelves are never shared.
+    const o1 = try shed.createOpaque();
+    const o2 = try shed.createOpaque();
+    try std.testing.expect(o1 != o2);
+
+    try o1.bucket.local._setItem("foo", "bar");
+    try std.testing.expect(null == o2.bucket.local._getItem("foo"));
+
+    shed.releaseOpaque(o1);
+    try std.testing.expectEqual(@as(usize, 1), shed.opaques.items.len);
+    try std.testing.expect(o2 == shed.opaques.items[0]);
+}
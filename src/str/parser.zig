@@ -104,3 +104,51 @@ test "trim" {
     try testing.expectEqualStrings("foo", trim(" \n\tfoo"));
     try testing.expectEqualStrings("foo", trim("foo \n\t"));
 }
+
+// The JS strings are exchanged in UTF-8, the binary data is converted from
+// and to binary strings, one code point per byte.
+
+// binaryToBytes returns the bytes of a binary string, each code point being
+// a byte. A code point above 0xFF is an InvalidCharacter error.
+// The caller owns the bytes returned.
+pub fn binaryToBytes(alloc: std.mem.Allocator, str: []const u8) ![]u8 {
+    const view = std.unicode.Utf8View.init(str) catch return error.InvalidCharacter;
+
+    var bytes = try std.ArrayListUnmanaged(u8).initCapacity(alloc, str.len);
+    errdefer bytes.deinit(alloc);
+
+    var it = view.iterator();
+    while (it.nextCodepoint()) |cp| {
+        if (cp > 0xFF) return error.InvalidCharacter;
+        bytes.appendAssumeCapacity(@intCast(cp));
+    }
+    return try bytes.toOwnedSlice(alloc);
+}
+
+// bytesToBinary returns the binary string of bytes encoded in UTF-8.
+// The caller owns the string returned.
+pub fn bytesToBinary(alloc: std.mem.Allocator, bytes: []const u8) ![]const u8 {
+    var str = try std.ArrayListUnmanaged(u8).initCapacity(alloc, bytes.len * 2);
+    errdefer str.deinit(alloc);
+
+    for (bytes) |b| {
+        var buf: [2]u8 = undefined;
+        const n = std.unicode.utf8Encode(b, &buf) catch unreachable;
+        str.appendSliceAssumeCapacity(buf[0..n]);
+    }
+    return try str.toOwnedSlice(alloc);
+}
+
+test "binary strings" {
+    const alloc = testing.allocator;
+
+    const b = try binaryToBytes(alloc, "a\u{e9}\u{ff}\u{0}");
+    defer alloc.free(b);
+    try testing.expectEqualSlices(u8, &.{ 'a', 0xe9, 0xff, 0 }, b);
+
+    const s = try bytesToBinary(alloc, b);
+    defer alloc.free(s);
+    try testing.expectEqualStrings("a\u{e9}\u{ff}\u{0}", s);
+
+    try testing.expectError(error.InvalidCharacter, binaryToBytes(alloc, "\u{100}"));
+}
@@ -62,6 +62,8 @@ pub const Values = struct {
     // append by taking the ownership of the key and the value
     fn appendOwned(self: *Values, k: []const u8, v: []const u8) !void {
         if (self.map.getPtr(k)) |list| {
+            // the key is already owned by the map.
+            self.alloc.free(k);
             return try list.append(self.alloc, v);
         }
 
@@ -87,22 +89,71 @@ pub const Values = struct {
         return "";
     }
 
+    // set replaces all the values of the key by the given value, or append
+    // the key value couple if the key doesn't exist.
+    // the key and the value are duplicated.
+    pub fn set(self: *Values, k: []const u8, v: []const u8) !void {
+        const list = self.map.getPtr(k) orelse return self.append(k, v);
+
+        const vv = try self.alloc.dupe(u8, v);
+        errdefer self.alloc.free(vv);
+
+        for (list.items) |old| self.alloc.free(old);
+        list.clearRetainingCapacity();
+        try list.append(self.alloc, vv);
+    }
+
+    pub fn has(self: *Values, k: []const u8) bool {
+        return self.map.contains(k);
+    }
+
+    pub fn hasValue(self: *Values, k: []const u8, v: []const u8) bool {
+        const list = self.map.getPtr(k) orelse return false;
+        for (list.items) |vv| {
+            if (std.mem.eql(u8, v, vv)) return true;
+        }
+        return false;
+    }
+
+    // delete removes the key and all its values.
+    // The keys order is preserved.
     pub fn delete(self: *Values, k: []const u8) void {
-        if (self.map.getPtr(k)) |list| {
+        if (self.map.fetchOrderedRemove(k)) |kv| {
+            var list = kv.value;
+            for (list.items) |v| self.alloc.free(v);
             list.deinit(self.alloc);
-            _ = self.map.fetchSwapRemove(k);
+            self.alloc.free(kv.key);
         }
     }
 
+    // deleteValue removes all the values of the key matching the given value.
+    // The key is removed if it no longer has values.
     pub fn deleteValue(self: *Values, k: []const u8, v: []const u8) void {
         const list = self.map.getPtr(k) orelse return;
 
-        for (list.items, 0..) |vv, i| {
-            if (std.mem.eql(u8, v, vv)) {
-                _ = list.swapRemove(i);
-                return;
+        var i: usize = 0;
+        while (i < list.items.len) {
+            if (std.mem.eql(u8, v, list.items[i])) {
+                self.alloc.free(list.orderedRemove(i));
+                continue;
             }
+            i += 1;
         }
+
+        if (list.items.len == 0) self.delete(k);
+    }
+
+    // sort sorts the keys by code point order.
+    // The relative order of the values of a same key is preserved.
+    pub fn sort(self: *Values) void {
+        const Ctx = struct {
+            keys: [][]const u8,
+
+            pub fn lessThan(ctx: @This(), a: usize, b: usize) bool {
+                return std.mem.order(u8, ctx.keys[a], ctx.keys[b]) == .lt;
+            }
+        };
+        self.map.sort(Ctx{ .keys = self.map.keys() });
     }
 
     pub fn count(self: *Values) usize {
@@ -281,3 +332,33 @@ test "encode query" {
 
     try std.testing.expect(std.mem.eql(u8, buf.items, "a=b&a=%7E&b=c"));
 }
+
+test "delete query" {
+    var values = try parseQuery(std.testing.allocator, "a=b&b=c&a=d&c=e");
+    defer values.deinit();
+
+    values.deleteValue("a", "b");
+    try std.testing.expect(values.get("a").len == 1);
+    try std.testing.expect(std.mem.eql(u8, values.first("a"), "d"));
+
+    values.deleteValue("a", "d");
+    try std.testing.expect(!values.has("a"));
+
+    values.delete("b");
+    try std.testing.expect(values.count() == 1);
+    try std.testing.expect(values.has("c"));
+}
+
+test "sort query" {
+    var values = try parseQuery(std.testing.allocator, "c=1&a=2&b=3&a=1");
+    defer values.deinit();
+
+    values.sort();
+
+    var buf: std.ArrayListUnmanaged(u8) = .{};
+    defer buf.deinit(std.testing.allocator);
+
+    try values.encode(buf.writer(std.testing.allocator));
+
+    try std.testing.expect(std.mem.eql(u8, buf.items, "a=2&a=1&b=3&c=1"));
+}
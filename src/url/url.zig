@@ -52,7 +52,10 @@ pub const URL = struct {
     pub const mem_guarantied = true;
 
     pub fn constructor(alloc: std.mem.Allocator, url: []const u8, base: ?[]const u8) !URL {
-        const raw = try std.mem.concat(alloc, u8, &[_][]const u8{ url, base orelse "" });
+        const raw = if (base) |b| resolve(alloc, b, url) catch |e| switch (e) {
+            error.OutOfMemory => return e,
+            else => return error.TypeError,
+        } else try alloc.dupe(u8, url);
         errdefer alloc.free(raw);
 
         const uri = std.Uri.parse(raw) catch {
@@ -213,6 +216,39 @@ pub const URL = struct {
     }
 };
 
+// resolve returns the url resolved against the base url.
+// A base with an opaque path, like about:blank, resolves only the fragments.
+// The caller owns the returned string.
+// https://url.spec.whatwg.org/#concept-basic-url-parser
+pub fn resolve(alloc: std.mem.Allocator, base: []const u8, url: []const u8) ![]const u8 {
+    const base_uri = try std.Uri.parse(base);
+
+    const absolute = if (std.Uri.parse(url)) |_| true else |_| false;
+    if (!absolute and base_uri.host == null and !std.mem.startsWith(u8, uriComponentStr(base_uri.path), "/")) {
+        if (!std.mem.startsWith(u8, url, "#")) return error.InvalidBase;
+    }
+
+    // the buffer contains the url and the resolved path.
+    const buf = try alloc.alloc(u8, 2 * (base.len + url.len));
+    defer alloc.free(buf);
+
+    var b: []u8 = buf;
+    const uri = try std.Uri.resolve_inplace(base_uri, url, &b);
+
+    var res = std.ArrayList(u8).init(alloc);
+    defer res.deinit();
+
+    try uri.writeToStream(.{
+        .scheme = true,
+        .authentication = true,
+        .authority = true,
+        .path = true,
+        .query = true,
+        .fragment = true,
+    }, res.writer());
+    return try res.toOwnedSlice();
+}
+
 // uriComponentNullStr converts an optional std.Uri.Component to string value.
 // The string value can be undecoded.
 fn uriComponentNullStr(c: ?std.Uri.Component) []const u8 {
@@ -263,13 +299,36 @@ pub const URLSearchParams = struct {
         return self.values.first(name);
     }
 
+    pub fn _has(self: *URLSearchParams, name: []const u8, value: ?[]const u8) bool {
+        if (value) |v| return self.values.hasValue(name, v);
+
+        return self.values.has(name);
+    }
+
+    pub fn _set(self: *URLSearchParams, name: []const u8, value: []const u8) !void {
+        try self.values.set(name, value);
+    }
+
+    // the caller must free the returned string.
+    // TODO return a disposable string
+    // https://github.com/lightpanda-io/jsruntime-lib/issues/195
+    pub fn _toString(self: *URLSearchParams, alloc: std.mem.Allocator) ![]const u8 {
+        var buf: std.ArrayListUnmanaged(u8) = .{};
+        defer buf.deinit(alloc);
+
+        try self.values.encode(buf.writer(alloc));
+        return buf.toOwnedSlice(alloc);
+    }
+
     // TODO return generates an error: caught unexpected error 'TypeLookup'
     // pub fn _getAll(self: *URLSearchParams, name: []const u8) [][]const u8 {
     //     try self.values.get(name);
     // }
 
-    // TODO
-    pub fn _sort(_: *URLSearchParams) void {}
+    // https://url.spec.whatwg.org/#dom-urlsearchparams-sort
+    pub fn _sort(self: *URLSearchParams) void {
+        self.values.sort();
+    }
 };
 
 // Tests
@@ -293,6 +352,15 @@ pub fn testExecFn(
         .{ .src = "url.search", .ex = "?query" },
         .{ .src = "url.hash", .ex = "#fragment" },
         .{ .src = "url.searchParams.get('query')", .ex = "" },
+
+        .{ .src = "new URL('../baz?a=1', 'https://foo.bar/path/to/page').href", .ex = "https://foo.bar/path/baz?a=1" },
+        .{ .src = "new URL('//other.bar/x', 'https://foo.bar/path').href", .ex = "https://other.bar/x" },
+        .{ .src = "new URL('http://abs.bar/', 'https://foo.bar/path').href", .ex = "http://abs.bar/" },
+        .{ .src = "new URL('/root', 'https://foo.bar/a/b').pathname", .ex = "/root" },
+        .{ .src = "new URL('?b=2', 'https://foo.bar/path?a=1').href", .ex = "https://foo.bar/path?b=2" },
+        .{ .src = "new URL('#frag', 'about:blank').href", .ex = "about:blank#frag" },
+        .{ .src = "try { new URL('foo', 'about:blank'); false } catch (e) { true }", .ex = "true" },
+        .{ .src = "try { new URL('foo', 'not a url'); false } catch (e) { true }", .ex = "true" },
     };
     try checkCases(js_env, &url);
 
@@ -315,4 +383,21 @@ pub fn testExecFn(
         .{ .src = "url.searchParams.get('a')", .ex = "" },
     };
     try checkCases(js_env, &qs);
+
+    var mutation = [_]Case{
+        .{ .src = "var url = new URL('https://foo.bar/path?c=1&a=2&b=3&a=1#fragment')", .ex = "undefined" },
+        .{ .src = "url.searchParams.sort()", .ex = "undefined" },
+        .{ .src = "url.search", .ex = "?a=2&a=1&b=3&c=1" },
+        .{ .src = "url.searchParams.set('b', 'foo')", .ex = "undefined" },
+        .{ .src = "url.searchParams.set('d', 'bar')", .ex = "undefined" },
+        .{ .src = "url.searchParams.has('d')", .ex = "true" },
+        .{ .src = "url.searchParams.has('a', '1')", .ex = "true" },
+        .{ .src = "url.searchParams.delete('a', '1')", .ex = "undefined" },
+        .{ .src = "url.searchParams.has('a', '1')", .ex = "false" },
+        .{ .src = "url.searchParams.get('a')", .ex = "2" },
+        .{ .src = "url.searchParams.toString()", .ex = "a=2&b=foo&c=1&d=bar" },
+        .{ .src = "url.href", .ex = "https://foo.bar/path?a=2&b=foo&c=1&d=bar#fragment" },
+        .{ .src = "new URL(url.href).href == url.href", .ex = "true" },
+    };
+    try checkCases(js_env, &mutation);
 }
@@ -28,6 +28,7 @@ const Loop = jsruntime.Loop;
 const Env = jsruntime.Env;
 const Window = @import("../html/window.zig").Window;
 const storage = @import("../storage/storage.zig");
+const polyfill = @import("../polyfill/polyfill.zig");
 
 const Types = @import("../main_wpt.zig").Types;
 const UserContext = @import("../main_wpt.zig").UserContext;
@@ -88,6 +89,9 @@ pub fn run(arena: *std.heap.ArenaAllocator, comptime dir: []const u8, f: []const
     window.setStorageShelf(&storageShelf);
     try js_env.bindGlobal(&window);
 
+    // load polyfills
+    try polyfill.load(alloc, &js_env);
+
     // thanks to the arena, we don't need to deinit res.
     var res: jsruntime.JSResult = undefined;
 
@@ -144,6 +148,7 @@ pub fn run(arena: *std.heap.ArenaAllocator, comptime dir: []const u8, f: []const
     defer parser.eventDestroy(loadevt);
 
     try parser.eventInit(loadevt, "load", .{});
+    parser.eventSetTrusted(loadevt, true);
     _ = try parser.eventTargetDispatchEvent(
         parser.toEventTarget(Window, &window),
         loadevt,
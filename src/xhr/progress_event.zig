@@ -33,6 +33,9 @@ pub const ProgressEvent = struct {
     pub const mem_guarantied = true;
 
     pub const EventInit = struct {
+        bubbles: bool = false,
+        cancelable: bool = false,
+        composed: bool = false,
         lengthComputable: bool = false,
         loaded: u64 = 0,
         total: u64 = 0,
@@ -44,13 +47,17 @@ pub const ProgressEvent = struct {
     total: u64 = 0,
 
     pub fn constructor(eventType: []const u8, opts: ?EventInit) !ProgressEvent {
+        const o = opts orelse EventInit{};
+
         const event = try parser.eventCreate();
         defer parser.eventDestroy(event);
-        try parser.eventInit(event, eventType, .{});
+        try parser.eventInit(event, eventType, .{
+            .bubbles = o.bubbles,
+            .cancelable = o.cancelable,
+            .composed = o.composed,
+        });
         try parser.eventSetInternalType(event, .progress_event);
 
-        const o = opts orelse EventInit{};
-
         return .{
             .proto = event.*,
             .lengthComputable = o.lengthComputable,
@@ -79,6 +86,10 @@ pub fn testExecFn(
     var progress_event = [_]Case{
         .{ .src = "let pevt = new ProgressEvent('foo');", .ex = "undefined" },
         .{ .src = "pevt.loaded", .ex = "0" },
+        .{ .src = "pevt.bubbles", .ex = "false" },
+        .{ .src = "pevt.isTrusted", .ex = "false" },
+        .{ .src = "new ProgressEvent('foo', { bubbles: true, loaded: 2 }).bubbles", .ex = "true" },
+        .{ .src = "new ProgressEvent('foo', { composed: true }).composed", .ex = "true" },
         .{ .src = "pevt instanceof ProgressEvent", .ex = "true" },
         .{ .src = "var nnb = 0; var eevt = null; function ccbk(event) { nnb ++; eevt = event; }", .ex = "undefined" },
         .{ .src = "document.addEventListener('foo', ccbk)", .ex = "undefined" },
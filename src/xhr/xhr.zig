@@ -30,6 +30,7 @@ const ProgressEvent = @import("progress_event.zig").ProgressEvent;
 const XMLHttpRequestEventTarget = @import("event_target.zig").XMLHttpRequestEventTarget;
 
 const Mime = @import("../browser/mime.zig");
+const strparser = @import("../str/parser.zig");
 
 const Loop = jsruntime.Loop;
 const YieldImpl = Loop.Yield(XMLHttpRequest);
@@ -52,48 +53,11 @@ pub const Interfaces = generate.Tuple(.{
 pub const XMLHttpRequestUpload = struct {
     pub const prototype = *XMLHttpRequestEventTarget;
     pub const mem_guarantied = true;
+    pub const Exception = DOMException;
 
     proto: XMLHttpRequestEventTarget = XMLHttpRequestEventTarget{},
 };
 
-pub const XMLHttpRequestBodyInitTag = enum {
-    Blob,
-    BufferSource,
-    FormData,
-    URLSearchParams,
-    String,
-};
-
-pub const XMLHttpRequestBodyInit = union(XMLHttpRequestBodyInitTag) {
-    Blob: []const u8,
-    BufferSource: []const u8,
-    FormData: []const u8,
-    URLSearchParams: []const u8,
-    String: []const u8,
-
-    fn contentType(self: XMLHttpRequestBodyInit) ![]const u8 {
-        return switch (self) {
-            .Blob => error.NotImplemented,
-            .BufferSource => error.NotImplemented,
-            .FormData => "multipart/form-data; boundary=TODO",
-            .URLSearchParams => "application/x-www-form-urlencoded; charset=UTF-8",
-            .String => "text/plain; charset=UTF-8",
-        };
-    }
-
-    // Duplicate the body content.
-    // The caller owns the allocated string.
-    fn dupe(self: XMLHttpRequestBodyInit, alloc: std.mem.Allocator) ![]const u8 {
-        return switch (self) {
-            .Blob => error.NotImplemented,
-            .BufferSource => error.NotImplemented,
-            .FormData => error.NotImplemented,
-            .URLSearchParams => error.NotImplemented,
-            .String => |v| try alloc.dupe(u8, v),
-        };
-    }
-};
-
 pub const XMLHttpRequest = struct {
     proto: XMLHttpRequestEventTarget = XMLHttpRequestEventTarget{},
     alloc: std.mem.Allocator,
@@ -112,17 +76,18 @@ pub const XMLHttpRequest = struct {
     sync: bool = true,
     err: ?anyerror = null,
 
-    // TODO uncomment this field causes casting issue with
-    // XMLHttpRequestEventTarget. I think it's dueto an alignement issue, but
-    // not sure. see
+    // The upload is allocated separately: embedding the struct causes casting
+    // issue with XMLHttpRequestEventTarget. I think it's dueto an alignement
+    // issue, but not sure. see
     // https://lightpanda.slack.com/archives/C05TRU6RBM1/p1707819010681019
-    // upload: ?XMLHttpRequestUpload = null,
+    upload: *XMLHttpRequestUpload,
 
-    // TODO uncomment this field causes casting issue with
-    // XMLHttpRequestEventTarget. I think it's dueto an alignement issue, but
-    // not sure. see
-    // https://lightpanda.slack.com/archives/C05TRU6RBM1/p1707819010681019
-    // timeout: u32 = 0,
+    // timeout in milliseconds, 0 means no timeout.
+    // https://xhr.spec.whatwg.org/#timeout-error
+    timeout: u32 = 0,
+    // send_at is set by send() and used to check the timeout deadline, which
+    // is also set as the connection's read timeout.
+    send_at: ?std.time.Instant = null,
 
     withCredentials: bool = false,
     // TODO: response readonly attribute any response;
@@ -141,6 +106,10 @@ pub const XMLHttpRequest = struct {
     send_flag: bool = false,
 
     payload: ?[]const u8 = null,
+    // number of payload's bytes written.
+    payload_sent: usize = 0,
+    // https://xhr.spec.whatwg.org/#upload-complete-flag
+    upload_complete: bool = false,
 
     pub const prototype = *XMLHttpRequestEventTarget;
     pub const mem_guarantied = true;
@@ -283,9 +252,16 @@ pub const XMLHttpRequest = struct {
 
     const min_delay: u64 = 50000000; // 50ms
 
+    // size of the payload written between each upload progress event.
+    const upload_chunk_size = 64 * 1024;
+
     pub fn constructor(alloc: std.mem.Allocator, loop: *Loop, userctx: UserContext) !XMLHttpRequest {
+        const upload = try alloc.create(XMLHttpRequestUpload);
+        upload.* = .{};
+
         return .{
             .alloc = alloc,
+            .upload = upload,
             .headers = Headers.init(alloc),
             .response_headers = Headers.init(alloc),
             .impl = YieldImpl.init(loop),
@@ -303,13 +279,16 @@ pub const XMLHttpRequest = struct {
 
         if (self.payload) |v| alloc.free(v);
         self.payload = null;
+        self.payload_sent = 0;
+        self.upload_complete = false;
 
         if (self.response_bytes) |v| alloc.free(v);
+        self.response_bytes = null;
+
         if (self.response_obj) |v| v.deinit();
 
         self.response_obj = null;
         self.response_mime = Mime.Empty;
-        self.response_type = .Empty;
 
         // TODO should we clearRetainingCapacity instead?
         self.headers.clearAndFree();
@@ -317,13 +296,11 @@ pub const XMLHttpRequest = struct {
         self.response_status = 0;
 
         self.send_flag = false;
+        self.send_at = null;
 
         self.priv_state = .new;
 
-        if (self.req) |*r| {
-            r.deinit();
-            self.req = null;
-        }
+        self.deinitReq();
     }
 
     pub fn deinit(self: *XMLHttpRequest, alloc: std.mem.Allocator) void {
@@ -331,6 +308,9 @@ pub const XMLHttpRequest = struct {
         self.headers.deinit();
         self.response_headers.deinit();
 
+        self.upload.proto.deinit(alloc);
+        alloc.destroy(self.upload);
+
         self.proto.deinit(alloc);
     }
 
@@ -338,16 +318,71 @@ pub const XMLHttpRequest = struct {
         return self.state;
     }
 
-    pub fn get_timeout(_: *XMLHttpRequest) u32 {
-        return 0;
+    pub fn get_timeout(self: *XMLHttpRequest) u32 {
+        return self.timeout;
     }
 
-    // TODO, the value is ignored for now.
-    pub fn set_timeout(_: *XMLHttpRequest, _: u32) !void {
-        // TODO If the current global object is a Window object and this’s
+    // https://xhr.spec.whatwg.org/#dom-xmlhttprequest-timeout
+    pub fn set_timeout(self: *XMLHttpRequest, timeout: u32) !void {
+        // If the current global object is a Window object and this’s
         // synchronous flag is set, then throw an "InvalidAccessError"
         // DOMException.
-        // https://xhr.spec.whatwg.org/#dom-xmlhttprequest-timeout
+        if (self.state != UNSENT and self.sync) return DOMError.InvalidAccess;
+
+        self.timeout = timeout;
+    }
+
+    // timedOut returns true if a timeout is set and the time elapsed since
+    // send() exceeds it.
+    fn timedOut(self: *XMLHttpRequest) bool {
+        if (self.timeout == 0) return false;
+        const send_at = self.send_at orelse return false;
+
+        const now = std.time.Instant.now() catch |e| {
+            log.err("timeout check: {any}", .{e});
+            return false;
+        };
+        return now.since(send_at) > @as(u64, self.timeout) * std.time.ns_per_ms;
+    }
+
+    // armDeadline sets the connection's read timeout to the time left before
+    // the request's timeout, so a blocking read fails once it's reached.
+    fn armDeadline(self: *XMLHttpRequest) !void {
+        if (self.timeout == 0) return;
+        const send_at = self.send_at orelse return;
+        const conn = self.req.?.connection orelse return;
+
+        const deadline = @as(u64, self.timeout) * std.time.ns_per_ms;
+        const elapsed = (try std.time.Instant.now()).since(send_at);
+        if (elapsed >= deadline) return DOMError.Timeout;
+
+        // round up the time left, 0 would disable the read timeout.
+        try conn.setReadTimeout((deadline - elapsed + std.time.ns_per_ms - 1) / std.time.ns_per_ms);
+    }
+
+    // readErr converts the connection's read timeout into the request's
+    // timeout.
+    fn readErr(self: *XMLHttpRequest, err: anyerror) anyerror {
+        if (self.timeout != 0 and err == error.ConnectionTimedOut) return DOMError.Timeout;
+        return err;
+    }
+
+    // deinitReq releases the request, the connection goes back to the pool
+    // without read timeout.
+    fn deinitReq(self: *XMLHttpRequest) void {
+        if (self.req) |*r| {
+            if (self.timeout != 0) {
+                if (r.connection) |conn| conn.setReadTimeout(0) catch |e| {
+                    log.err("reset read timeout: {any}", .{e});
+                };
+            }
+            r.deinit();
+            self.req = null;
+        }
+    }
+
+    pub fn get_upload(self: *XMLHttpRequest) *XMLHttpRequestUpload {
+        return self.upload;
     }
 
     pub fn get_withCredentials(self: *XMLHttpRequest) bool {
@@ -379,11 +414,17 @@ pub const XMLHttpRequest = struct {
 
         self.method = try validMethod(method);
 
+        const sync = if (asyn) |b| !b else false;
+        // If async is false, the current global object is a Window object, and
+        // either this’s timeout is not 0 or this’s response type is not the
+        // empty string, then throw an "InvalidAccessError" DOMException.
+        if (sync and (self.timeout != 0 or self.response_type != .Empty)) return DOMError.InvalidAccess;
+
         self.reset(alloc);
 
         self.url = try alloc.dupe(u8, url);
         self.uri = std.Uri.parse(self.url.?) catch return DOMError.Syntax;
-        self.sync = if (asyn) |b| !b else false;
+        self.sync = sync;
 
         self.state = OPENED;
         self.dispatchEvt("readystatechange");
@@ -402,6 +443,7 @@ pub const XMLHttpRequest = struct {
         parser.eventInit(evt, typ, .{ .bubbles = true, .cancelable = true }) catch |e| {
             return log.err("dispatch event init: {any}", .{e});
         };
+        parser.eventSetTrusted(evt, true);
         _ = parser.eventTargetDispatchEvent(@as(*parser.EventTarget, @ptrCast(self)), evt) catch |e| {
             return log.err("dispatch event: {any}", .{e});
         };
@@ -411,6 +453,22 @@ pub const XMLHttpRequest = struct {
         self: *XMLHttpRequest,
         typ: []const u8,
         opts: ProgressEvent.EventInit,
+    ) void {
+        dispatchProgress(@as(*parser.EventTarget, @ptrCast(self)), typ, opts);
+    }
+
+    fn dispatchUploadProgressEvent(
+        self: *XMLHttpRequest,
+        typ: []const u8,
+        opts: ProgressEvent.EventInit,
+    ) void {
+        dispatchProgress(@as(*parser.EventTarget, @ptrCast(self.upload)), typ, opts);
+    }
+
+    fn dispatchProgress(
+        et: *parser.EventTarget,
+        typ: []const u8,
+        opts: ProgressEvent.EventInit,
     ) void {
         log.debug("dispatch progress event: {s}", .{typ});
         var evt = ProgressEvent.constructor(typ, .{
@@ -421,9 +479,10 @@ pub const XMLHttpRequest = struct {
         }) catch |e| {
             return log.err("construct progress event: {any}", .{e});
         };
+        parser.eventSetTrusted(&evt.proto, true);
 
         _ = parser.eventTargetDispatchEvent(
-            @as(*parser.EventTarget, @ptrCast(self)),
+            et,
             @as(*parser.Event, @ptrCast(&evt)),
         ) catch |e| {
             return log.err("dispatch progress event: {any}", .{e});
@@ -466,34 +525,54 @@ pub const XMLHttpRequest = struct {
         return try self.headers.append(name, value);
     }
 
-    // TODO body can be either a XMLHttpRequestBodyInit or a document
-    pub fn _send(self: *XMLHttpRequest, alloc: std.mem.Allocator, body: ?[]const u8) !void {
+    // The polyfill extracts the body, a string, a Blob, a FormData, an
+    // URLSearchParams or a buffer, into bytes encoded with one code point per
+    // byte, and gives its content type.
+    // TODO body can be a document
+    pub fn _send(
+        self: *XMLHttpRequest,
+        alloc: std.mem.Allocator,
+        body: ?[]const u8,
+        content_type: ?[]const u8,
+    ) !void {
         if (self.state != OPENED) return DOMError.InvalidState;
         if (self.send_flag) return DOMError.InvalidState;
 
         //  The body argument provides the request body, if any, and is ignored
         //  if the request method is GET or HEAD.
         //  https://xhr.spec.whatwg.org/#the-send()-method
-        // var used_body: ?XMLHttpRequestBodyInit = null;
         if (body != null and self.method != .GET and self.method != .HEAD) {
             // TODO If body is a Document, then set this’s request body to body, serialized, converted, and UTF-8 encoded.
 
-            const body_init = XMLHttpRequestBodyInit{ .String = body.? };
+            const payload = try strparser.binaryToBytes(alloc, body.?);
+            errdefer alloc.free(payload);
 
             // keep the user content type from request headers.
-            if (self.headers.has("Content-Type")) {
-                // https://fetch.spec.whatwg.org/#bodyinit-safely-extract
-                try self.headers.append("Content-Type", try body_init.contentType());
+            // https://fetch.spec.whatwg.org/#bodyinit-safely-extract
+            if (content_type) |ct| {
+                if (!self.headers.has("Content-Type")) try self.headers.append("Content-Type", ct);
             }
 
             // copy the payload
             if (self.payload) |v| alloc.free(v);
-            self.payload = try body_init.dupe(alloc);
+            self.payload = payload;
         }
 
         log.debug("{any} {any}", .{ self.method, self.uri });
 
         self.send_flag = true;
+        self.send_at = try std.time.Instant.now();
+
+        // Without body, the upload is complete.
+        // Otherwise the loadstart is dispatched now to the upload, so the
+        // listeners registered before send are called.
+        // TODO dispatch only if the upload has listeners.
+        self.payload_sent = 0;
+        self.upload_complete = self.payload == null;
+        if (self.payload) |v| {
+            self.dispatchUploadProgressEvent("loadstart", .{ .loaded = 0, .total = v.len });
+        }
+
         self.impl.yield(self);
     }
 
@@ -504,6 +583,9 @@ pub const XMLHttpRequest = struct {
     pub fn onYield(self: *XMLHttpRequest, err: ?anyerror) void {
         if (err) |e| return self.onErr(e);
 
+        // A timeout occuring after the response is received is ignored.
+        if (self.priv_state != .done and self.timedOut()) return self.onErr(DOMError.Timeout);
+
         switch (self.priv_state) {
             .new => {
                 self.priv_state = .open;
@@ -520,21 +602,35 @@ pub const XMLHttpRequest = struct {
                 self.req.?.send() catch |e| return self.onErr(e);
             },
             .send => {
-                if (self.payload) |payload| {
+                if (self.payload != null) {
                     self.priv_state = .write;
-                    self.req.?.writeAll(payload) catch |e| return self.onErr(e);
+                    self.writeChunk() catch |e| return self.onErr(e);
                 } else {
                     self.priv_state = .finish;
                     self.req.?.finish() catch |e| return self.onErr(e);
                 }
             },
             .write => {
-                self.priv_state = .finish;
-                self.req.?.finish() catch |e| return self.onErr(e);
+                const payload = self.payload.?;
+                if (self.payload_sent < payload.len) {
+                    self.writeChunk() catch |e| return self.onErr(e);
+                } else {
+                    // process request end-of-body.
+                    // https://xhr.spec.whatwg.org/#the-send()-method
+                    self.upload_complete = true;
+                    const total = payload.len;
+                    self.dispatchUploadProgressEvent("progress", .{ .loaded = total, .total = total });
+                    self.dispatchUploadProgressEvent("load", .{ .loaded = total, .total = total });
+                    self.dispatchUploadProgressEvent("loadend", .{ .loaded = total, .total = total });
+
+                    self.priv_state = .finish;
+                    self.req.?.finish() catch |e| return self.onErr(e);
+                }
             },
             .finish => {
                 self.priv_state = .wait;
-                self.req.?.wait() catch |e| return self.onErr(e);
+                self.armDeadline() catch |e| return self.onErr(e);
+                self.req.?.wait() catch |e| return self.onErr(self.readErr(e));
             },
             .wait => {
                 log.info("{any} {any} {d}", .{ self.method, self.uri, self.req.?.response.status });
@@ -568,10 +664,14 @@ pub const XMLHttpRequest = struct {
                 var ln = buffer.len;
                 var prev_dispatch: ?std.time.Instant = null;
                 while (ln > 0) {
-                    ln = reader.read(&buffer) catch |e| {
+                    self.armDeadline() catch |e| {
                         buf.deinit(self.alloc);
                         return self.onErr(e);
                     };
+                    ln = reader.read(&buffer) catch |e| {
+                        buf.deinit(self.alloc);
+                        return self.onErr(self.readErr(e));
+                    };
                     buf.appendSlice(self.alloc, buffer[0..ln]) catch |e| {
                         buf.deinit(self.alloc);
                         return self.onErr(e);
@@ -607,10 +707,7 @@ pub const XMLHttpRequest = struct {
                 self.dispatchProgressEvent("loadend", .{ .loaded = loaded, .total = total });
             },
             .done => {
-                if (self.req) |*r| {
-                    r.deinit();
-                    self.req = null;
-                }
+                self.deinitReq();
 
                 // finalize fetch process.
                 return;
@@ -620,18 +717,46 @@ pub const XMLHttpRequest = struct {
         self.impl.yield(self);
     }
 
+    // writeChunk writes the next payload's chunk and dispatches an upload
+    // progress event, except for the last chunk: the end of body dispatches
+    // it.
+    fn writeChunk(self: *XMLHttpRequest) !void {
+        const payload = self.payload.?;
+        const end = @min(payload.len, self.payload_sent + upload_chunk_size);
+
+        try self.req.?.writeAll(payload[self.payload_sent..end]);
+        self.payload_sent = end;
+
+        if (end < payload.len) {
+            self.dispatchUploadProgressEvent("progress", .{ .loaded = end, .total = payload.len });
+        }
+    }
+
     fn onErr(self: *XMLHttpRequest, err: anyerror) void {
         self.priv_state = .done;
-        if (self.req) |*r| {
-            r.deinit();
-            self.req = null;
-        }
+        self.deinitReq();
 
         self.err = err;
         self.state = DONE;
         self.send_flag = false;
+        // the response is a network error.
+        self.response_status = 0;
         self.dispatchEvt("readystatechange");
-        self.dispatchProgressEvent("error", .{});
+
+        const typ = switch (err) {
+            DOMError.Timeout => "timeout",
+            DOMError.Abort => "abort",
+            else => "error",
+        };
+
+        // https://xhr.spec.whatwg.org/#request-error-steps
+        if (!self.upload_complete) {
+            self.upload_complete = true;
+            self.dispatchUploadProgressEvent(typ, .{});
+            self.dispatchUploadProgressEvent("loadend", .{});
+        }
+
+        self.dispatchProgressEvent(typ, .{});
         self.dispatchProgressEvent("loadend", .{});
 
         log.debug("{any} {any} {any}", .{ self.method, self.uri, self.err });
@@ -654,6 +779,10 @@ pub const XMLHttpRequest = struct {
 
     pub fn set_responseType(self: *XMLHttpRequest, rtype: []const u8) !void {
         if (self.state == LOADING or self.state == DONE) return DOMError.InvalidState;
+        // If the current global object is a Window object and this’s
+        // synchronous flag is set, then throw an "InvalidAccessError"
+        // DOMException.
+        if (self.state != UNSENT and self.sync) return DOMError.InvalidAccess;
 
         if (std.mem.eql(u8, rtype, "")) {
             self.response_type = .Empty;
@@ -717,10 +846,13 @@ pub const XMLHttpRequest = struct {
     // https://xhr.spec.whatwg.org/#the-response-attribute
     pub fn get_response(self: *XMLHttpRequest, alloc: std.mem.Allocator) !?Response {
         if (self.response_type == .Empty or self.response_type == .Text) {
-            if (self.state == LOADING or self.state == DONE) return .{ .Text = "" };
+            if (self.state != LOADING and self.state != DONE) return .{ .Text = "" };
             return .{ .Text = try self.get_responseText() };
         }
 
+        // If this’s state is not done, then return null.
+        if (self.state != DONE) return null;
+
         // fastpath if response is previously parsed.
         if (self.response_obj) |obj| {
             return switch (obj) {
@@ -731,11 +863,10 @@ pub const XMLHttpRequest = struct {
         }
 
         if (self.response_type == .ArrayBuffer) {
-            // TODO If this’s response type is "arraybuffer", then set this’s
-            // response object to a new ArrayBuffer object representing this’s
-            // received bytes. If this throws an exception, then set this’s
-            // response object to failure and return null.
-            return null;
+            // ArrayBuffer is not supported by the native bindings, the
+            // received bytes are returned as a string with one code point per
+            // byte and the ArrayBuffer is created by the xhr polyfill.
+            return .{ .Text = try strparser.bytesToBinary(alloc, self.response_bytes orelse "") };
         }
 
         if (self.response_type == .Blob) {
@@ -905,7 +1036,7 @@ pub fn testExecFn(
         .{ .src = "req.getResponseHeader('Content-Type')", .ex = "text/html; charset=utf-8" },
         .{ .src = "req.getAllResponseHeaders().length > 64", .ex = "true" },
         .{ .src = "req.responseText.length > 64", .ex = "true" },
-        .{ .src = "req.response", .ex = "" },
+        .{ .src = "req.response === req.responseText", .ex = "true" },
         .{ .src = "req.responseXML instanceof Document", .ex = "true" },
     };
     try checkCases(js_env, &send);
@@ -938,9 +1069,46 @@ pub fn testExecFn(
         .{ .src = "req3.status", .ex = "200" },
         .{ .src = "req3.statusText", .ex = "OK" },
         .{ .src = "req3.response.slideshow.author", .ex = "Yours Truly" },
+        .{ .src = "req3.response === req3.response", .ex = "true" },
+        .{ .src = "var err; try { req3.responseText } catch (e) { err = e } err.name", .ex = "InvalidStateError" },
+        .{ .src = "try { req3.responseType = 'text' } catch (e) { err = e } err.name", .ex = "InvalidStateError" },
     };
     try checkCases(js_env, &json);
 
+    var json_failure = [_]Case{
+        .{ .src = "const req7 = new XMLHttpRequest()", .ex = "undefined" },
+        .{ .src = "req7.open('GET', 'http://httpbin.io/html')", .ex = "undefined" },
+        .{ .src = "req7.responseType = 'json'", .ex = "json" },
+        .{ .src = "req7.response", .ex = "null" },
+
+        .{ .src = "req7.send()", .ex = "undefined" },
+
+        // Each case executed waits for all loop callaback calls.
+        // So the url has been retrieved.
+        .{ .src = "req7.status", .ex = "200" },
+        // an invalid JSON response is null.
+        .{ .src = "req7.response", .ex = "null" },
+    };
+    try checkCases(js_env, &json_failure);
+
+    var arraybuffer = [_]Case{
+        .{ .src = "const req8 = new XMLHttpRequest()", .ex = "undefined" },
+        .{ .src = "req8.open('GET', 'http://httpbin.io/json')", .ex = "undefined" },
+        .{ .src = "req8.responseType = 'arraybuffer'", .ex = "arraybuffer" },
+
+        .{ .src = "req8.send()", .ex = "undefined" },
+
+        // Each case executed waits for all loop callaback calls.
+        // So the url has been retrieved.
+        .{ .src = "req8.status", .ex = "200" },
+        .{ .src = "req8.response instanceof ArrayBuffer", .ex = "true" },
+        .{ .src = "req8.response === req8.response", .ex = "true" },
+        .{ .src = "req8.response.byteLength > 64", .ex = "true" },
+        .{ .src = "new Uint8Array(req8.response)[0]", .ex = "123" },
+        .{ .src = "JSON.parse(new TextDecoder().decode(req8.response)).slideshow.author", .ex = "Yours Truly" },
+    };
+    try checkCases(js_env, &arraybuffer);
+
     var post = [_]Case{
         .{ .src = "const req4 = new XMLHttpRequest()", .ex = "undefined" },
         .{ .src = "req4.open('POST', 'http://httpbin.io/post')", .ex = "undefined" },
@@ -954,6 +1122,47 @@ pub fn testExecFn(
     };
     try checkCases(js_env, &post);
 
+    var upload = [_]Case{
+        .{ .src = "const req9 = new XMLHttpRequest()", .ex = "undefined" },
+        .{ .src = "req9.upload instanceof XMLHttpRequestUpload", .ex = "true" },
+        .{ .src = "req9.upload === req9.upload", .ex = "true" },
+        .{ .src = "req9.open('POST', 'http://httpbin.io/post')", .ex = "undefined" },
+        .{ .src = "var uevts = []; var utotal = 0; function ucbk(e) { uevts.push(e.type); utotal = e.total };", .ex = "undefined" },
+        .{ .src = "req9.upload.onloadstart = ucbk; req9.upload.onprogress = ucbk; req9.upload.onload = ucbk; req9.upload.onloadend = ucbk;", .ex = "function ucbk(e) { uevts.push(e.type); utotal = e.total }" },
+        .{ .src = "req9.send('foobar')", .ex = "undefined" },
+
+        // Each case executed waits for all loop callaback calls.
+        // So the body has been sent.
+        .{ .src = "uevts.join(',')", .ex = "loadstart,progress,load,loadend" },
+        .{ .src = "utotal", .ex = "6" },
+        .{ .src = "req9.status", .ex = "200" },
+    };
+    try checkCases(js_env, &upload);
+
+    var upload_body = [_]Case{
+        .{ .src = "const req10 = new XMLHttpRequest()", .ex = "undefined" },
+        .{ .src = "req10.open('POST', 'http://httpbin.io/post')", .ex = "undefined" },
+        .{ .src = "req10.upload.onloadend = ucbk", .ex = "function ucbk(e) { uevts.push(e.type); utotal = e.total }" },
+        .{ .src = "req10.send(new Uint8Array([0, 128, 255]).buffer)", .ex = "undefined" },
+        .{ .src = "utotal", .ex = "3" },
+        .{ .src = "req10.status", .ex = "200" },
+
+        .{ .src = "const req11 = new XMLHttpRequest()", .ex = "undefined" },
+        .{ .src = "req11.open('POST', 'http://httpbin.io/post')", .ex = "undefined" },
+        .{ .src = "req11.upload.onloadend = ucbk", .ex = "function ucbk(e) { uevts.push(e.type); utotal = e.total }" },
+        .{ .src = "req11.send(new Blob(['\\u00e9t\\u00e9'], { type: 'text/plain' }))", .ex = "undefined" },
+        .{ .src = "utotal", .ex = "5" },
+        .{ .src = "req11.status", .ex = "200" },
+        .{ .src = "JSON.parse(req11.responseText).headers['Content-Type'][0]", .ex = "text/plain" },
+
+        .{ .src = "const req12 = new XMLHttpRequest()", .ex = "undefined" },
+        .{ .src = "req12.open('POST', 'http://httpbin.io/post')", .ex = "undefined" },
+        .{ .src = "req12.send(new URLSearchParams('a=1'))", .ex = "undefined" },
+        .{ .src = "req12.status", .ex = "200" },
+        .{ .src = "JSON.parse(req12.responseText).form.a[0]", .ex = "1" },
+    };
+    try checkCases(js_env, &upload_body);
+
     var cbk = [_]Case{
         .{ .src = "const req5 = new XMLHttpRequest()", .ex = "undefined" },
         .{ .src = "req5.open('GET', 'http://httpbin.io/json')", .ex = "undefined" },
@@ -965,4 +1174,22 @@ pub fn testExecFn(
         .{ .src = "status", .ex = "200" },
     };
     try checkCases(js_env, &cbk);
+
+    var timeout = [_]Case{
+        .{ .src = "const req6 = new XMLHttpRequest()", .ex = "undefined" },
+        .{ .src = "req6.timeout", .ex = "0" },
+        .{ .src = "req6.timeout = 100", .ex = "100" },
+        .{ .src = "req6.timeout", .ex = "100" },
+        .{ .src = "var err; try { req6.open('GET', 'http://httpbin.io/delay/2', false) } catch (e) { err = e } err.name", .ex = "InvalidAccessError" },
+        .{ .src = "req6.open('GET', 'http://httpbin.io/delay/2')", .ex = "undefined" },
+        .{ .src = "var evts = []; req6.ontimeout = function (e) { evts.push(e.type) }; req6.onloadend = function (e) { evts.push(e.type) };", .ex = "function (e) { evts.push(e.type) }" },
+        .{ .src = "req6.send()", .ex = "undefined" },
+
+        // Each case executed waits for all loop callaback calls.
+        // So the request has timed out.
+        .{ .src = "evts.join(',')", .ex = "timeout,loadend" },
+        .{ .src = "req6.readyState", .ex = "4" },
+        .{ .src = "req6.status", .ex = "0" },
+    };
+    try checkCases(js_env, &timeout);
 }